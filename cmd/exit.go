@@ -0,0 +1,56 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/samber/oops"
+)
+
+// 程序的退出码，便于脚本判断执行结果。
+const (
+	ExitOK              = 0   // 全部成功
+	ExitError           = 1   // 其他错误
+	ExitInvalidArgument = 2   // 配置或参数不合法
+	ExitUnauthenticated = 3   // 身份验证失败，如应用ID或密钥不正确
+	ExitPartialFailure  = 4   // 部分文档导出失败
+	ExitInterrupted     = 130 // 被用户中断，如按下Ctrl+C或提前退出下载UI
+)
+
+// ExitCode 根据执行返回的错误得到程序的退出码。
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var oopsError oops.OopsError
+	if errors.As(err, &oopsError) {
+		switch oopsError.Code() {
+		case "InvalidArgument":
+			return ExitInvalidArgument
+		case "Unauthenticated":
+			return ExitUnauthenticated
+		case "PartialFailure":
+			return ExitPartialFailure
+		case "Interrupted":
+			return ExitInterrupted
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+	return ExitError
+}
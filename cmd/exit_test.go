@@ -0,0 +1,47 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/samber/oops"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"成功", nil, ExitOK},
+		{"普通错误", errors.New("未知错误"), ExitError},
+		{"没有错误码", oops.Errorf("未知错误"), ExitError},
+		{"参数不合法", oops.Code("InvalidArgument").Errorf("dir是必需参数"), ExitInvalidArgument},
+		{"多层包装的参数不合法", oops.Wrap(oops.Wrapf(oops.Code("InvalidArgument").Errorf("dir是必需参数"), "校验失败")), ExitInvalidArgument},
+		{"身份验证失败", oops.Code("Unauthenticated").Errorf("msg:app secret invalid,code:10014"), ExitUnauthenticated},
+		{"部分文档导出失败", oops.Code("PartialFailure").Errorf("部分文档导出失败"), ExitPartialFailure},
+		{"被中断", oops.Code("Interrupted").Errorf("导出被中断"), ExitInterrupted},
+		{"读取文档树时被中断", oops.Wrap(context.Canceled), ExitInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
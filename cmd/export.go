@@ -19,6 +19,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/samber/oops"
@@ -44,6 +45,9 @@ type exportCommand struct {
 	args       *argument.Args
 	subs       []command
 	subCommand string
+	job        string // --job 指定的导出任务名称
+	all        bool   // --all 是否运行全部导出任务
+	now        func() time.Time
 }
 
 func (c *exportCommand) init(vip *viper.Viper, args *argument.Args) {
@@ -59,19 +63,27 @@ func (c *exportCommand) init(vip *viper.Viper, args *argument.Args) {
 【指向下级命令】
 ./xdoc export feishu --help
 ./xdoc export feishu --config ./local.yaml
-./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls https://xxx.feishu.cn/wiki/123456789`,
+./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls https://xxx.feishu.cn/wiki/123456789
+【运行配置文件export.jobs中的导出任务】
+./xdoc export --config ./local.yaml --job wiki
+./xdoc export --config ./local.yaml --all`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return c.exec()
 		},
 	}
 	c.vip = vip
 	c.args = args
+	c.now = time.Now
 }
 
 func (c *exportCommand) bind() (err error) {
 	persistentFlags := c.Command.PersistentFlags()
 	persistentFlags.BoolP(flagNameListOnly, "l", false, "是否只列出云文档信息不进行导出下载")
 	_ = c.vip.BindPFlag(commandNameExport+"."+flagNameListOnly, persistentFlags.Lookup(flagNameListOnly))
+	// --job 和 --all 只用于命令行，不绑定 Viper
+	flags := c.Command.Flags()
+	flags.StringVar(&c.job, flagNameJob, "", "只运行配置文件export.jobs中指定名称的导出任务")
+	flags.BoolVar(&c.all, flagNameAll, false, "按顺序运行配置文件export.jobs中的全部导出任务, 最后输出每个任务的结果")
 	osArgs := os.Args[1:]
 	if len(osArgs) >= 2 {
 		second := osArgs[1]
@@ -101,6 +113,10 @@ func (c *exportCommand) children() []command {
 }
 
 func (c *exportCommand) exec() error {
+	// 指定了 --job 或 --all 时运行export.jobs中的导出任务
+	if c.job != "" || c.all {
+		return c.execJobs(c.job, c.all)
+	}
 	// export命令没有定义对应的flag参数，仅支持从配置文件或环境变量中取值
 	// 从配置文件或环境变量中取值判断是否启用飞书导出功能
 	if c.subCommand == "" && c.vip.GetBool(viperKeyFeishuEnabled) {
@@ -15,13 +15,19 @@
 package cmd
 
 import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/samber/lo"
 	"github.com/samber/oops"
+	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -36,14 +42,38 @@ import (
 const (
 	commandNameFeishu = "feishu"
 
-	flagNameAppID          = "app-id"          //    --app-id
-	flagNameAppSecret      = "app-secret"      //    --app-secret
-	flagNameURLs           = "urls"            //    --urls
-	flagNameDir            = "dir"             //    --dir
-	flagNameExt            = "ext"             //    --ext
-	flagNameFileExtensions = "file.extensions" //    --ext
+	flagNameAppID            = "app-id"             //    --app-id
+	flagNameAppSecret        = "app-secret"         //    --app-secret
+	flagNameURLs             = "urls"               //    --urls
+	flagNameDir              = "dir"                //    --dir
+	flagNameExt              = "ext"                //    --ext
+	flagNameFileExtensions   = "file.extensions"    //    --ext
+	flagNameIncremental      = "incremental"        //    --incremental
+	flagNameResume           = "resume"             //    --resume
+	flagNamePrune            = "prune"              //    --prune
+	flagNameRewriteDocxLinks = "rewrite-docx-links" //    --rewrite-docx-links
+	flagNameExportWorkers    = "export-workers"     //    --export-workers
+	flagNameDownloadWorkers  = "download-workers"   //    --download-workers
+	flagNameQueueSize        = "queue-size"         //    --queue-size
+	flagNameRateLimits       = "rate-limits"        //    --rate-limits
+	flagNameDiscoverTimeout  = "discover-timeout"   //    --discover-timeout
+	flagNameReportFormats    = "report-formats"     //    --report-formats
+	flagNameRetryFailed      = "retry-failed"       //    --retry-failed
+	flagNameInclude          = "include"            //    --include
+	flagNameExclude          = "exclude"            //    --exclude
+	flagNameTypes            = "types"              //    --types
+	flagNameMaxDepth         = "max-depth"          //    --max-depth
+	flagNameModifiedSince    = "modified-since"     //    --modified-since
+	flagNameModifiedBefore   = "modified-before"    //    --modified-before
+	flagNameOwner            = "owner"              //    --owner
+	flagNamePathTemplate     = "path-template"      //    --path-template
+	flagNameDuplicateNames   = "duplicate-names"    //    --duplicate-names
+	flagNameNamePolicy       = "name-policy"        //    --name-policy
+	flagNameUnicodeForm      = "unicode-form"       //    --unicode-form
+	flagNameMaxPathLength    = "max-path-length"    //    --max-path-length
 
 	viperKeyPrefix = "export.feishu."
+	feishuHost     = "feishu.cn" // 重试失败的文档且没有指定文档地址时使用的文档来源域名
 )
 
 type exportFeishuCommand struct {
@@ -83,14 +113,51 @@ func (c *exportFeishuCommand) bind() (err error) {
 	flags.String(flagNameAppSecret, "", "飞书应用密钥")
 	flags.StringSlice(flagNameURLs, []string{}, "文档地址, 如 https://sample.feishu.cn/wiki/MP4PwXweMi2FydkkG0ScNwBdnLz")
 	flags.String(flagNameDir, "", "文档存放目录(本地)")
-	flags.StringToString(flagNameExt, map[string]string{}, `文档扩展名映射, 用于指定文档下载后的文件类型, 如 docx=docx,doc=pdf
+	flags.StringToString(flagNameExt, map[string]string{}, `文档扩展名映射, 用于指定文档下载后的文件类型, 如 docx=docx,doc=pdf, 新版文档docx还支持md, 多维表格bitable还支持json(都在本地转换)
 对应配置文件参数 export.feishu.file.extensions`)
+	flags.Bool(flagNameIncremental, false, "是否增量导出, 与上一次的document-tree.json对比, 跳过未变更的文档")
+	flags.Bool(flagNameResume, false, "是否从上一次中断的位置恢复导出, 跳过export-journal.jsonl中已完成的文档")
+	flags.String(flagNamePrune, feishu.PruneOff, `清理云文档已删除或已移动的本地文件(只清理上一次document-tree.json中记录的文件), 可选值:
+off: 不清理
+dry-run: 只列出将被清理的文件和目录, 不导出也不清理
+delete: 先列出再删除
+quarantine: 先列出再移动到dir中的.xdoc-trash目录`)
+	flags.Bool(flagNameRewriteDocxLinks, false, "是否将docx文件中指向本次已导出文档的超链接也改写为本地相对路径(md文件总是会改写)")
+	flags.Int(flagNameExportWorkers, feishu.DefaultExportWorkers, "同时创建导出任务的协程数量")
+	flags.Int(flagNameDownloadWorkers, feishu.DefaultDownloadWorkers, "同时下载文件的协程数量")
+	flags.Int(flagNameQueueSize, feishu.DefaultQueueSize, "等待下载的导出结果队列大小, 队列满时暂停创建导出任务")
+	flags.StringToInt(flagNameRateLimits, map[string]int{}, `各类接口每分钟最多请求次数, 用于客户端限流, 如 wiki=100,export=100, 设置为0表示不限流
+可选的接口类别: drive,download,wiki,export,docx,sheets,bitable
+对应配置文件参数 export.feishu.rate-limits`)
+	flags.Duration(flagNameDiscoverTimeout, 0, "读取云文档信息(阶段1)的超时时间, 如 30m, 为0时不限制")
+	flags.StringSlice(flagNameReportFormats, []string{}, "除export-report.json外额外生成的导出报告格式, 可选值: html,md")
+	flags.String(flagNameRetryFailed, "", `只重试上一次失败的文档, 指定上一次的导出报告export-report.json或导出日志export-journal.jsonl,
+文档信息取自dir中的document-tree.json, 不再读取文档树, 此时可以不指定urls`)
+	flags.StringSlice(flagNameInclude, []string{}, `只导出路径匹配这些glob模式的文档(及其子树), 路径与阶段1打印的目录结构一致, 如 "知识库/产品/**",
+*匹配一级路径内的任意字符, ?匹配一个字符, **匹配任意多级路径`)
+	flags.StringSlice(flagNameExclude, []string{}, `不导出路径匹配这些glob模式的文档及其子树, 如 "**/归档", 优先于--include`)
+	flags.StringSlice(flagNameTypes, []string{}, "只导出这些类型的文档, 如 docx,sheet,bitable,pdf")
+	flags.Int(flagNameMaxDepth, 0, "只导出文档树中前几层的文档, 文档地址对应的节点为第1层, 为0时不限制")
+	flags.String(flagNameModifiedSince, "", `只导出在此时间及之后编辑过的文档, 如 2025-01-01、2025-01-01 08:00:00,
+也可以是相对时长, 如 30d 表示最近30天, 12h 表示最近12小时`)
+	flags.String(flagNameModifiedBefore, "", "只导出在此时间之前编辑过的文档, 格式同--modified-since")
+	flags.StringSlice(flagNameOwner, []string{}, "只导出这些用户所有的文档, 值为所有者的open_id, 如 ou_xxx")
+	flags.String(flagNamePathTemplate, "", `文件保存路径模板(相对于dir), 如 "{space}/{path}/{title}_{token}.{ext}"、"{type}/{title}.{ext}", 为空时按文档层级保存
+可用的占位符: {title} {token} {node_token} {type} {ext} {space} {path} {date} {depth}`)
+	flags.String(flagNameDuplicateNames, feishu.DuplicateNamesIndex, `同级重名文档的文件名区分策略, 可选值:
+index: 追加序号, 沿用上一次document-tree.json中的文件名, 新增的文档按创建时间排序后编号
+token: 追加文档token`)
+	flags.String(flagNameNamePolicy, feishu.NamePolicyPortable, `文件名清理策略, 可选值:
+portable: 在Windows、macOS、Linux上都合法, 替换\:*?"<>|等字符, 去掉末尾的点和空格, 处理CON、NUL等保留设备名
+posix: 只替换/和控制字符`)
+	flags.String(flagNameUnicodeForm, feishu.UnicodeFormNFC, "文件名的Unicode规范化形式, 可选值: nfc、nfd、none")
+	flags.Int(flagNameMaxPathLength, 0, "保存路径(绝对路径)的最大长度, 超过时截断文件名并保留扩展名, 为0时Windows为259, 其他系统为4095")
 
 	// 绑定 Viper
 	flags.VisitAll(func(flag *pflag.Flag) {
 		switch flag.Name {
-		case flagNameExt:
-			// 不绑定，因为要实现 --ext 参数【局部覆盖】fileExtensions中的key的效果
+		case flagNameExt, flagNameRateLimits:
+			// 不绑定，因为要实现 --ext 和 --rate-limits 参数【局部覆盖】配置文件中的key的效果
 			return
 		default:
 			_ = c.vip.BindPFlag(viperKeyPrefix+flag.Name, flag)
@@ -107,13 +174,18 @@ func (c *exportFeishuCommand) children() []command {
 	return []command{}
 }
 
-func (c *exportFeishuCommand) exec() (err error) {
-	out := c.OutOrStdout()
-	args := c.args
-	err = setArgs(c.Command, c.vip, args)
+func (c *exportFeishuCommand) exec() error {
+	err := setArgs(c.Command, c.vip, c.args)
 	if err != nil {
 		return oops.Wrap(err)
 	}
+	return runExport(c.OutOrStdout(), c.args)
+}
+
+// runExport 输出本次使用的参数，校验后执行一次飞书云文档导出。
+func runExport(out io.Writer, args *feishu.Args) (err error) {
+	// json进度输出到标准输出时，参数和耗时等日志改为输出到标准错误
+	out = args.LogOutput(out)
 	args.StartTime = time.Now()
 	defer func() {
 		duration := time.Since(args.StartTime)
@@ -146,27 +218,49 @@ func (c *exportFeishuCommand) exec() (err error) {
 	app.Fprintf(out, " SaveDir: %s\n", args.SaveDir)
 	app.Fprintf(out, " FileExtensions: %v\n", args.FileExtensions)
 	app.Fprintf(out, " ListOnly: %v\n", args.ListOnly)
+	app.Fprintf(out, " Incremental: %v\n", args.Incremental)
+	app.Fprintf(out, " Resume: %v\n", args.Resume)
+	app.Fprintf(out, " Prune: %s\n", args.Prune)
+	app.Fprintf(out, " RewriteDocxLinks: %v\n", args.RewriteDocxLinks)
+	app.Fprintf(out, " ExportWorkers: %d\n", args.ExportWorkers)
+	app.Fprintf(out, " DownloadWorkers: %d\n", args.DownloadWorkers)
+	app.Fprintf(out, " QueueSize: %d\n", args.QueueSize)
+	app.Fprintf(out, " RateLimits: %v\n", args.RateLimits)
+	app.Fprintf(out, " DiscoverTimeout: %s\n", args.DiscoverTimeout)
+	app.Fprintf(out, " ReportFormats: %v\n", args.ReportFormats)
+	app.Fprintf(out, " RetryFailed: %s\n", args.RetryFailed)
+	app.Fprintf(out, " Include: %v\n", args.Include)
+	app.Fprintf(out, " Exclude: %v\n", args.Exclude)
+	app.Fprintf(out, " Types: %v\n", args.Types)
+	app.Fprintf(out, " MaxDepth: %d\n", args.MaxDepth)
+	app.Fprintf(out, " ModifiedSince: %s\n", args.ModifiedSince)
+	app.Fprintf(out, " ModifiedBefore: %s\n", args.ModifiedBefore)
+	app.Fprintf(out, " Owners: %v\n", args.Owners)
+	app.Fprintf(out, " PathTemplate: %s\n", args.PathTemplate)
+	app.Fprintf(out, " DuplicateNames: %s\n", args.DuplicateNames)
+	app.Fprintf(out, " NamePolicy: %s\n", args.NamePolicy)
+	app.Fprintf(out, " UnicodeForm: %s\n", args.UnicodeForm)
+	app.Fprintf(out, " MaxPathLength: %d\n", args.MaxPathLength)
 	app.Fprintf(out, " QuitAutomatically: %v\n", args.QuitAutomatically)
+	app.Fprintf(out, " Progress: %s\n", args.Progress)
+	app.Fprintf(out, " ProgressFile: %s\n", args.ProgressFile)
 	app.Fprintln(out, "----------------------------------------------")
 	if err = args.Validate(); err != nil {
 		return oops.Wrap(err)
 	}
 	// 先通过文档地址获取文件类型和token
-	var gotHost string
-	var docSources []*cloud.DocumentSource
-	for _, docURL := range args.DocURLs {
-		host, typ, token, err := analysisURL(docURL)
-		if err != nil {
-			return oops.Wrap(err)
-		}
-		if gotHost == "" {
-			gotHost = host
-		} else if gotHost != host {
-			return oops.Errorf("文档地址不匹配, 请确保所有文档地址都是同一域名")
-		}
-		docSources = append(docSources, &cloud.DocumentSource{Type: typ, Token: token})
+	host, docSources, err := parseDocSources(args)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	// 收到中断信号时取消上下文，中断进行中的请求和下载
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = doExport(ctx, args, host, docSources, nil)
+	if err == nil && ctx.Err() != nil {
+		// 收到过中断信号但执行过程没有返回错误，也视为被中断
+		err = oops.Code("Interrupted").Errorf("导出被中断")
 	}
-	err = doExport(args, gotHost, docSources)
 	return oops.Wrap(err)
 }
 
@@ -180,31 +274,95 @@ func setArgs(cmd *cobra.Command, vip *viper.Viper, args *feishu.Args) error {
 	args.SaveDir = vip.GetString(getFlagName(flagNameDir))
 	args.SaveDir = filepath.Clean(args.SaveDir)
 	args.SetFileExtensions(vip.GetStringMapString(getFlagName(flagNameFileExtensions)))
-	overrides, err := cmd.Flags().GetStringToString(flagNameExt)
+	// 只有飞书导出命令有 --ext 和 --rate-limits 参数，其他命令(如schedule)只使用配置中的值
+	flags := cmd.Flags()
+	if flags.Lookup(flagNameExt) != nil {
+		overrides, err := flags.GetStringToString(flagNameExt)
+		if err != nil {
+			return oops.Wrap(err)
+		}
+		args.SetFileExtensions(overrides)
+	}
+	args.Incremental = vip.GetBool(getFlagName(flagNameIncremental))
+	args.Resume = vip.GetBool(getFlagName(flagNameResume))
+	args.Prune = vip.GetString(getFlagName(flagNamePrune))
+	args.RewriteDocxLinks = vip.GetBool(getFlagName(flagNameRewriteDocxLinks))
+	args.ExportWorkers = vip.GetInt(getFlagName(flagNameExportWorkers))
+	args.DownloadWorkers = vip.GetInt(getFlagName(flagNameDownloadWorkers))
+	args.QueueSize = vip.GetInt(getFlagName(flagNameQueueSize))
+	rateLimits, err := cast.ToStringMapIntE(vip.GetStringMap(getFlagName(flagNameRateLimits)))
 	if err != nil {
-		return oops.Wrap(err)
+		return oops.Wrapf(err, "rate-limits配置不合法")
+	}
+	args.SetRateLimits(rateLimits)
+	if flags.Lookup(flagNameRateLimits) != nil {
+		rateLimits, err = flags.GetStringToInt(flagNameRateLimits)
+		if err != nil {
+			return oops.Wrap(err)
+		}
+		args.SetRateLimits(rateLimits)
 	}
-	args.SetFileExtensions(overrides)
+	args.DiscoverTimeout = vip.GetDuration(getFlagName(flagNameDiscoverTimeout))
+	args.ReportFormats = vip.GetStringSlice(getFlagName(flagNameReportFormats))
+	args.RetryFailed = vip.GetString(getFlagName(flagNameRetryFailed))
+	args.Include = vip.GetStringSlice(getFlagName(flagNameInclude))
+	args.Exclude = vip.GetStringSlice(getFlagName(flagNameExclude))
+	args.Types = vip.GetStringSlice(getFlagName(flagNameTypes))
+	args.MaxDepth = vip.GetInt(getFlagName(flagNameMaxDepth))
+	args.ModifiedSince = vip.GetString(getFlagName(flagNameModifiedSince))
+	args.ModifiedBefore = vip.GetString(getFlagName(flagNameModifiedBefore))
+	args.Owners = vip.GetStringSlice(getFlagName(flagNameOwner))
+	args.PathTemplate = vip.GetString(getFlagName(flagNamePathTemplate))
+	args.DuplicateNames = vip.GetString(getFlagName(flagNameDuplicateNames))
+	args.NamePolicy = vip.GetString(getFlagName(flagNameNamePolicy))
+	args.UnicodeForm = vip.GetString(getFlagName(flagNameUnicodeForm))
+	args.MaxPathLength = vip.GetInt(getFlagName(flagNameMaxPathLength))
 	// 去重
 	args.DocURLs = lo.Uniq[string](args.DocURLs)
 	return nil
 }
 
+// parseDocSources 通过文档地址获取文档来源域名以及文件类型和token。
+func parseDocSources(args *feishu.Args) (gotHost string, docSources []*cloud.DocumentSource, err error) {
+	for _, docURL := range args.DocURLs {
+		host, typ, token, err := analysisURL(docURL)
+		if err != nil {
+			return "", nil, oops.Wrap(err)
+		}
+		if gotHost == "" {
+			gotHost = host
+		} else if gotHost != host {
+			return "", nil, oops.Errorf("文档地址不匹配, 请确保所有文档地址都是同一域名")
+		}
+		docSources = append(docSources, &cloud.DocumentSource{Type: typ, Token: token})
+	}
+	// 重试失败的文档时可以不指定文档地址
+	if gotHost == "" && args.RetryFailed != "" {
+		gotHost = feishuHost
+	}
+	return gotHost, docSources, nil
+}
+
 func getFlagName(name string) string {
 	return viperKeyPrefix + name
 }
 
-func doExport(args *feishu.Args, host string, docSources []*cloud.DocumentSource) error {
+// doExport 按文档来源域名导出文档，programConstructor不为nil时用它创建记录下载进度的 progress.IProgram。
+func doExport(ctx context.Context, args *feishu.Args, host string, docSources []*cloud.DocumentSource,
+	programConstructor func(progress.Stats) progress.IProgram) error {
 	switch {
 	case strings.HasSuffix(host, "feishu.cn"):
 		// 创建 飞书客户端
 		client := feishu.NewClient(args)
+		if programConstructor != nil {
+			client.(*feishu.ClientImpl).ProgramConstructor = programConstructor
+		}
 		// 下载文档
-		return client.DownloadDocuments(docSources)
+		return client.DownloadDocuments(ctx, docSources)
 	case host == "progress.test":
 		// 创建 进度条测试客户端
 		client := progress.NewTestClient()
-		return client.DownloadDocuments(docSources)
+		return client.DownloadDocuments(ctx, docSources)
 	case host == "silence.test":
 		return nil
 	default:
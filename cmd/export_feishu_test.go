@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -30,6 +31,7 @@ import (
 	"github.com/acyumi/xdoc/component/argument"
 	"github.com/acyumi/xdoc/component/constant"
 	"github.com/acyumi/xdoc/component/feishu"
+	"github.com/acyumi/xdoc/component/progress"
 )
 
 // 注册测试套件。
@@ -80,6 +82,29 @@ func (s *ExportFeishuTestSuite) TestExecute() {
 				// "--list-only", "true", 移到了export命令中
 				"--quit-automatically", "true",
 				"--ext", "docx=docx,doc=docx",
+				"--incremental",
+				"--resume",
+				"--rewrite-docx-links",
+				"--export-workers", "8",
+				"--download-workers", "2",
+				"--queue-size", "10",
+				"--rate-limits", "wiki=50,export=0",
+				"--discover-timeout", "30m",
+				"--progress", "json",
+				"--progress-file", "/tmp/progress.jsonl",
+				"--report-formats", "html,md",
+				"--include", "知识库/**,云空间/*.docx",
+				"--exclude", "**/归档",
+				"--types", "docx,sheet",
+				"--max-depth", "3",
+				"--modified-since", "30d",
+				"--modified-before", "2025-06-01",
+				"--owner", "ou_a,ou_b",
+				"--path-template", "{type}/{title}_{token}.{ext}",
+				"--duplicate-names", "token",
+				"--name-policy", "posix",
+				"--unicode-form", "nfd",
+				"--max-path-length", "200",
 			},
 			wantArgs: &feishu.Args{
 				Args: &argument.Args{
@@ -90,6 +115,8 @@ func (s *ExportFeishuTestSuite) TestExecute() {
 						return filepath.Join(exeDir, flagNameConfig+".yaml")
 					}(),
 					QuitAutomatically: true,
+					Progress:          progress.ModeJSON,
+					ProgressFile:      "/tmp/progress.jsonl",
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -100,7 +127,37 @@ func (s *ExportFeishuTestSuite) TestExecute() {
 					constant.DocTypeDocx: constant.FileExtDocx,
 					constant.DocTypeDoc:  constant.FileExtDocx,
 				},
-				ListOnly: false,
+				ListOnly:         false,
+				Incremental:      true,
+				Resume:           true,
+				Prune:            feishu.PruneOff,
+				RewriteDocxLinks: true,
+				ExportWorkers:    8,
+				DownloadWorkers:  2,
+				QueueSize:        10,
+				RateLimits: map[string]int{
+					feishu.RateLimitDrive:    1000,
+					feishu.RateLimitDownload: 300,
+					feishu.RateLimitWiki:     50,
+					feishu.RateLimitExport:   0,
+					feishu.RateLimitDocx:     300,
+					feishu.RateLimitSheets:   100,
+					feishu.RateLimitBitable:  1200,
+				},
+				DiscoverTimeout: 30 * time.Minute,
+				ReportFormats:   []string{feishu.ReportHTML, feishu.ReportMarkdown},
+				Include:         []string{"知识库/**", "云空间/*.docx"},
+				Exclude:         []string{"**/归档"},
+				Types:           []string{"docx", "sheet"},
+				MaxDepth:        3,
+				ModifiedSince:   "30d",
+				ModifiedBefore:  "2025-06-01",
+				Owners:          []string{"ou_a", "ou_b"},
+				PathTemplate:    "{type}/{title}_{token}.{ext}",
+				DuplicateNames:  feishu.DuplicateNamesToken,
+				NamePolicy:      feishu.NamePolicyPOSIX,
+				UnicodeForm:     feishu.UnicodeFormNFD,
+				MaxPathLength:   200,
 			},
 			wantError: "不支持的文档来源域名: invalid.cn",
 		},
@@ -120,12 +177,24 @@ export:
       extensions:
         docx: "docx"
         doc: "docx"
+    rate-limits:
+      wiki: 60
+      bitable: 0
+    discover-timeout: 90s
+    report-formats: [html]
+    exclude: ["**/归档"]
+    types: [bitable]
+    max-depth: 2
+    modified-since: "2025-01-01 08:00:00"
+    owner: [ou_c]
+    path-template: "{space}/{path}/{title}.{ext}"
 `),
 			args: []string{"--config", filepath.Join(s.TempDir, "test.yaml")},
 			wantArgs: &feishu.Args{
 				Args: &argument.Args{
 					ConfigFile:        filepath.Join(s.TempDir, "test.yaml"),
 					QuitAutomatically: true,
+					Progress:          progress.ModeAuto,
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -136,7 +205,32 @@ export:
 					constant.DocTypeDocx: constant.FileExtDocx,
 					constant.DocTypeDoc:  constant.FileExtDocx,
 				},
-				ListOnly: true,
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits: map[string]int{
+					feishu.RateLimitDrive:    1000,
+					feishu.RateLimitDownload: 300,
+					feishu.RateLimitWiki:     60,
+					feishu.RateLimitExport:   100,
+					feishu.RateLimitDocx:     300,
+					feishu.RateLimitSheets:   100,
+					feishu.RateLimitBitable:  0,
+				},
+				DiscoverTimeout: 90 * time.Second,
+				ReportFormats:   []string{feishu.ReportHTML},
+				Include:         []string{},
+				Exclude:         []string{"**/归档"},
+				Types:           []string{"bitable"},
+				MaxDepth:        2,
+				ModifiedSince:   "2025-01-01 08:00:00",
+				Owners:          []string{"ou_c"},
+				PathTemplate:    "{space}/{path}/{title}.{ext}",
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "不支持的文档来源域名: invalid.cn",
 		},
@@ -166,6 +260,7 @@ export:
 						return filepath.Join(exeDir, flagNameConfig+".yaml")
 					}(),
 					QuitAutomatically: true,
+					Progress:          progress.ModeAuto,
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -176,7 +271,20 @@ export:
 					constant.DocTypeDocx: constant.FileExtDocx,
 					constant.DocTypeDoc:  constant.FileExtDocx,
 				},
-				ListOnly: true,
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "不支持的文档来源域名: invalid.cn",
 		},
@@ -206,6 +314,7 @@ export:
 						return filepath.Join(exeDir, flagNameConfig+".yaml")
 					}(),
 					QuitAutomatically: true,
+					Progress:          progress.ModeAuto,
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -216,7 +325,20 @@ export:
 					constant.DocTypeDocx: constant.FileExtDocx,
 					constant.DocTypeDoc:  constant.FileExtDocx,
 				},
-				ListOnly: true,
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "url地址必须是http://或https://开头",
 			wantCode:  "BadRequest",
@@ -263,14 +385,28 @@ export:
 				Args: &argument.Args{
 					ConfigFile:        "nonexistent.yaml",
 					QuitAutomatically: false,
+					Progress:          progress.ModeAuto,
 				},
-				Enabled:        true,
-				AppID:          "",
-				AppSecret:      "",
-				DocURLs:        []string{},
-				SaveDir:        ".",
-				FileExtensions: map[constant.DocType]constant.FileExt{},
-				ListOnly:       false,
+				Enabled:         true,
+				AppID:           "",
+				AppSecret:       "",
+				DocURLs:         []string{},
+				SaveDir:         ".",
+				FileExtensions:  map[constant.DocType]constant.FileExt{},
+				ListOnly:        false,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "AppID: app-id是必需参数; AppSecret: app-secret是必需参数; DocURLs: urls是必需参数.",
 			wantCode:  "InvalidArgument",
@@ -320,6 +456,7 @@ export:
 				Args: &argument.Args{
 					ConfigFile:        "/tmp/local.yaml",
 					QuitAutomatically: true,
+					Progress:          progress.ModeAuto,
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -328,8 +465,21 @@ export:
 					"https://invalid.cn/docs/xxx",
 					"https://xxxyyy.feishu.cn/docs/xxx",
 				},
-				SaveDir:  filepath.Clean("/tmp"),
-				ListOnly: true,
+				SaveDir:         filepath.Clean("/tmp"),
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 				FileExtensions: map[constant.DocType]constant.FileExt{
 					"docx": "docx",
 					"doc":  "docx",
@@ -338,6 +488,48 @@ export:
 			wantError: "文档地址不匹配, 请确保所有文档地址都是同一域名",
 			wantCode:  "",
 		},
+		{
+			name: "重试失败的文档不需要urls",
+			args: []string{
+				"--app-id", "xx",
+				"--app-secret", "yy",
+				"--dir", "/tmp",
+				"--retry-failed", "/tmp/not-exists/export-report.json",
+			},
+			wantArgs: &feishu.Args{
+				Args: &argument.Args{
+					ConfigFile: func() string {
+						exePath, err := app.Executable()
+						s.Require().NoError(err, "获取程序所在目录失败")
+						exeDir := filepath.Dir(exePath)
+						return filepath.Join(exeDir, flagNameConfig+".yaml")
+					}(),
+					Progress: progress.ModeAuto,
+				},
+				Enabled:         true,
+				AppID:           "xx",
+				AppSecret:       "yy",
+				DocURLs:         []string{},
+				SaveDir:         filepath.Clean("/tmp"),
+				FileExtensions:  map[constant.DocType]constant.FileExt{},
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
+				RetryFailed:     "/tmp/not-exists/export-report.json",
+			},
+			wantError: "retry-failed指定的文件不存在: /tmp/not-exists/export-report.json",
+			wantCode:  "InvalidArgument",
+		},
 		{
 			name:       "执行下载报错",
 			configFile: "/tmp/local.yaml",
@@ -361,6 +553,7 @@ export:
 				Args: &argument.Args{
 					ConfigFile:        "/tmp/local.yaml",
 					QuitAutomatically: true,
+					Progress:          progress.ModeAuto,
 				},
 				Enabled:   true,
 				AppID:     "xx",
@@ -368,8 +561,21 @@ export:
 				DocURLs: []string{
 					"https://xxxyyy.feishu.cn/docs/xxx",
 				},
-				SaveDir:  filepath.Clean("/tmp"),
-				ListOnly: true,
+				SaveDir:         filepath.Clean("/tmp"),
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 				FileExtensions: map[constant.DocType]constant.FileExt{
 					"docx": "docx",
 					"doc":  "docx",
@@ -387,7 +593,7 @@ export:
 				s.True(gock.IsDone(), name)
 			},
 			wantError: "msg:模拟请求失败,code:500",
-			wantCode:  "",
+			wantCode:  "Unauthenticated",
 		},
 	}
 
@@ -513,13 +719,26 @@ func (s *ExportFeishuTestSuite) Test_exec() {
 					ConfigFile:        "",
 					QuitAutomatically: false,
 				},
-				Enabled:        true,
-				AppID:          "xxx",
-				AppSecret:      "yyy",
-				DocURLs:        []string{"https://xxx.feishu.cn/docs/xxx"},
-				SaveDir:        filepath.Clean("/tmp"),
-				FileExtensions: map[constant.DocType]constant.FileExt{},
-				ListOnly:       true,
+				Enabled:         true,
+				AppID:           "xxx",
+				AppSecret:       "yyy",
+				DocURLs:         []string{"https://xxx.feishu.cn/docs/xxx"},
+				SaveDir:         filepath.Clean("/tmp"),
+				FileExtensions:  map[constant.DocType]constant.FileExt{},
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "模拟Stat执行失败",
 			wantCode:  "",
@@ -543,13 +762,26 @@ func (s *ExportFeishuTestSuite) Test_exec() {
 					ConfigFile:        "",
 					QuitAutomatically: false,
 				},
-				Enabled:        true,
-				AppID:          "xxx",
-				AppSecret:      "yyy",
-				DocURLs:        []string{"https://silence.test/docs/xxx"},
-				SaveDir:        filepath.Clean("/tmp"),
-				FileExtensions: map[constant.DocType]constant.FileExt{},
-				ListOnly:       true,
+				Enabled:         true,
+				AppID:           "xxx",
+				AppSecret:       "yyy",
+				DocURLs:         []string{"https://silence.test/docs/xxx"},
+				SaveDir:         filepath.Clean("/tmp"),
+				FileExtensions:  map[constant.DocType]constant.FileExt{},
+				ListOnly:        true,
+				Prune:           feishu.PruneOff,
+				ExportWorkers:   feishu.DefaultExportWorkers,
+				DownloadWorkers: feishu.DefaultDownloadWorkers,
+				QueueSize:       feishu.DefaultQueueSize,
+				RateLimits:      feishu.DefaultRateLimits,
+				ReportFormats:   []string{},
+				Include:         []string{},
+				Exclude:         []string{},
+				Types:           []string{},
+				Owners:          []string{},
+				DuplicateNames:  feishu.DuplicateNamesIndex,
+				NamePolicy:      feishu.NamePolicyPortable,
+				UnicodeForm:     feishu.UnicodeFormNFC,
 			},
 			wantError: "",
 			wantCode:  "",
@@ -613,7 +845,7 @@ func (s *ExportFeishuTestSuite) Test_doExport() {
 				AppID:     "1111",
 				AppSecret: "2222",
 			},
-			wantError: "Client: Args: DocURLs: urls是必需参数; SaveDir: dir是必需参数..; Docs: cannot be blank.",
+			wantError: "Client: Args: DocURLs: urls是必需参数; DownloadWorkers: download-workers必须大于0; ExportWorkers: export-workers必须大于0; QueueSize: queue-size必须大于0; SaveDir: dir是必需参数..; Docs: cannot be blank.",
 			wantCode:  "InvalidArgument",
 		},
 		{
@@ -634,7 +866,7 @@ func (s *ExportFeishuTestSuite) Test_doExport() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			err := doExport(tt.args, tt.host, nil)
+			err := doExport(context.Background(), tt.args, tt.host, nil, nil)
 			if tt.wantError != "" || err != nil {
 				s.Require().Error(err, tt.name)
 				s.IsType(oops.OopsError{}, err, tt.name)
@@ -0,0 +1,242 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/feishu"
+)
+
+const (
+	flagNameJob = "job" //    --job
+	flagNameAll = "all" //    --all
+
+	viperKeyExportJobs = "export.jobs"
+	jobKeyName         = "name" // 任务名称
+)
+
+type (
+	// exportJob 配置文件中export.jobs下的一个导出任务。
+	exportJob struct {
+		name string
+		vip  *viper.Viper // 合并了任务配置的Viper
+	}
+
+	// exportJobResult 导出任务的运行结果。
+	exportJobResult struct {
+		name     string
+		ran      bool          // 是否运行过，被中断后剩余的任务不再运行
+		err      error         // 运行失败的原因
+		duration time.Duration // 运行耗时
+		summary  string        // 导出报告中的统计信息
+	}
+)
+
+// readJobConfigs 读取配置中的任务列表，并校验任务名称必填且不重复，kind 用于错误信息，如"定时任务"。
+func readJobConfigs(vip *viper.Viper, key, kind string) ([]map[string]any, error) {
+	var configs []map[string]any
+	if err := vip.UnmarshalKey(key, &configs); err != nil {
+		return nil, oops.Code("InvalidArgument").Wrapf(err, "%s配置不合法", key)
+	}
+	if len(configs) == 0 {
+		return nil, oops.Code("InvalidArgument").Errorf("%s中没有%s", key, kind)
+	}
+	names := map[string]bool{}
+	for i, config := range configs {
+		name := cast.ToString(config[jobKeyName])
+		if name == "" {
+			return nil, oops.Code("InvalidArgument").Errorf("%s[%d].%s是必需参数", key, i, jobKeyName)
+		}
+		if names[name] {
+			return nil, oops.Code("InvalidArgument").Errorf("%s名称重复: %s", kind, name)
+		}
+		names[name] = true
+	}
+	return configs, nil
+}
+
+// newJobViper 以全局配置为基础，用任务中的配置覆盖export.feishu下的同名参数，
+// 优先级: 命令行参数 > 环境变量 > 任务中的配置 > 全局配置。
+func newJobViper(vip *viper.Viper, config map[string]any, flags *pflag.FlagSet) *viper.Viper {
+	jv := viper.New()
+	// 全局配置(已包含命令行参数、环境变量和配置文件中的值)作为默认值
+	for _, key := range vip.AllKeys() {
+		jv.SetDefault(key, vip.Get(key))
+	}
+	values := lo.OmitByKeys(config, []string{jobKeyName, jobKeyCron})
+	// 目前版本的MergeConfigMap不会返回错误，所以这里直接吃掉错误
+	_ = jv.MergeConfigMap(map[string]any{commandNameExport: map[string]any{commandNameFeishu: values}})
+	// 命令行中指定了任务中的同名参数时以命令行为准
+	flags.Visit(func(flag *pflag.Flag) {
+		if _, ok := values[flag.Name]; ok {
+			_ = jv.BindPFlag(viperKeyPrefix+flag.Name, flag)
+		}
+	})
+	// 环境变量同样优先于任务中的配置
+	jv.SetEnvPrefix(strings.ToUpper(commandNameXdoc))
+	jv.AutomaticEnv()
+	jv.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	return jv
+}
+
+// execJobs 运行export.jobs中指定名称的任务，或者按顺序运行全部任务，最后输出每个任务的结果。
+func (c *exportCommand) execJobs(name string, all bool) error {
+	if name != "" && all {
+		return oops.Code("InvalidArgument").Errorf("--%s和--%s不能同时指定", flagNameJob, flagNameAll)
+	}
+	jobs, err := c.loadJobs(name)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	out := c.args.LogOutput(c.OutOrStdout())
+	results := lo.Map(jobs, func(job *exportJob, _ int) *exportJobResult {
+		return &exportJobResult{name: job.name}
+	})
+	for i, job := range jobs {
+		app.Fprintf(out, "\n>>> 导出任务[%s] (%d/%d)\n", job.name, i+1, len(jobs))
+		result := results[i]
+		result.ran = true
+		startTime := c.now()
+		args, err := c.newJobArgs(job)
+		if err == nil {
+			// 依次运行多个任务时自动退出下载UI，以便继续运行下一个任务
+			args.QuitAutomatically = args.QuitAutomatically || len(jobs) > 1
+			err = runExport(out, args)
+			result.summary, _ = feishu.ReadReportSummary(args.SaveDir, args.StartTime)
+		}
+		result.err = err
+		result.duration = c.now().Sub(startTime)
+		if ExitCode(err) == ExitInterrupted {
+			// 被中断时不再运行剩余的任务
+			break
+		}
+	}
+	printJobResults(out, results)
+	return jobsOutcome(results)
+}
+
+// loadJobs 读取export.jobs中的导出任务，name不为空时只返回该任务，运行前先校验要运行的任务的参数。
+func (c *exportCommand) loadJobs(name string) ([]*exportJob, error) {
+	configs, err := readJobConfigs(c.vip, viperKeyExportJobs, "导出任务")
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	var jobs []*exportJob
+	for _, config := range configs {
+		jobName := cast.ToString(config[jobKeyName])
+		if name != "" && jobName != name {
+			continue
+		}
+		job := &exportJob{name: jobName, vip: newJobViper(c.vip, config, c.Flags())}
+		if _, err := c.newJobArgs(job); err != nil {
+			return nil, oops.Wrapf(err, "导出任务[%s]的参数不合法", jobName)
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		return nil, oops.Code("InvalidArgument").Errorf("%s中没有名为%s的导出任务", viperKeyExportJobs, name)
+	}
+	return jobs, nil
+}
+
+// newJobArgs 读取导出任务的参数并校验，不修改全局参数。
+func (c *exportCommand) newJobArgs(job *exportJob) (*feishu.Args, error) {
+	base := *c.args
+	args := &feishu.Args{Args: &base}
+	if err := setArgs(c.Command, job.vip, args); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	args.Enabled = true
+	if err := args.Validate(); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	if _, _, err := parseDocSources(args); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	return args, nil
+}
+
+// printJobResults 输出每个导出任务的结果。
+func printJobResults(out io.Writer, results []*exportJobResult) {
+	app.Fprintln(out, "----------------------------------------------")
+	app.Fprintln(out, "导出任务结果:")
+	for _, result := range results {
+		app.Fprintf(out, " [%s] %s\n", result.name, result.String())
+	}
+}
+
+func (r *exportJobResult) String() string {
+	if !r.ran {
+		return "未运行"
+	}
+	code := ExitCode(r.err)
+	var status string
+	switch code {
+	case ExitOK:
+		status = "成功"
+	case ExitPartialFailure:
+		status = "部分失败"
+	case ExitInterrupted:
+		status = "已中断"
+	default:
+		status = "失败"
+	}
+	s := fmt.Sprintf("%s, 退出码: %d, 耗时: %s", status, code, r.duration.String())
+	if r.summary != "" {
+		s += ", " + r.summary
+	}
+	if r.err != nil {
+		s += ", 错误信息: " + r.err.Error()
+	}
+	return s
+}
+
+// jobsOutcome 汇总全部导出任务的结果，失败原因相同时保留其退出码，被中断时总是返回中断的退出码。
+func jobsOutcome(results []*exportJobResult) error {
+	failed := lo.Filter(results, func(r *exportJobResult, _ int) bool {
+		return r.err != nil
+	})
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		return failed[0].err
+	}
+	names := lo.Map(failed, func(r *exportJobResult, _ int) string {
+		return r.name
+	})
+	msg := fmt.Sprintf("%d个导出任务失败: %s", len(failed), strings.Join(names, ", "))
+	if interrupted, ok := lo.Find(failed, func(r *exportJobResult) bool {
+		return ExitCode(r.err) == ExitInterrupted
+	}); ok {
+		return oops.Wrapf(interrupted.err, "%s", msg)
+	}
+	code := ExitCode(failed[0].err)
+	if lo.EveryBy(failed, func(r *exportJobResult) bool { return ExitCode(r.err) == code }) {
+		return oops.Wrapf(failed[0].err, "%s", msg)
+	}
+	return oops.Errorf("%s", msg)
+}
@@ -0,0 +1,369 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 注册测试套件。
+func TestExportJobsSuite(t *testing.T) {
+	suite.Run(t, new(ExportJobsTestSuite))
+}
+
+type ExportJobsTestSuite struct {
+	suite.Suite
+	vip *viper.Viper
+	out *bytes.Buffer
+	cmd *exportCommand
+}
+
+func (s *ExportJobsTestSuite) SetupSuite() {
+	app.Fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+}
+
+func (s *ExportJobsTestSuite) SetupTest() {
+	// 与正式执行时一样，飞书导出命令的参数默认值也绑定到同一个Viper中
+	s.vip = app.NewViper()
+	args := &argument.Args{Progress: progress.ModePlain}
+	root := &XdocCommand{}
+	feishuCommand := &exportFeishuCommand{}
+	s.cmd = &exportCommand{}
+	for _, cmd := range []command{root, s.cmd, feishuCommand} {
+		cmd.init(s.vip, args)
+		s.Require().NoError(cmd.bind())
+		if c := cmd.get(); root.get() != c {
+			root.AddCommand(c)
+		}
+	}
+	s.out = &bytes.Buffer{}
+	s.cmd.SetOut(s.out)
+	s.cmd.now = func() time.Time {
+		return time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	}
+}
+
+func (s *ExportJobsTestSuite) readConfig(content string) {
+	s.vip.SetConfigType("yaml")
+	s.Require().NoError(s.vip.ReadConfig(strings.NewReader(content)))
+}
+
+func (s *ExportJobsTestSuite) Test_loadJobs() {
+	s.readConfig(`
+export:
+  feishu:
+    app-id: "cli_xxx"
+    app-secret: "xxx"
+    dir: "/tmp/docs"
+    file:
+      extensions:
+        doc: "pdf"
+    export-workers: 8
+  jobs:
+    - name: "wiki"
+      urls:
+        - "https://xxx.feishu.cn/wiki/xxx"
+      dir: "/tmp/wiki"
+    - name: "drive"
+      app-id: "cli_yyy"
+      app-secret: "yyy"
+      urls:
+        - "https://yyy.feishu.cn/drive/folder/yyy"
+      dir: "/tmp/drive"
+      file:
+        extensions:
+          docx: "md"
+`)
+	jobs, err := s.cmd.loadJobs("")
+	s.Require().NoError(err)
+	s.Require().Len(jobs, 2)
+
+	s.Equal("wiki", jobs[0].name)
+	args, err := s.cmd.newJobArgs(jobs[0])
+	s.Require().NoError(err)
+	s.Equal("cli_xxx", args.AppID, "未指定的参数使用export.feishu中的值")
+	s.Equal("xxx", args.AppSecret)
+	s.Equal([]string{"https://xxx.feishu.cn/wiki/xxx"}, args.DocURLs)
+	s.Equal(filepath.Clean("/tmp/wiki"), args.SaveDir)
+	s.Equal(constant.FileExtPDF, args.FileExtensions[constant.DocTypeDoc])
+	s.Equal(8, args.ExportWorkers)
+	s.Equal(3, args.DownloadWorkers, "使用飞书导出命令的参数默认值")
+
+	s.Equal("drive", jobs[1].name)
+	args, err = s.cmd.newJobArgs(jobs[1])
+	s.Require().NoError(err)
+	s.Equal("cli_yyy", args.AppID, "每个任务可以使用不同的应用")
+	s.Equal("yyy", args.AppSecret)
+	s.Equal([]string{"https://yyy.feishu.cn/drive/folder/yyy"}, args.DocURLs)
+	s.Equal(filepath.Clean("/tmp/drive"), args.SaveDir)
+	s.Equal(constant.FileExtMarkdown, args.FileExtensions[constant.DocTypeDocx])
+	s.Equal(constant.FileExt(""), args.FileExtensions[constant.DocTypeDoc], "任务中的file整体覆盖export.feishu中的值")
+	s.Equal(filepath.Clean("/tmp/docs"), s.vip.GetString(getFlagName(flagNameDir)), "不修改全局配置")
+
+	jobs, err = s.cmd.loadJobs("drive")
+	s.Require().NoError(err)
+	s.Require().Len(jobs, 1)
+	s.Equal("drive", jobs[0].name)
+}
+
+func (s *ExportJobsTestSuite) Test_newJobViper() {
+	s.readConfig(`
+export:
+  feishu:
+    app-id: "cli_xxx"
+    app-secret: "xxx"
+    dir: "/tmp/docs"
+    export-workers: 8
+`)
+	config := map[string]any{
+		jobKeyName:       "wiki",
+		"app-secret":     "yyy",
+		"dir":            "/tmp/wiki",
+		"export-workers": 2,
+	}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String(flagNameDir, "", "")
+	flags.Int(flagNameExportWorkers, 0, "")
+	s.Require().NoError(flags.Parse([]string{"--dir", "/tmp/flag"}))
+	s.T().Setenv("XDOC_EXPORT_FEISHU_APP_SECRET", "zzz")
+
+	jv := newJobViper(s.vip, config, flags)
+	s.Equal("/tmp/flag", jv.GetString(getFlagName(flagNameDir)), "命令行参数优先于任务中的配置")
+	s.Equal("zzz", jv.GetString(getFlagName(flagNameAppSecret)), "环境变量优先于任务中的配置")
+	s.Equal(2, jv.GetInt(getFlagName(flagNameExportWorkers)), "未在命令行中指定的参数使用任务中的配置")
+	s.Equal("cli_xxx", jv.GetString(getFlagName(flagNameAppID)), "任务中没有的参数使用全局配置")
+	s.Empty(jv.GetString(getFlagName(jobKeyName)), "任务名称不作为参数")
+	s.Equal("/tmp/docs", s.vip.GetString(getFlagName(flagNameDir)), "不修改全局配置")
+}
+
+func (s *ExportJobsTestSuite) Test_execJobs_invalid() {
+	tests := []struct {
+		name      string
+		jobs      string
+		job       string
+		all       bool
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:      "同时指定--job和--all",
+			jobs:      `jobs: [{name: a}]`,
+			job:       "a",
+			all:       true,
+			wantError: "--job和--all不能同时指定",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "没有导出任务",
+			jobs:      "",
+			all:       true,
+			wantError: "export.jobs中没有导出任务",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "没有名称",
+			jobs:      `jobs: [{dir: /tmp/a}]`,
+			all:       true,
+			wantError: "export.jobs[0].name是必需参数",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "名称重复",
+			jobs:      `jobs: [{name: a}, {name: a}]`,
+			all:       true,
+			wantError: "导出任务名称重复: a",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "指定的任务不存在",
+			jobs:      `jobs: [{name: a}]`,
+			job:       "b",
+			wantError: "export.jobs中没有名为b的导出任务",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "运行前校验全部任务的参数",
+			jobs:      `jobs: [{name: a}, {name: b, app-secret: ""}]`,
+			all:       true,
+			wantError: "导出任务[b]的参数不合法: AppSecret: app-secret是必需参数.",
+			wantCode:  "InvalidArgument",
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+			s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+  ` + tt.jobs)
+			err := s.cmd.execJobs(tt.job, tt.all)
+			s.Require().EqualError(err, tt.wantError, tt.name)
+			var oopsError oops.OopsError
+			s.Require().True(errors.As(err, &oopsError), tt.name)
+			s.Equal(tt.wantCode, oopsError.Code(), tt.name)
+			s.Empty(s.out.String(), "参数不合法时不运行任何任务")
+		})
+	}
+
+	// 只校验要运行的任务
+	s.SetupTest()
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+  jobs: [{name: a}, {name: b, app-secret: ""}]
+`)
+	s.Require().NoError(s.cmd.execJobs("a", false))
+}
+
+func (s *ExportJobsTestSuite) Test_exec() {
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+  jobs:
+    - {name: "a", dir: "/tmp/a"}
+    - {name: "b", urls: ["https://invalid.cn/wiki/xxx"], dir: "/tmp/b"}
+    - {name: "c", app-id: "cli_ccc", dir: "/tmp/c"}
+`)
+	// 导出报告中的统计信息附加到任务结果中
+	report := fmt.Sprintf(`{"finishedAt": %d, "summary": {"total": 3, "canDownload": 2, "submitted": 2, "completed": 2}}`,
+		time.Now().Add(time.Hour).UnixMilli())
+	s.Require().NoError(app.Fs.WriteFile(filepath.Join(filepath.Clean("/tmp/a"), "export-report.json"), []byte(report), 0o644))
+
+	s.Require().NoError(s.cmd.Flags().Set(flagNameAll, "true"))
+	err := s.cmd.exec()
+	s.Require().EqualError(err, "1个导出任务失败: b: 不支持的文档来源域名: invalid.cn")
+	s.Equal(ExitError, ExitCode(err))
+	out := s.out.String()
+	s.Contains(out, "\n>>> 导出任务[a] (1/3)\n")
+	s.Contains(out, "\n>>> 导出任务[b] (2/3)\n")
+	s.Contains(out, "\n>>> 导出任务[c] (3/3)\n")
+	s.Contains(out, " SaveDir: "+filepath.Clean("/tmp/c")+"\n", "每个任务使用自己的参数")
+	s.Contains(out, " QuitAutomatically: true\n", "依次运行多个任务时自动退出下载UI")
+	s.True(strings.HasSuffix(out, `----------------------------------------------
+导出任务结果:
+ [a] 成功, 退出码: 0, 耗时: 0s, 文档总数: 3, 可下载: 2, 已提交: 2, 已下载: 2, 未下载: 0, 已失败: 0, 已跳过: 0, 已中断: 0
+ [b] 失败, 退出码: 1, 耗时: 0s, 错误信息: 不支持的文档来源域名: invalid.cn
+ [c] 成功, 退出码: 0, 耗时: 0s
+`), out)
+
+	// 只运行指定的任务
+	s.SetupTest()
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+  jobs:
+    - {name: "a", dir: "/tmp/a"}
+    - {name: "b", urls: ["https://invalid.cn/wiki/xxx"], dir: "/tmp/b"}
+`)
+	s.Require().NoError(s.cmd.Flags().Set(flagNameJob, "b"))
+	err = s.cmd.exec()
+	s.Require().EqualError(err, "不支持的文档来源域名: invalid.cn", "只有一个任务时直接返回其错误")
+	out = s.out.String()
+	s.NotContains(out, "导出任务[a]")
+	s.Contains(out, "\n>>> 导出任务[b] (1/1)\n")
+	s.Contains(out, " QuitAutomatically: false\n")
+	s.True(strings.HasSuffix(out, "导出任务结果:\n [b] 失败, 退出码: 1, 耗时: 0s, 错误信息: 不支持的文档来源域名: invalid.cn\n"), out)
+}
+
+func (s *ExportJobsTestSuite) Test_jobsOutcome() {
+	partial := oops.Code("PartialFailure").Errorf("部分文档导出失败")
+	interrupted := oops.Code("Interrupted").Errorf("导出被中断")
+	failed := oops.Errorf("获取访问凭证失败")
+	tests := []struct {
+		name      string
+		results   []*exportJobResult
+		wantError string
+		wantExit  int
+	}{
+		{
+			name:     "全部成功",
+			results:  []*exportJobResult{{name: "a", ran: true}, {name: "b", ran: true}},
+			wantExit: ExitOK,
+		},
+		{
+			name:      "只有一个任务",
+			results:   []*exportJobResult{{name: "a", ran: true, err: partial}},
+			wantError: "部分文档导出失败",
+			wantExit:  ExitPartialFailure,
+		},
+		{
+			name: "失败原因相同时保留退出码",
+			results: []*exportJobResult{
+				{name: "a", ran: true, err: partial},
+				{name: "b", ran: true},
+				{name: "c", ran: true, err: partial},
+			},
+			wantError: "2个导出任务失败: a, c: 部分文档导出失败",
+			wantExit:  ExitPartialFailure,
+		},
+		{
+			name: "失败原因不同",
+			results: []*exportJobResult{
+				{name: "a", ran: true, err: partial},
+				{name: "b", ran: true, err: failed},
+			},
+			wantError: "2个导出任务失败: a, b",
+			wantExit:  ExitError,
+		},
+		{
+			name: "被中断",
+			results: []*exportJobResult{
+				{name: "a", ran: true, err: failed},
+				{name: "b", ran: true, err: interrupted},
+				{name: "c"},
+			},
+			wantError: "2个导出任务失败: a, b: 导出被中断",
+			wantExit:  ExitInterrupted,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			err := jobsOutcome(tt.results)
+			if tt.wantError == "" {
+				s.Require().NoError(err, tt.name)
+			} else {
+				s.Require().EqualError(err, tt.wantError, tt.name)
+			}
+			s.Equal(tt.wantExit, ExitCode(err), tt.name)
+		})
+	}
+}
+
+func (s *ExportJobsTestSuite) Test_exportJobResult_String() {
+	s.Equal("未运行", (&exportJobResult{name: "a"}).String())
+	s.Equal("已中断, 退出码: 130, 耗时: 1s, 错误信息: 导出被中断", (&exportJobResult{
+		name: "a", ran: true, duration: time.Second, err: oops.Code("Interrupted").Errorf("导出被中断"),
+	}).String())
+	s.Equal("部分失败, 退出码: 4, 耗时: 1m0s, 错误信息: 部分文档导出失败", (&exportJobResult{
+		name: "a", ran: true, duration: time.Minute, err: oops.Code("PartialFailure").Errorf("部分文档导出失败"),
+	}).String())
+}
@@ -102,16 +102,20 @@ Examples:
 Available Commands:
   export      云文档批量导出器
   help        Help about any command
+  schedule    定时导出云文档
+  server      通过REST接口导出云文档
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 
 Use "xdoc [command] --help" for more information about a command.
 `,
@@ -137,21 +141,28 @@ Examples:
 ./xdoc export feishu --help
 ./xdoc export feishu --config ./local.yaml
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls https://xxx.feishu.cn/wiki/123456789
+【运行配置文件export.jobs中的导出任务】
+./xdoc export --config ./local.yaml --job wiki
+./xdoc export --config ./local.yaml --all
 
 Available Commands:
   feishu      飞书云文档批量导出器
 
 Flags:
-  -h, --help        help for export
-  -l, --list-only   是否只列出云文档信息不进行导出下载
+      --all          按顺序运行配置文件export.jobs中的全部导出任务, 最后输出每个任务的结果
+  -h, --help         help for export
+      --job string   只运行配置文件export.jobs中指定名称的导出任务
+  -l, --list-only    是否只列出云文档信息不进行导出下载
 
 Global Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
 
 Use "xdoc export [command] --help" for more information about a command.
 `,
@@ -0,0 +1,252 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/samber/oops"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/cloud"
+	"github.com/acyumi/xdoc/component/feishu"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+const (
+	commandNameSchedule = "schedule"
+
+	viperKeyScheduleJobs = "schedule.jobs"
+	jobKeyCron           = "cron" // 定时任务的cron表达式
+)
+
+type scheduleCommand struct {
+	*cobra.Command
+	vip     *viper.Viper
+	args    *argument.Args
+	now     func() time.Time
+	runLock sync.Mutex // 不同的定时任务依次运行，避免同时输出到标准输出、同时写保存目录中的导出报告
+}
+
+// scheduleJob 定时导出任务，实现了 cron.Job 接口。
+type scheduleJob struct {
+	name    string
+	spec    string           // cron表达式
+	vip     *viper.Viper     // 合并了定时任务配置的Viper，每次运行都从中重新读取参数
+	cmd     *cobra.Command   // 用于读取参数的命令
+	base    *argument.Args   // 全局参数
+	out     io.Writer        // 运行日志的输出
+	now     func() time.Time // 获取当前时间，便于测试
+	ctx     context.Context  // 取消时中断运行中的导出
+	runLock *sync.Mutex      // 全部定时任务共用的运行锁
+	running atomic.Bool      // 是否正在运行或等待运行，同一个定时任务不重叠运行
+	runs    atomic.Int32     // 运行次数
+}
+
+func (c *scheduleCommand) init(vip *viper.Viper, args *argument.Args) {
+	c.Command = &cobra.Command{
+		Use:   commandNameSchedule,
+		Short: "定时导出云文档",
+		Long:  "这是在后台常驻运行的定时导出程序, 按配置文件中schedule.jobs的cron表达式定时执行飞书云文档导出",
+		Example: `【使用默认config.yaml中的schedule.jobs】
+./xdoc schedule
+【指定配置文件】
+./xdoc schedule --config ./local.yaml
+【进度以JSON事件写入文件，每个定时任务写入各自的文件，如/tmp/progress-wiki.jsonl】
+./xdoc schedule --config ./local.yaml --progress json --progress-file /tmp/progress.jsonl`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.exec()
+		},
+	}
+	c.vip = vip
+	c.args = args
+	c.now = time.Now
+}
+
+func (c *scheduleCommand) bind() error {
+	// 定时任务只从配置文件中读取，没有自己的命令行参数
+	return nil
+}
+
+func (c *scheduleCommand) get() *cobra.Command {
+	return c.Command
+}
+
+func (c *scheduleCommand) children() []command {
+	return []command{}
+}
+
+func (c *scheduleCommand) exec() error {
+	jobs, err := c.loadJobs()
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	// 收到中断信号时停止调度，并中断运行中的导出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return c.run(ctx, jobs)
+}
+
+// loadJobs 读取配置文件中的定时任务，启动前先校验全部参数，避免到了运行时间才发现配置错误。
+func (c *scheduleCommand) loadJobs() ([]*scheduleJob, error) {
+	configs, err := readJobConfigs(c.vip, viperKeyScheduleJobs, "定时任务")
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	var jobs []*scheduleJob
+	for _, config := range configs {
+		name := cast.ToString(config[jobKeyName])
+		spec := cast.ToString(config[jobKeyCron])
+		if _, err := cron.ParseStandard(spec); err != nil {
+			return nil, oops.Code("InvalidArgument").Wrapf(err, "定时任务[%s]的cron表达式不合法: %s", name, spec)
+		}
+		job := &scheduleJob{
+			name:    name,
+			spec:    spec,
+			vip:     newJobViper(c.vip, config, c.Flags()),
+			cmd:     c.Command,
+			base:    c.args,
+			out:     c.args.LogOutput(c.OutOrStdout()),
+			now:     c.now,
+			runLock: &c.runLock,
+		}
+		if _, _, _, err := job.newArgs(); err != nil {
+			return nil, oops.Wrapf(err, "定时任务[%s]的参数不合法", name)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// run 按cron表达式调度定时任务，直到ctx被取消，再等待运行中的任务结束。
+func (c *scheduleCommand) run(ctx context.Context, jobs []*scheduleJob) error {
+	out := c.args.LogOutput(c.OutOrStdout())
+	scheduler := cron.New()
+	for _, job := range jobs {
+		job.ctx = ctx
+		if _, err := scheduler.AddJob(job.spec, job); err != nil {
+			return oops.Code("InvalidArgument").Wrapf(err, "定时任务[%s]的cron表达式不合法: %s", job.name, job.spec)
+		}
+	}
+	scheduler.Start()
+	for _, entry := range scheduler.Entries() {
+		job := entry.Job.(*scheduleJob)
+		job.logf("cron: %s, 下次运行时间: %s", job.spec, entry.Next.Format(time.DateTime))
+	}
+	app.Fprintln(out, "定时任务已启动, 按Ctrl+C退出")
+	<-ctx.Done()
+	app.Fprintln(out, "正在停止定时任务, 等待运行中的任务结束")
+	<-scheduler.Stop().Done()
+	app.Fprintln(out, "定时任务已停止")
+	return nil
+}
+
+// newArgs 读取本次运行的参数，每次运行都重新读取，避免受到上一次运行的影响。
+func (j *scheduleJob) newArgs() (*feishu.Args, string, []*cloud.DocumentSource, error) {
+	base := *j.base
+	// 在后台运行时不能使用全屏的下载UI，除json外都逐行输出进度，并且下载完成后自动退出
+	if base.Progress != progress.ModeJSON {
+		base.Progress = progress.ModePlain
+	}
+	base.QuitAutomatically = true
+	base.ProgressFile = jobProgressFile(base.ProgressFile, j.name)
+	args := &feishu.Args{Args: &base}
+	if err := setArgs(j.cmd, j.vip, args); err != nil {
+		return nil, "", nil, oops.Wrap(err)
+	}
+	args.Enabled = true
+	if err := args.Validate(); err != nil {
+		return nil, "", nil, oops.Wrap(err)
+	}
+	host, docSources, err := parseDocSources(args)
+	if err != nil {
+		return nil, "", nil, oops.Wrap(err)
+	}
+	return args, host, docSources, nil
+}
+
+// jobProgressFile 在进度输出文件名后追加定时任务名称，避免多个定时任务写同一个文件。
+func jobProgressFile(filePath, name string) string {
+	if filePath == "" {
+		return ""
+	}
+	ext := filepath.Ext(filePath)
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filePath, ext), name, ext)
+}
+
+// Run 运行一次导出，上一次运行还未结束时跳过本次运行，其他定时任务正在运行时等待其结束，结束后输出本次运行的摘要。
+func (j *scheduleJob) Run() {
+	if !j.running.CompareAndSwap(false, true) {
+		j.logf("上一次运行还未结束, 跳过本次运行")
+		return
+	}
+	defer j.running.Store(false)
+	if !j.runLock.TryLock() {
+		j.logf("其他定时任务正在运行, 等待其结束后再运行")
+		j.runLock.Lock()
+	}
+	defer j.runLock.Unlock()
+	if j.ctx.Err() != nil {
+		j.logf("定时任务已停止, 跳过本次运行")
+		return
+	}
+	run := j.runs.Add(1)
+	startTime := j.now()
+	j.logf("开始第%d次运行", run)
+	defer func() {
+		// 避免一次运行的异常导致整个定时程序退出
+		if r := recover(); r != nil {
+			j.logf("第%d次运行异常: %v", run, r)
+		}
+	}()
+	args, host, docSources, err := j.newArgs()
+	if err == nil {
+		args.StartTime = startTime
+		err = doExport(j.ctx, args, host, docSources, nil)
+	}
+	j.logf("第%d次运行结束, 耗时: %s, 退出码: %d", run, j.now().Sub(startTime).String(), ExitCode(err))
+	if args != nil {
+		summary, er := feishu.ReadReportSummary(args.SaveDir, startTime)
+		if er != nil {
+			j.logf("第%d次运行读取导出报告失败: %v", run, er)
+		} else if summary != "" {
+			j.logf("第%d次运行的统计信息: %s", run, summary)
+		}
+	}
+	if err != nil {
+		j.logf("第%d次运行的错误信息: %v", run, err)
+	}
+}
+
+// logf 输出带时间和任务名称的运行日志。
+func (j *scheduleJob) logf(format string, a ...any) {
+	app.Fprintf(j.out, "%s [%s] %s\n", j.now().Format(time.DateTime), j.name, fmt.Sprintf(format, a...))
+}
@@ -0,0 +1,377 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/cloud"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 注册测试套件。
+func TestScheduleSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	vip *viper.Viper
+	out *bytes.Buffer
+	cmd *scheduleCommand
+}
+
+func (s *ScheduleTestSuite) SetupSuite() {
+	app.Fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+}
+
+func (s *ScheduleTestSuite) SetupTest() {
+	// 与正式执行时一样，飞书导出命令的参数默认值也绑定到同一个Viper中
+	s.vip = app.NewViper()
+	args := &argument.Args{Progress: progress.ModeAuto}
+	root := &XdocCommand{}
+	feishuCommand := &exportFeishuCommand{}
+	s.cmd = &scheduleCommand{}
+	for _, cmd := range []command{root, feishuCommand, s.cmd} {
+		cmd.init(s.vip, args)
+		s.Require().NoError(cmd.bind())
+		if c := cmd.get(); root.get() != c {
+			root.AddCommand(c)
+		}
+	}
+	s.out = &bytes.Buffer{}
+	s.cmd.SetOut(s.out)
+	s.cmd.now = func() time.Time {
+		return time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	}
+}
+
+func (s *ScheduleTestSuite) readConfig(content string) {
+	s.vip.SetConfigType("yaml")
+	s.Require().NoError(s.vip.ReadConfig(strings.NewReader(content)))
+}
+
+func (s *ScheduleTestSuite) Test_loadJobs() {
+	s.readConfig(`
+export:
+  feishu:
+    app-id: "cli_xxx"
+    app-secret: "xxx"
+    urls:
+      - "https://xxx.feishu.cn/wiki/xxx"
+    dir: "/tmp/docs"
+    file:
+      extensions:
+        doc: "pdf"
+    export-workers: 8
+schedule:
+  jobs:
+    - name: "wiki"
+      cron: "0 2 * * *"
+    - name: "drive"
+      cron: "@every 1h"
+      app-secret: "yyy"
+      urls:
+        - "https://xxx.feishu.cn/drive/folder/xxx"
+      dir: "/tmp/drive"
+      file:
+        extensions:
+          docx: "md"
+      incremental: true
+`)
+	jobs, err := s.cmd.loadJobs()
+	s.Require().NoError(err)
+	s.Require().Len(jobs, 2)
+
+	s.Equal("wiki", jobs[0].name)
+	s.Equal("0 2 * * *", jobs[0].spec)
+	args, host, docSources, err := jobs[0].newArgs()
+	s.Require().NoError(err)
+	s.Equal("xxx.feishu.cn", host)
+	s.Len(docSources, 1)
+	s.Equal("xxx", args.AppSecret)
+	s.Equal(filepath.Clean("/tmp/docs"), args.SaveDir)
+	s.Equal(constant.FileExtPDF, args.FileExtensions[constant.DocTypeDoc])
+	s.Equal(8, args.ExportWorkers)
+	s.Equal(3, args.DownloadWorkers, "使用飞书导出命令的参数默认值")
+	s.Equal(progress.ModePlain, args.Progress, "后台运行时不使用下载UI")
+	s.True(args.QuitAutomatically)
+	s.Equal(progress.ModeAuto, s.cmd.args.Progress, "不修改全局参数")
+
+	s.Equal("drive", jobs[1].name)
+	args, _, docSources, err = jobs[1].newArgs()
+	s.Require().NoError(err)
+	s.Equal("cli_xxx", args.AppID, "未指定的参数使用export.feishu中的值")
+	s.Equal("yyy", args.AppSecret)
+	s.Equal([]string{"https://xxx.feishu.cn/drive/folder/xxx"}, args.DocURLs)
+	s.Equal(&cloud.DocumentSource{Type: "/drive/folder", Token: "xxx"}, docSources[0])
+	s.Equal(filepath.Clean("/tmp/drive"), args.SaveDir)
+	s.Equal(constant.FileExtMarkdown, args.FileExtensions[constant.DocTypeDocx])
+	s.Equal(constant.FileExt(""), args.FileExtensions[constant.DocTypeDoc], "定时任务中的file整体覆盖export.feishu中的值")
+	s.True(args.Incremental)
+	s.Equal(8, args.ExportWorkers)
+}
+
+func (s *ScheduleTestSuite) Test_loadJobs_invalid() {
+	tests := []struct {
+		name      string
+		jobs      string
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:      "没有定时任务",
+			jobs:      "",
+			wantError: "schedule.jobs中没有定时任务",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "配置不是列表",
+			jobs:      `schedule: {jobs: "xxx"}`,
+			wantError: "schedule.jobs配置不合法: 1 error(s) decoding:\n\n* '[0]' expected a map, got 'string'",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "没有名称",
+			jobs:      `schedule: {jobs: [{cron: "@daily"}]}`,
+			wantError: "schedule.jobs[0].name是必需参数",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "名称重复",
+			jobs:      `schedule: {jobs: [{name: a, cron: "@daily"}, {name: a, cron: "@hourly"}]}`,
+			wantError: "定时任务名称重复: a",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "cron表达式不合法",
+			jobs:      `schedule: {jobs: [{name: a, cron: "* * *"}]}`,
+			wantError: "定时任务[a]的cron表达式不合法: * * *: expected exactly 5 fields, found 3: [* * *]",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "导出参数不合法",
+			jobs:      `schedule: {jobs: [{name: a, cron: "@daily", app-id: ""}]}`,
+			wantError: "定时任务[a]的参数不合法: AppID: app-id是必需参数.",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "文档地址不合法",
+			jobs:      `schedule: {jobs: [{name: a, cron: "@daily", urls: ["ftp://xxx.feishu.cn/wiki/xxx"]}]}`,
+			wantError: "定时任务[a]的参数不合法: url地址必须是http://或https://开头",
+			wantCode:  "BadRequest",
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+			s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://xxx.feishu.cn/wiki/xxx"], dir: "/tmp/docs"}
+` + tt.jobs)
+			_, err := s.cmd.loadJobs()
+			s.Require().EqualError(err, tt.wantError, tt.name)
+			var oopsError oops.OopsError
+			s.Require().True(errors.As(err, &oopsError), tt.name)
+			s.Equal(tt.wantCode, oopsError.Code(), tt.name)
+		})
+	}
+}
+
+func (s *ScheduleTestSuite) Test_exec() {
+	// 配置不合法时直接返回，不启动调度
+	err := s.cmd.exec()
+	s.Require().EqualError(err, "schedule.jobs中没有定时任务")
+	s.Equal(ExitInvalidArgument, ExitCode(err))
+}
+
+func (s *ScheduleTestSuite) Test_run() {
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+schedule:
+  jobs:
+    - {name: "a", cron: "@daily"}
+    - {name: "b", cron: "0 2 * * *"}
+`)
+	jobs, err := s.cmd.loadJobs()
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.cmd.run(ctx, jobs)
+	s.Require().NoError(err)
+	out := s.out.String()
+	s.Contains(out, "2025-01-02 15:04:05 [a] cron: @daily, 下次运行时间: ")
+	s.Contains(out, "2025-01-02 15:04:05 [b] cron: 0 2 * * *, 下次运行时间: ")
+	s.True(strings.HasSuffix(out, "定时任务已启动, 按Ctrl+C退出\n正在停止定时任务, 等待运行中的任务结束\n定时任务已停止\n"), out)
+	s.Equal(int32(0), jobs[0].runs.Load(), "还没到运行时间")
+}
+
+func (s *ScheduleTestSuite) Test_scheduleJob_Run() {
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+schedule:
+  jobs:
+    - {name: "silence", cron: "@daily"}
+    - {name: "invalid", cron: "@daily", urls: ["https://invalid.cn/wiki/xxx"], dir: "/tmp/invalid"}
+`)
+	jobs, err := s.cmd.loadJobs()
+	s.Require().NoError(err)
+	ctx := context.Background()
+
+	// 成功，并输出本次运行的导出报告摘要
+	job := jobs[0]
+	job.ctx = ctx
+	report := fmt.Sprintf(`{"finishedAt": %d, "summary": {"total": 3, "canDownload": 2, "submitted": 2, "completed": 2}}`,
+		time.Date(2025, 1, 2, 15, 4, 6, 0, time.Local).UnixMilli())
+	s.Require().NoError(app.Fs.WriteFile(filepath.Join(filepath.Clean("/tmp/docs"), "export-report.json"), []byte(report), 0o644))
+	job.Run()
+	s.Equal(`2025-01-02 15:04:05 [silence] 开始第1次运行
+2025-01-02 15:04:05 [silence] 第1次运行结束, 耗时: 0s, 退出码: 0
+2025-01-02 15:04:05 [silence] 第1次运行的统计信息: 文档总数: 3, 可下载: 2, 已提交: 2, 已下载: 2, 未下载: 0, 已失败: 0, 已跳过: 0, 已中断: 0
+`, s.out.String())
+	s.False(job.running.Load())
+
+	// 上一次运行还未结束
+	s.out.Reset()
+	job.running.Store(true)
+	job.Run()
+	s.Equal("2025-01-02 15:04:05 [silence] 上一次运行还未结束, 跳过本次运行\n", s.out.String())
+	s.Equal(int32(1), job.runs.Load())
+	job.running.Store(false)
+
+	// 失败时输出退出码和错误信息，不影响下一次运行
+	s.out.Reset()
+	job = jobs[1]
+	job.ctx = ctx
+	job.Run()
+	job.Run()
+	s.Equal(`2025-01-02 15:04:05 [invalid] 开始第1次运行
+2025-01-02 15:04:05 [invalid] 第1次运行结束, 耗时: 0s, 退出码: 1
+2025-01-02 15:04:05 [invalid] 第1次运行的错误信息: 不支持的文档来源域名: invalid.cn
+2025-01-02 15:04:05 [invalid] 开始第2次运行
+2025-01-02 15:04:05 [invalid] 第2次运行结束, 耗时: 0s, 退出码: 1
+2025-01-02 15:04:05 [invalid] 第2次运行的错误信息: 不支持的文档来源域名: invalid.cn
+`, s.out.String())
+}
+
+func (s *ScheduleTestSuite) Test_scheduleJob_Run_serialized() {
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+schedule:
+  jobs:
+    - {name: "a", cron: "@daily", dir: "/tmp/serialized"}
+`)
+	jobs, err := s.cmd.loadJobs()
+	s.Require().NoError(err)
+	job := jobs[0]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	job.ctx = ctx
+	written := make(chan struct{}, 10)
+	job.out = &notifyWriter{out: s.out, written: written}
+
+	// 其他定时任务正在运行时等待其结束后再运行
+	s.cmd.runLock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		job.Run()
+	}()
+	<-written
+	s.True(job.running.Load(), "等待运行时同一个定时任务的下一次运行被跳过")
+	s.cmd.runLock.Unlock()
+	<-done
+	s.Equal(`2025-01-02 15:04:05 [a] 其他定时任务正在运行, 等待其结束后再运行
+2025-01-02 15:04:05 [a] 开始第1次运行
+2025-01-02 15:04:05 [a] 第1次运行结束, 耗时: 0s, 退出码: 0
+`, s.out.String())
+
+	// 等待期间定时任务已停止
+	s.out.Reset()
+	written = make(chan struct{}, 10)
+	job.out = &notifyWriter{out: s.out, written: written}
+	s.cmd.runLock.Lock()
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		job.Run()
+	}()
+	<-written
+	cancel()
+	s.cmd.runLock.Unlock()
+	<-done
+	s.Equal(`2025-01-02 15:04:05 [a] 其他定时任务正在运行, 等待其结束后再运行
+2025-01-02 15:04:05 [a] 定时任务已停止, 跳过本次运行
+`, s.out.String())
+	s.Equal(int32(1), job.runs.Load())
+	s.False(job.running.Load())
+}
+
+func (s *ScheduleTestSuite) Test_jobProgressFile() {
+	s.Equal("", jobProgressFile("", "wiki"))
+	s.Equal(filepath.Join("/tmp", "progress-wiki.jsonl"), jobProgressFile(filepath.Join("/tmp", "progress.jsonl"), "wiki"))
+	s.Equal(filepath.Join("/tmp", "progress-a_b"), jobProgressFile(filepath.Join("/tmp", "progress"), "a/b"))
+
+	// 每个定时任务的进度写入各自的文件
+	s.readConfig(`
+export:
+  feishu: {app-id: "cli_xxx", app-secret: "xxx", urls: ["https://silence.test/wiki/xxx"], dir: "/tmp/docs"}
+schedule:
+  jobs:
+    - {name: "a", cron: "@daily"}
+    - {name: "b", cron: "@daily"}
+`)
+	s.cmd.args.ProgressFile = "/tmp/progress.jsonl"
+	jobs, err := s.cmd.loadJobs()
+	s.Require().NoError(err)
+	args, _, _, err := jobs[0].newArgs()
+	s.Require().NoError(err)
+	s.Equal("/tmp/progress-a.jsonl", args.ProgressFile)
+	args, _, _, err = jobs[1].newArgs()
+	s.Require().NoError(err)
+	s.Equal("/tmp/progress-b.jsonl", args.ProgressFile)
+	s.Equal("/tmp/progress.jsonl", s.cmd.args.ProgressFile, "不修改全局参数")
+}
+
+// notifyWriter 每次写入后发出通知，便于等待另一个协程输出日志。
+type notifyWriter struct {
+	out     io.Writer
+	written chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	w.written <- struct{}{}
+	return n, err
+}
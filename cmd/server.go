@@ -0,0 +1,192 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/feishu"
+	"github.com/acyumi/xdoc/component/progress"
+	"github.com/acyumi/xdoc/component/server"
+)
+
+const (
+	commandNameServer = "server"
+
+	flagNameAddr  = "addr"  //    --addr
+	flagNameToken = "token" //    --token
+
+	viperKeyServerAddr  = "server.addr"
+	viperKeyServerToken = "server.token"
+
+	shutdownTimeout = 10 * time.Second // 停止服务时等待进行中的请求结束的最长时间
+)
+
+type serverCommand struct {
+	*cobra.Command
+	vip  *viper.Viper
+	args *argument.Args
+	now  func() time.Time
+}
+
+func (c *serverCommand) init(vip *viper.Viper, args *argument.Args) {
+	c.Command = &cobra.Command{
+		Use:   commandNameServer,
+		Short: "通过REST接口导出云文档",
+		Long: `这是常驻运行的HTTP服务, 通过REST接口提交飞书云文档导出任务, 并查询任务和各文档的状态
+任务只保存在内存中, 服务停止时取消全部运行中的任务
+	POST /jobs                  提交导出任务, 请求体如 {"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/xxx/docs", "extensions": {"docx": "md"}}
+	GET  /jobs                  列出全部任务
+	GET  /jobs/{id}             查询任务
+	GET  /jobs/{id}/documents   查询任务中各文档的状态, 可以通过?status=failed过滤
+	POST /jobs/{id}/cancel      取消任务
+请求中未指定的参数使用配置中export.feishu的值`,
+		Example: `【使用默认config.yaml, 监听127.0.0.1:8080】
+./xdoc server
+【指定监听地址和访问令牌】
+./xdoc server --config ./local.yaml --addr 0.0.0.0:8080 --token xxx
+【提交导出任务】
+curl -H "Authorization: Bearer xxx" -d '{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/xxx/docs"}' http://127.0.0.1:8080/jobs`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.exec()
+		},
+	}
+	c.vip = vip
+	c.args = args
+	c.now = time.Now
+}
+
+func (c *serverCommand) bind() error {
+	flags := c.Flags()
+	flags.SortFlags = false
+	flags.String(flagNameAddr, "127.0.0.1:8080", "服务监听地址")
+	flags.String(flagNameToken, "", "访问令牌, 不为空时请求需要携带 Authorization: Bearer <token>")
+	_ = c.vip.BindPFlag(viperKeyServerAddr, flags.Lookup(flagNameAddr))
+	_ = c.vip.BindPFlag(viperKeyServerToken, flags.Lookup(flagNameToken))
+	return nil
+}
+
+func (c *serverCommand) get() *cobra.Command {
+	return c.Command
+}
+
+func (c *serverCommand) children() []command {
+	return []command{}
+}
+
+func (c *serverCommand) exec() error {
+	addr := c.vip.GetString(viperKeyServerAddr)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return oops.Code("InvalidArgument").Wrapf(err, "监听地址失败: %s", addr)
+	}
+	// 收到中断信号时停止服务，并取消运行中的任务
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return c.serve(ctx, listener)
+}
+
+// serve 在listener上提供REST接口，直到ctx被取消，再取消并等待运行中的任务结束。
+func (c *serverCommand) serve(ctx context.Context, listener net.Listener) error {
+	out := c.OutOrStdout()
+	token := c.vip.GetString(viperKeyServerToken)
+	srv := server.New(c.prepare, token)
+	defer srv.Close()
+	httpServer := &http.Server{
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+	if token == "" && !isLoopback(listener.Addr()) {
+		app.Fprintln(out, "警告: 未设置访问令牌, 任何能访问该地址的人都可以提交导出任务")
+	}
+	app.Fprintf(out, "服务已启动, 监听地址: http://%s, 按Ctrl+C退出\n", listener.Addr())
+	select {
+	case <-ctx.Done():
+	case err := <-served:
+		return oops.Wrap(err)
+	}
+	app.Fprintln(out, "正在停止服务, 取消运行中的任务")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := httpServer.Shutdown(shutdownCtx)
+	if er := <-served; !errors.Is(er, http.ErrServerClosed) {
+		err = errors.Join(err, er)
+	}
+	srv.Close()
+	app.Fprintln(out, "服务已停止")
+	return oops.Wrap(err)
+}
+
+// prepare 以配置中export.feishu的参数为基础，用请求中的参数覆盖后校验，并回填实际使用的参数。
+func (c *serverCommand) prepare(req *server.JobRequest) (server.ExportFunc, error) {
+	base := *c.args
+	base.QuitAutomatically = true
+	args := &feishu.Args{Args: &base}
+	if err := setArgs(c.Command, c.vip, args); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	args.Enabled = true
+	// 重试失败的文档只用于命令行
+	args.RetryFailed = ""
+	if len(req.URLs) > 0 {
+		args.DocURLs = lo.Uniq(req.URLs)
+	}
+	if req.Dir != "" {
+		args.SaveDir = filepath.Clean(req.Dir)
+	}
+	args.SetFileExtensions(req.Extensions)
+	if err := args.Validate(); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	host, docSources, err := parseDocSources(args)
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	req.URLs = args.DocURLs
+	req.Dir = args.SaveDir
+	req.Extensions = lo.MapEntries(args.FileExtensions, func(k constant.DocType, v constant.FileExt) (string, string) {
+		return string(k), string(v)
+	})
+	return func(ctx context.Context, programConstructor func(progress.Stats) progress.IProgram) error {
+		args.StartTime = c.now()
+		return doExport(ctx, args, host, docSources, programConstructor)
+	}, nil
+}
+
+// isLoopback 判断监听地址是否只允许本机访问。
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
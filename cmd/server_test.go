@@ -0,0 +1,224 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/progress"
+	"github.com/acyumi/xdoc/component/server"
+)
+
+// 注册测试套件。
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	vip *viper.Viper
+	out *bytes.Buffer
+	cmd *serverCommand
+}
+
+func (s *ServerTestSuite) SetupSuite() {
+	app.Fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	// 与正式执行时一样，飞书导出命令的参数默认值也绑定到同一个Viper中
+	s.vip = app.NewViper()
+	args := &argument.Args{Progress: progress.ModeAuto}
+	root := &XdocCommand{}
+	feishuCommand := &exportFeishuCommand{}
+	s.cmd = &serverCommand{}
+	for _, cmd := range []command{root, feishuCommand, s.cmd} {
+		cmd.init(s.vip, args)
+		s.Require().NoError(cmd.bind())
+		if c := cmd.get(); root.get() != c {
+			root.AddCommand(c)
+		}
+	}
+	s.out = &bytes.Buffer{}
+	s.cmd.SetOut(s.out)
+	s.vip.SetConfigType("yaml")
+	s.Require().NoError(s.vip.ReadConfig(strings.NewReader(`
+export:
+  feishu:
+    app-id: "cli_xxx"
+    app-secret: "xxx"
+    urls:
+      - "https://xxx.feishu.cn/wiki/xxx"
+    dir: "/tmp/docs"
+    file:
+      extensions:
+        doc: "pdf"
+`)))
+}
+
+func (s *ServerTestSuite) Test_bind() {
+	s.Equal("127.0.0.1:8080", s.vip.GetString(viperKeyServerAddr))
+	s.Equal("", s.vip.GetString(viperKeyServerToken))
+	s.Require().NoError(s.cmd.Flags().Set(flagNameToken, "secret"))
+	s.Equal("secret", s.vip.GetString(viperKeyServerToken))
+}
+
+func (s *ServerTestSuite) Test_prepare() {
+	// 未指定的参数使用export.feishu中的值
+	req := &server.JobRequest{}
+	export, err := s.cmd.prepare(req)
+	s.Require().NoError(err)
+	s.NotNil(export)
+	s.Equal([]string{"https://xxx.feishu.cn/wiki/xxx"}, req.URLs)
+	s.Equal(filepath.Clean("/tmp/docs"), req.Dir)
+	s.Equal(map[string]string{"doc": "pdf"}, req.Extensions)
+
+	req = &server.JobRequest{
+		URLs:       []string{"https://xxx.feishu.cn/drive/folder/yyy", "https://xxx.feishu.cn/drive/folder/yyy"},
+		Dir:        "/tmp/drive/",
+		Extensions: map[string]string{"docx": "md"},
+	}
+	_, err = s.cmd.prepare(req)
+	s.Require().NoError(err)
+	s.Equal([]string{"https://xxx.feishu.cn/drive/folder/yyy"}, req.URLs)
+	s.Equal(filepath.Clean("/tmp/drive"), req.Dir)
+	s.Equal(map[string]string{"doc": "pdf", "docx": "md"}, req.Extensions, "请求中的扩展名局部覆盖配置中的值")
+	s.Equal(progress.ModeAuto, s.cmd.args.Progress, "不修改全局参数")
+	s.False(s.cmd.args.QuitAutomatically, "不修改全局参数")
+}
+
+func (s *ServerTestSuite) Test_prepare_invalid() {
+	tests := []struct {
+		name      string
+		config    map[string]any
+		req       *server.JobRequest
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:      "请求和配置中都没有文档地址",
+			config:    map[string]any{getFlagName(flagNameURLs): []string{}},
+			req:       &server.JobRequest{Dir: "/tmp/docs"},
+			wantError: "DocURLs: urls是必需参数.",
+			wantCode:  "InvalidArgument",
+		},
+		{
+			name:      "文档地址不合法",
+			req:       &server.JobRequest{URLs: []string{"ftp://xxx.feishu.cn/wiki/xxx"}},
+			wantError: "url地址必须是http://或https://开头",
+			wantCode:  "BadRequest",
+		},
+		{
+			name:      "文档地址不是同一域名",
+			req:       &server.JobRequest{URLs: []string{"https://xxx.feishu.cn/wiki/xxx", "https://silence.test/wiki/xxx"}},
+			wantError: "文档地址不匹配, 请确保所有文档地址都是同一域名",
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+			for key, value := range tt.config {
+				s.vip.Set(key, value)
+			}
+			_, err := s.cmd.prepare(tt.req)
+			s.Require().EqualError(err, tt.wantError, tt.name)
+			var oopsError oops.OopsError
+			s.Require().True(errors.As(err, &oopsError), tt.name)
+			s.Equal(tt.wantCode, oopsError.Code(), tt.name)
+		})
+	}
+}
+
+func (s *ServerTestSuite) Test_serve() {
+	s.vip.Set(viperKeyServerToken, "secret")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.cmd.serve(ctx, listener)
+	}()
+	baseURL := "http://" + listener.Addr().String()
+
+	request := func(method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, baseURL+path, strings.NewReader(body))
+		s.Require().NoError(err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		return resp.StatusCode, string(data)
+	}
+
+	code, body := request(http.MethodPost, "/jobs", `{"urls": ["https://silence.test/wiki/xxx"], "dir": "/tmp/silence"}`)
+	s.Equal(http.StatusCreated, code, body)
+	var job server.Job
+	s.Eventually(func() bool {
+		_, body = request(http.MethodGet, "/jobs/1", "")
+		s.Require().NoError(json.Unmarshal([]byte(body), &job), body)
+		return job.Status != server.JobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Equal(server.JobCompleted, job.Status)
+	s.Equal([]string{"https://silence.test/wiki/xxx"}, job.URLs)
+	s.Equal(filepath.Clean("/tmp/silence"), job.Dir)
+
+	code, body = request(http.MethodPost, "/jobs", `{"urls": ["https://invalid.cn/wiki/xxx"]}`)
+	s.Equal(http.StatusCreated, code, body)
+	s.Eventually(func() bool {
+		_, body = request(http.MethodGet, "/jobs/2", "")
+		s.Require().NoError(json.Unmarshal([]byte(body), &job), body)
+		return job.Status != server.JobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Equal(server.JobFailed, job.Status)
+	s.Equal("不支持的文档来源域名: invalid.cn", job.Error)
+
+	cancel()
+	s.Require().NoError(<-served)
+	s.Equal("服务已启动, 监听地址: "+baseURL+", 按Ctrl+C退出\n正在停止服务, 取消运行中的任务\n服务已停止\n", s.out.String())
+}
+
+func (s *ServerTestSuite) Test_exec() {
+	s.vip.Set(viperKeyServerAddr, "127.0.0.1:-1")
+	err := s.cmd.exec()
+	s.Require().Error(err)
+	s.Contains(err.Error(), "监听地址失败: 127.0.0.1:-1")
+	s.Equal(ExitInvalidArgument, ExitCode(err))
+}
+
+func (s *ServerTestSuite) Test_isLoopback() {
+	s.True(isLoopback(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}))
+	s.True(isLoopback(&net.TCPAddr{IP: net.ParseIP("::1")}))
+	s.False(isLoopback(&net.TCPAddr{IP: net.ParseIP("0.0.0.0")}))
+	s.False(isLoopback(&net.UnixAddr{Name: "/tmp/xdoc.sock"}))
+}
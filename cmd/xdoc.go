@@ -32,6 +32,7 @@ import (
 
 	"github.com/acyumi/xdoc/component/app"
 	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/progress"
 )
 
 const (
@@ -41,6 +42,8 @@ const (
 	flagNameGenerateConfig    = "generate-config"    // -g --generate-config
 	flagNameQuitAutomatically = "quit-automatically" // -q --quit-automatically
 	flagNameVerbose           = "verbose"            // -V --verbose
+	flagNameProgress          = "progress"           //    --progress
+	flagNameProgressFile      = "progress-file"      //    --progress-file
 
 	viperKeyGotConfigFile = "gotConfigFile"
 )
@@ -136,6 +139,8 @@ func (c *XdocCommand) bind() error {
 	persistentFlags.BoolP(flagNameGenerateConfig, "g", false, "是否在程序目录生成config.yaml")
 	persistentFlags.BoolP(flagNameQuitAutomatically, "q", false, "是否在程序跑完后自动退出")
 	persistentFlags.BoolP(flagNameVerbose, "V", false, "是否显示详细日志")
+	persistentFlags.String(flagNameProgress, progress.ModeAuto, "进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出")
+	persistentFlags.String(flagNameProgressFile, "", "plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误")
 	// 绑定 Viper
 	// 反复测试发现目前版本的BindPFlags正常使用下不会报错，所以这里直接吃掉错误
 	_ = c.vip.BindPFlags(persistentFlags)
@@ -156,6 +161,8 @@ func (c *XdocCommand) children() []command {
 	// 这里children()只在初始化时调用一次，所以可以不缓存起来
 	return []command{
 		&exportCommand{},
+		&scheduleCommand{},
+		&serverCommand{},
 	}
 }
 
@@ -173,6 +180,11 @@ func (c *XdocCommand) PersistentPreRunE(cmd *cobra.Command, _ []string) error {
 	c.args.Verbose = c.vip.GetBool(flagNameVerbose)
 	c.args.GenerateConfig = c.vip.GetBool(flagNameGenerateConfig)
 	c.args.QuitAutomatically = c.vip.GetBool(flagNameQuitAutomatically)
+	c.args.Progress = c.vip.GetString(flagNameProgress)
+	c.args.ProgressFile = c.vip.GetString(flagNameProgressFile)
+	if err = c.args.Validate(); err != nil {
+		return oops.Wrap(err)
+	}
 	if !c.args.GenerateConfig {
 		// 如果是根命令，则打印 logo 和 帮助信息
 		if c.Command == cmd {
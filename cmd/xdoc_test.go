@@ -104,14 +104,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 `,
 		},
 		{
@@ -149,14 +151,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 `,
 		},
 		{
@@ -168,6 +172,41 @@ Flags:
 [44;97m[44;97m OK [0m[0m [96m[96m配置文件已生成: [3;32mconfig.yaml (%s)[0m[96m[0m[0m
 
 `, filepath.Clean("/tmp/config.yaml")),
+		},
+		{
+			name:      "进度展示方式不合法",
+			args:      []string{"-g", "--progress", "xml"},
+			setupMock: func(name string, root *XdocCommand) {},
+			wantCode:  "InvalidArgument",
+			wantError: "Progress: progress只能是auto、tui、plain或json.",
+			want: `Usage:
+  xdoc [flags]
+
+Examples:
+【在程序目录生成config.yaml】
+./xdoc -g
+./xdoc --generate-config
+【使用程序目录的config.yaml导出文档(需要设置相关enabled值为true)】
+./xdoc export
+【指定配置文件导出文档(需要设置相关enabled值为true)】
+./xdoc export --config ./local.yaml
+【指定命令行参数执行飞书导出】
+./xdoc export feishu --help
+./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
+
+Flags:
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
+
+`,
 		},
 		{
 			name: "生成config.yaml[指定环境变量]",
@@ -217,14 +256,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 
 `,
 		},
@@ -251,14 +292,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 
 `,
 		},
@@ -291,14 +334,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 
 `,
 		},
@@ -339,14 +384,16 @@ Examples:
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls url1,url2...
 
 Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -h, --help                 help for xdoc
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
-  -v, --version              version for xdoc
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+  -h, --help                   help for xdoc
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
+  -v, --version                version for xdoc
 `,
 		},
 		{
@@ -377,18 +424,25 @@ Examples:
 ./xdoc export feishu --help
 ./xdoc export feishu --config ./local.yaml
 ./xdoc export feishu --app-id cli_xxx --app-secret yyy --dir /tmp/docs --urls https://xxx.feishu.cn/wiki/123456789
+【运行配置文件export.jobs中的导出任务】
+./xdoc export --config ./local.yaml --job wiki
+./xdoc export --config ./local.yaml --all
 
 Flags:
-  -h, --help        help for export
-  -l, --list-only   是否只列出云文档信息不进行导出下载
+      --all          按顺序运行配置文件export.jobs中的全部导出任务, 最后输出每个任务的结果
+  -h, --help         help for export
+      --job string   只运行配置文件export.jobs中指定名称的导出任务
+  -l, --list-only    是否只列出云文档信息不进行导出下载
 
 Global Flags:
-      --config string        指定配置文件(默认使用./config.yaml), 
-                             配置文件的参数可覆盖, 
-                             优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
-  -g, --generate-config      是否在程序目录生成config.yaml
-  -q, --quit-automatically   是否在程序跑完后自动退出
-  -V, --verbose              是否显示详细日志
+      --config string          指定配置文件(默认使用./config.yaml), 
+                               配置文件的参数可覆盖, 
+                               优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+  -g, --generate-config        是否在程序目录生成config.yaml
+      --progress string        进度展示方式, 可选值: auto/tui/plain/json, auto时标准输出不是终端则使用plain逐行输出 (default "auto")
+      --progress-file string   plain或json进度的输出文件, 默认输出到标准输出, 此时json模式的其他日志输出到标准错误
+  -q, --quit-automatically     是否在程序跑完后自动退出
+  -V, --verbose                是否显示详细日志
 `,
 		},
 	}
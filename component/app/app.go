@@ -15,6 +15,7 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,10 +30,23 @@ var (
 	MarshalIndent = json.MarshalIndent
 	Executable    = os.Executable
 
-	Fs    = &afero.Afero{Fs: afero.NewOsFs()}
-	Sleep = func(duration time.Duration) { time.Sleep(duration) } // 睡眠等待函数
+	Fs           = &afero.Afero{Fs: afero.NewOsFs()}
+	Sleep        = func(duration time.Duration) { time.Sleep(duration) } // 睡眠等待函数
+	SleepContext = sleepContext                                          // 可被ctx中断的睡眠等待函数
 )
 
+// sleepContext 睡眠等待，ctx被取消时立即返回ctx.Err()。
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func NewViper() *viper.Viper {
 	vip := viper.New()
 	vip.SetFs(Fs)
@@ -15,6 +15,7 @@
 package app
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -29,6 +30,20 @@ func TestSleep(t *testing.T) {
 	require.Less(t, time.Since(start), 5*time.Millisecond)
 }
 
+func TestSleepContext(t *testing.T) {
+	require.NoError(t, SleepContext(context.Background(), time.Millisecond))
+
+	// ctx被取消时不再等待
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	require.ErrorIs(t, SleepContext(ctx, time.Hour), context.Canceled)
+	require.Less(t, time.Since(start), time.Second)
+}
+
 func TestPrint(t *testing.T) {
 	vip := NewViper()
 	require.NotNil(t, vip)
@@ -15,7 +15,13 @@
 package argument
 
 import (
+	"io"
 	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/progress"
 )
 
 // Args 程序参数，优先级：命令行参数 > 环境变量 > 配置文件 > 默认值。
@@ -26,9 +32,19 @@ type Args struct {
 	Verbose           bool   // 是否显示详细日志
 	GenerateConfig    bool   // 是否在程序目录生成config.yaml
 	QuitAutomatically bool   // 是否在程序跑完后自动退出
+	Progress          string // 进度展示方式: auto/tui/plain/json
+	ProgressFile      string // plain或json进度的输出文件，为空时输出到标准输出
 }
 
 func (a Args) Validate() error {
-	// TODO
-	return nil
+	return oops.Code("InvalidArgument").Wrap(
+		validation.ValidateStruct(&a,
+			validation.Field(&a.Progress, validation.In(progress.ModeAuto, progress.ModeTUI, progress.ModePlain, progress.ModeJSON).
+				Error("progress只能是auto、tui、plain或json")),
+		))
+}
+
+// LogOutput 返回人可读日志的输出位置，json进度输出到标准输出时改为标准错误，否则为out。
+func (a Args) LogOutput(out io.Writer) io.Writer {
+	return progress.LogOutput(a.Progress, a.ProgressFile, out)
 }
@@ -15,10 +15,14 @@
 package argument
 
 import (
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/samber/oops"
 	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/progress"
 )
 
 func TestArgs_Validate(t *testing.T) {
@@ -38,12 +42,26 @@ func TestArgs_Validate(t *testing.T) {
 				QuitAutomatically: false,
 			},
 		},
+		{
+			name: "进度展示方式有效",
+			args: Args{Progress: progress.ModePlain},
+		},
+		{
+			name:     "进度展示方式不合法",
+			args:     Args{Progress: "xml"},
+			wantErr:  "Progress: progress只能是auto、tui、plain或json.",
+			wantCode: "InvalidArgument",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.args.Validate()
 			if tt.wantErr != "" || err != nil {
 				require.Error(t, err, tt.name)
+				var oe oops.OopsError
+				require.True(t, errors.As(err, &oe), tt.name)
+				require.Equal(t, tt.wantCode, oe.Code(), tt.name)
+				require.Equal(t, tt.wantErr, oe.Error(), tt.name)
 			}
 		})
 	}
@@ -15,6 +15,8 @@
 package cloud
 
 import (
+	"context"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
@@ -31,15 +33,15 @@ type Client[A any] interface {
 	SetArgs(args A)
 	// GetArgs 获取参数
 	GetArgs() A
-	// 	DownloadDocuments 下载文档，下载过程中可通过实现和创建 Task 来执行批量下载和获取下载进度
-	DownloadDocuments([]*DocumentSource) error
+	// 	DownloadDocuments 下载文档，下载过程中可通过实现和创建 Task 来执行批量下载和获取下载进度，ctx取消时中断下载
+	DownloadDocuments(ctx context.Context, dss []*DocumentSource) error
 }
 
 // Task 云任务。
 type Task interface {
 	validation.Validatable
-	// Run 运行任务
-	Run() error
+	// Run 运行任务，ctx取消时中断任务
+	Run(ctx context.Context) error
 	// Close 关闭任务资源，一般配合defer使用
 	Close()
 	// Interrupt 中断任务
@@ -44,4 +44,7 @@ const (
 	FileExtPDF  FileExt = "pdf"
 	FileExtXlsx FileExt = "xlsx"
 	FileExtCSV  FileExt = "csv"
+
+	FileExtMarkdown FileExt = "md"   // 仅支持新版文档docx，通过读取文档块在本地转换
+	FileExtJSON     FileExt = "json" // 仅支持多维表格bitable，通过读取数据表的字段和记录在本地转换
 )
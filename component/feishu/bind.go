@@ -16,7 +16,9 @@ package feishu
 
 import (
 	"fmt"
+	"maps"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/samber/oops"
@@ -25,24 +27,77 @@ import (
 	"github.com/acyumi/xdoc/component/constant"
 )
 
+const (
+	DefaultExportWorkers   = 5  // 默认同时创建导出任务的协程数量
+	DefaultDownloadWorkers = 3  // 默认同时下载文件的协程数量
+	DefaultQueueSize       = 20 // 默认等待下载的导出结果队列大小
+)
+
 type Args struct {
 	*argument.Args
-	Enabled        bool                                  // 是否启用
-	AppID          string                                // 应用ID
-	AppSecret      string                                // 应用密钥
-	DocURLs        []string                              // 文档地址
-	SaveDir        string                                // 文档存放目录(本地)
-	FileExtensions map[constant.DocType]constant.FileExt // 文档扩展名映射, 用于指定文档下载后的文件类型
-	ListOnly       bool                                  // 是否只列出云文档信息不进行导出下载
+	Enabled          bool                                  // 是否启用
+	AppID            string                                // 应用ID
+	AppSecret        string                                // 应用密钥
+	DocURLs          []string                              // 文档地址
+	SaveDir          string                                // 文档存放目录(本地)
+	FileExtensions   map[constant.DocType]constant.FileExt // 文档扩展名映射, 用于指定文档下载后的文件类型
+	ListOnly         bool                                  // 是否只列出云文档信息不进行导出下载
+	Incremental      bool                                  // 是否增量导出，跳过与上一次document-tree.json对比未变更的文档
+	Resume           bool                                  // 是否从上一次中断的位置恢复导出，跳过导出日志中已完成的文档
+	Prune            string                                // 清理模式，清理云文档已删除或已移动的本地文件，可选值: off/dry-run/delete/quarantine
+	RewriteDocxLinks bool                                  // 是否将docx文件中指向本次已导出文档的超链接也改写为本地相对路径
+	ExportWorkers    int                                   // 同时创建导出任务的协程数量
+	DownloadWorkers  int                                   // 同时下载文件的协程数量
+	QueueSize        int                                   // 等待下载的导出结果队列大小
+	RateLimits       map[string]int                        // 各类接口每分钟最多请求次数，用于客户端限流，小于等于0时不限流
+	DiscoverTimeout  time.Duration                         // 读取云文档信息(阶段1)的超时时间，为0时不限制
+	ReportFormats    []string                              // 额外生成的导出报告格式，可选值: html/md，json格式总是会生成
+	RetryFailed      string                                // 上一次的导出报告或导出日志，不为空时只重试其中失败的文档，不再读取文档树
+	Include          []string                              // 只导出路径匹配这些glob模式的文档（及其子树），路径与阶段1打印的目录结构一致
+	Exclude          []string                              // 不导出路径匹配这些glob模式的文档及其子树
+	Types            []string                              // 只导出这些类型的文档，如 docx/sheet/bitable/pdf
+	MaxDepth         int                                   // 只导出文档树中前几层的文档，为0时不限制
+	ModifiedSince    string                                // 只导出在此时间及之后编辑过的文档，可以是日期时间或相对时长，如 2025-01-01、30d
+	ModifiedBefore   string                                // 只导出在此时间之前编辑过的文档，格式同 ModifiedSince
+	Owners           []string                              // 只导出这些用户所有的文档，值为所有者ID
+	PathTemplate     string                                // 文件保存路径模板，如 {space}/{path}/{title}_{token}.{ext}，为空时按文档层级保存
+	DuplicateNames   string                                // 同级重名文档的文件名区分策略，可选值: index/token
+	NamePolicy       string                                // 文件名清理策略，可选值: portable/posix
+	UnicodeForm      string                                // 文件名的Unicode规范化形式，可选值: nfc/nfd/none
+	MaxPathLength    int                                   // 保存路径的最大长度，超过时截断，为0时按系统默认: Windows为259，其他系统为4095
 }
 
 func (a Args) Validate() error {
+	// 重试失败的文档时不需要读取文档树，可以不指定文档地址
+	var urlRules []validation.Rule
+	if a.RetryFailed == "" {
+		urlRules = append(urlRules, validation.Required.Error("urls是必需参数"))
+	}
 	return oops.Code("InvalidArgument").Wrap(
 		validation.ValidateStruct(&a,
 			validation.Field(&a.AppID, validation.Required.Error("app-id是必需参数")),
 			validation.Field(&a.AppSecret, validation.Required.Error("app-secret是必需参数")),
-			validation.Field(&a.DocURLs, validation.Required.Error("urls是必需参数")),
+			validation.Field(&a.DocURLs, urlRules...),
 			validation.Field(&a.SaveDir, validation.Required.Error("dir是必需参数")),
+			validation.Field(&a.Prune, validation.In(PruneOff, PruneDryRun, PruneDelete, PruneQuarantine).
+				Error("prune只能是off、dry-run、delete或quarantine")),
+			validation.Field(&a.ExportWorkers, validation.Required.Error("export-workers必须大于0"), validation.Min(1).Error("export-workers必须大于0")),
+			validation.Field(&a.DownloadWorkers, validation.Required.Error("download-workers必须大于0"), validation.Min(1).Error("download-workers必须大于0")),
+			validation.Field(&a.QueueSize, validation.Required.Error("queue-size必须大于0"), validation.Min(1).Error("queue-size必须大于0")),
+			validation.Field(&a.RateLimits, validation.By(validateRateLimits)),
+			validation.Field(&a.DiscoverTimeout, validation.Min(time.Duration(0)).Error("discover-timeout不能小于0")),
+			validation.Field(&a.ReportFormats, validation.By(validateReportFormats)),
+			validation.Field(&a.MaxDepth, validation.Min(0).Error("max-depth不能小于0")),
+			validation.Field(&a.ModifiedSince, validation.By(validateTimeFilter)),
+			validation.Field(&a.ModifiedBefore, validation.By(validateTimeFilter)),
+			validation.Field(&a.PathTemplate, validation.By(validatePathTemplate)),
+			validation.Field(&a.DuplicateNames, validation.In(DuplicateNamesIndex, DuplicateNamesToken).
+				Error("duplicate-names只能是index或token")),
+			validation.Field(&a.NamePolicy, validation.In(NamePolicyPortable, NamePolicyPOSIX).
+				Error("name-policy只能是portable或posix")),
+			validation.Field(&a.UnicodeForm, validation.In(UnicodeFormNFC, UnicodeFormNFD, UnicodeFormNone).
+				Error("unicode-form只能是nfc、nfd或none")),
+			validation.Field(&a.MaxPathLength, validation.Min(0).Error("max-path-length不能小于0")),
 		))
 }
 
@@ -55,6 +110,14 @@ func (a *Args) SetFileExtensions(fes map[string]string) {
 	}
 }
 
+// SetRateLimits 设置各类接口每分钟最多请求次数，未设置过时以默认值为基础覆盖。
+func (a *Args) SetRateLimits(limits map[string]int) {
+	if a.RateLimits == nil {
+		a.RateLimits = maps.Clone(DefaultRateLimits)
+	}
+	maps.Copy(a.RateLimits, limits)
+}
+
 func (a *Args) DesensitizeSlice(str ...string) (res []string) {
 	for _, s := range str {
 		s = a.Desensitize(s)
@@ -17,6 +17,7 @@ package feishu
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/samber/oops"
 	"github.com/stretchr/testify/assert"
@@ -33,13 +34,83 @@ func TestArgs_Validate(t *testing.T) {
 		AppSecret string
 		DocURLs   []string
 		SaveDir   string
+		setup     func(a *Args)
 		expected  string
 	}{
-		{"AppID 为空", "", "valid_secret", []string{"valid_url"}, "valid_dir", "AppID: app-id是必需参数."},
-		{"AppSecret 为空", "valid_id", "", []string{"valid_url"}, "valid_dir", "AppSecret: app-secret是必需参数."},
-		{"DocURLs 为空", "valid_id", "valid_secret", []string{}, "valid_dir", "DocURLs: urls是必需参数."},
-		{"SaveDir 为空", "valid_id", "valid_secret", []string{"valid_url"}, "", "SaveDir: dir是必需参数."},
-		{"所有参数都有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", ""},
+		{"AppID 为空", "", "valid_secret", []string{"valid_url"}, "valid_dir", nil, "AppID: app-id是必需参数."},
+		{"AppSecret 为空", "valid_id", "", []string{"valid_url"}, "valid_dir", nil, "AppSecret: app-secret是必需参数."},
+		{"DocURLs 为空", "valid_id", "valid_secret", []string{}, "valid_dir", nil, "DocURLs: urls是必需参数."},
+		{"SaveDir 为空", "valid_id", "valid_secret", []string{"valid_url"}, "", nil, "SaveDir: dir是必需参数."},
+		{"所有参数都有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", nil, ""},
+		{"Prune 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.Prune = "all"
+		}, "Prune: prune只能是off、dry-run、delete或quarantine."},
+		{"Prune 有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.Prune = PruneQuarantine
+		}, ""},
+		{"ExportWorkers 为0", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ExportWorkers = 0
+		}, "ExportWorkers: export-workers必须大于0."},
+		{"DownloadWorkers 为负数", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.DownloadWorkers = -1
+		}, "DownloadWorkers: download-workers必须大于0."},
+		{"QueueSize 为0", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.QueueSize = 0
+		}, "QueueSize: queue-size必须大于0."},
+		{"RateLimits 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.RateLimits = map[string]int{"im": 100}
+		}, "RateLimits: rate-limits不支持的接口类别: im."},
+		{"协程数量和队列大小有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ExportWorkers = 10
+			a.DownloadWorkers = 1
+			a.QueueSize = 1
+		}, ""},
+		{"DiscoverTimeout 为负数", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.DiscoverTimeout = -time.Second
+		}, "DiscoverTimeout: discover-timeout不能小于0."},
+		{"ReportFormats 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ReportFormats = []string{ReportHTML, "pdf"}
+		}, "ReportFormats: report-formats只能是json、html或md: pdf."},
+		{"MaxDepth 为负数", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.MaxDepth = -1
+		}, "MaxDepth: max-depth不能小于0."},
+		{"ModifiedSince 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ModifiedSince = "last month"
+		}, "ModifiedSince: 时间格式不合法, 应为日期时间如2025-01-01或相对时长如30d: last month."},
+		{"ModifiedBefore 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ModifiedBefore = "-30d"
+		}, "ModifiedBefore: 时间格式不合法, 应为日期时间如2025-01-01或相对时长如30d: -30d."},
+		{"编辑时间过滤条件有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ModifiedSince = "30d"
+			a.ModifiedBefore = "2025-06-01"
+		}, ""},
+		{"PathTemplate 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.PathTemplate = "{type}/{name}.{ext}"
+		}, "PathTemplate: path-template不支持的占位符: {name}."},
+		{"PathTemplate 有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.PathTemplate = "{space}/{path}/{title}_{token}.{ext}"
+		}, ""},
+		{"DuplicateNames 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.DuplicateNames = "hash"
+		}, "DuplicateNames: duplicate-names只能是index或token."},
+		{"NamePolicy 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.NamePolicy = "windows"
+		}, "NamePolicy: name-policy只能是portable或posix."},
+		{"UnicodeForm 不合法", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.UnicodeForm = "nfkc"
+		}, "UnicodeForm: unicode-form只能是nfc、nfd或none."},
+		{"MaxPathLength 小于0", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.MaxPathLength = -1
+		}, "MaxPathLength: max-path-length不能小于0."},
+		{"重试失败的文档时 DocURLs 可以为空", "valid_id", "valid_secret", []string{}, "valid_dir", func(a *Args) {
+			a.RetryFailed = "valid_dir/export-report.json"
+		}, ""},
+		{"ReportFormats 有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.ReportFormats = []string{ReportHTML, ReportMarkdown}
+		}, ""},
+		{"DiscoverTimeout 有效", "valid_id", "valid_secret", []string{"valid_url"}, "valid_dir", func(a *Args) {
+			a.DiscoverTimeout = time.Minute
+		}, ""},
 	}
 
 	for _, tt := range tests {
@@ -49,6 +120,12 @@ func TestArgs_Validate(t *testing.T) {
 			args.AppSecret = tt.AppSecret
 			args.DocURLs = tt.DocURLs
 			args.SaveDir = tt.SaveDir
+			args.ExportWorkers = DefaultExportWorkers
+			args.DownloadWorkers = DefaultDownloadWorkers
+			args.QueueSize = DefaultQueueSize
+			if tt.setup != nil {
+				tt.setup(&args)
+			}
 			err := args.Validate()
 			if tt.expected == "" {
 				assert.NoError(t, err, tt.name)
@@ -98,6 +175,18 @@ func TestArgs_SetFileExtensions(t *testing.T) {
 	}
 }
 
+func TestArgs_SetRateLimits(t *testing.T) {
+	var args Args
+	args.SetRateLimits(nil)
+	require.Equal(t, DefaultRateLimits, args.RateLimits)
+	args.SetRateLimits(map[string]int{RateLimitWiki: 10})
+	args.SetRateLimits(map[string]int{RateLimitExport: 0})
+	require.Equal(t, 10, args.RateLimits[RateLimitWiki])
+	require.Equal(t, 0, args.RateLimits[RateLimitExport])
+	require.Equal(t, DefaultRateLimits[RateLimitDocx], args.RateLimits[RateLimitDocx])
+	require.Equal(t, 100, DefaultRateLimits[RateLimitWiki], "不能修改默认值")
+}
+
 func TestArgs_DesensitizeSlice(t *testing.T) {
 	tests := []struct {
 		name     string
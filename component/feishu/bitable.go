@@ -0,0 +1,350 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	"github.com/samber/oops"
+	"github.com/spf13/cast"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 需要解析为可读值的多维表格字段类型。
+// https://open.feishu.cn/document/server-docs/docs/bitable-v1/bitable-structure
+const (
+	bitableFieldText         = 1    // 多行文本
+	bitableFieldUser         = 11   // 人员
+	bitableFieldAttachment   = 17   // 附件
+	bitableFieldSingleLink   = 18   // 单向关联
+	bitableFieldDuplexLink   = 21   // 双向关联
+	bitableFieldCreatedUser  = 1003 // 创建人
+	bitableFieldModifiedUser = 1004 // 修改人
+)
+
+// bitableExport 多维表格导出为JSON的结构。
+type bitableExport struct {
+	Name   string          `json:"name"`   // 多维表格名
+	Token  string          `json:"token"`  // 多维表格token
+	Tables []*bitableTable `json:"tables"` // 数据表
+}
+
+// bitableTable 数据表，包括字段定义和全部记录。
+type bitableTable struct {
+	TableID string           `json:"tableId"` // 数据表ID
+	Name    string           `json:"name"`    // 数据表名
+	Fields  []*bitableField  `json:"fields"`  // 字段定义
+	Records []*bitableRecord `json:"records"` // 记录
+
+	primaryField string // 索引列的字段名，用于展示关联记录
+}
+
+// bitableField 字段定义。
+type bitableField struct {
+	FieldID   string                             `json:"fieldId"`            // 字段ID
+	FieldName string                             `json:"fieldName"`          // 字段名
+	Type      int                                `json:"type"`               // 字段类型
+	UIType    string                             `json:"uiType"`             // 字段在界面上的展示类型
+	IsPrimary bool                               `json:"isPrimary"`          // 是否是索引列
+	Property  *larkbitable.AppTableFieldProperty `json:"property,omitempty"` // 字段属性，如单选、多选的选项
+}
+
+// bitableRecord 记录，字段值以字段名为key。
+type bitableRecord struct {
+	RecordID string         `json:"recordId"` // 记录ID
+	Fields   map[string]any `json:"fields"`   // 字段值
+}
+
+// bitableAttachment 附件字段中的一个附件，临时下载链接会过期，所以不保留。
+type bitableAttachment struct {
+	Name      string `json:"name"`      // 文件名
+	Type      string `json:"type"`      // 文件的MIME类型
+	Size      int64  `json:"size"`      // 文件大小，单位字节
+	FileToken string `json:"fileToken"` // 文件token
+}
+
+// convertBitable 读取多维表格所有数据表的字段和记录，转换为JSON。
+// 人员字段解析为姓名，附件字段解析为文件信息，关联字段解析为关联记录的索引列的值。
+func (e *exporter) convertBitable(ctx context.Context, di *DocumentInfo) ([]byte, error) {
+	tables, err := e.listBitableTables(ctx, di)
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	for i, table := range tables {
+		if table.Fields, err = e.listBitableFields(ctx, di, table); err != nil {
+			return nil, oops.Wrap(err)
+		}
+		if table.Records, err = e.listBitableRecords(ctx, di, table, i+1, len(tables)); err != nil {
+			return nil, oops.Wrap(err)
+		}
+	}
+	resolveBitableValues(tables)
+	data, err := app.MarshalIndent(&bitableExport{Name: di.Name, Token: di.Token, Tables: tables}, "", "  ")
+	return data, oops.Wrap(err)
+}
+
+// listBitableTables 分页查询多维表格的所有数据表。
+func (e *exporter) listBitableTables(ctx context.Context, di *DocumentInfo) ([]*bitableTable, error) {
+	var tables []*bitableTable
+	var pageToken string
+	for {
+		req := larkbitable.NewListAppTableReqBuilder().AppToken(di.Token).PageToken(pageToken).PageSize(100).Build()
+		resp, err := SendWithRetry(func(count int) (*larkbitable.ListAppTableResp, error) {
+			e.program.Update(di.FilePath, 0.18, progress.StatusDownloading, "读取数据表, 请求%d次", count)
+			return e.client.BitableTableList(ctx, req)
+		})
+		if err != nil {
+			if resp != nil && !resp.Success() {
+				return nil, oops.New(toErrMsg(resp, "列出数据表"))
+			}
+			return nil, oops.Wrap(err)
+		}
+		for _, item := range resp.Data.Items {
+			tables = append(tables, &bitableTable{TableID: larkcore.StringValue(item.TableId), Name: larkcore.StringValue(item.Name)})
+		}
+		if !larkcore.BoolValue(resp.Data.HasMore) {
+			return tables, nil
+		}
+		pageToken = larkcore.StringValue(resp.Data.PageToken)
+	}
+}
+
+// listBitableFields 分页查询数据表的所有字段。
+func (e *exporter) listBitableFields(ctx context.Context, di *DocumentInfo, table *bitableTable) ([]*bitableField, error) {
+	var fields []*bitableField
+	var pageToken string
+	for {
+		req := larkbitable.NewListAppTableFieldReqBuilder().
+			AppToken(di.Token).
+			TableId(table.TableID).
+			PageToken(pageToken).
+			PageSize(100).
+			Build()
+		resp, err := SendWithRetry(func(count int) (*larkbitable.ListAppTableFieldResp, error) {
+			e.program.Update(di.FilePath, 0.18, progress.StatusDownloading, "读取数据表[%s]的字段, 请求%d次", table.Name, count)
+			return e.client.BitableFieldList(ctx, req)
+		})
+		if err != nil {
+			if resp != nil && !resp.Success() {
+				return nil, oops.New(toErrMsg(resp, "列出字段"))
+			}
+			return nil, oops.Wrap(err)
+		}
+		for _, item := range resp.Data.Items {
+			field := &bitableField{
+				FieldID:   larkcore.StringValue(item.FieldId),
+				FieldName: larkcore.StringValue(item.FieldName),
+				Type:      larkcore.IntValue(item.Type),
+				UIType:    larkcore.StringValue(item.UiType),
+				IsPrimary: larkcore.BoolValue(item.IsPrimary),
+				Property:  item.Property,
+			}
+			if field.IsPrimary {
+				table.primaryField = field.FieldName
+			}
+			fields = append(fields, field)
+		}
+		if !larkcore.BoolValue(resp.Data.HasMore) {
+			return fields, nil
+		}
+		pageToken = larkcore.StringValue(resp.Data.PageToken)
+	}
+}
+
+// listBitableRecords 分页查询数据表的所有记录。
+func (e *exporter) listBitableRecords(ctx context.Context, di *DocumentInfo, table *bitableTable, index, total int) ([]*bitableRecord, error) {
+	var records []*bitableRecord
+	var pageToken string
+	for {
+		req := larkbitable.NewListAppTableRecordReqBuilder().
+			AppToken(di.Token).
+			TableId(table.TableID).
+			PageToken(pageToken).
+			PageSize(500).
+			Build()
+		resp, err := SendWithRetry(func(count int) (*larkbitable.ListAppTableRecordResp, error) {
+			e.program.Update(di.FilePath, 0.18, progress.StatusDownloading, "读取数据表%d/%d, 记录%d条, 请求%d次", index, total, len(records), count)
+			return e.client.BitableRecordList(ctx, req)
+		})
+		if err != nil {
+			if resp != nil && !resp.Success() {
+				return nil, oops.New(toErrMsg(resp, "列出记录"))
+			}
+			return nil, oops.Wrap(err)
+		}
+		for _, item := range resp.Data.Items {
+			fields := item.Fields
+			if fields == nil {
+				fields = map[string]any{}
+			}
+			records = append(records, &bitableRecord{RecordID: larkcore.StringValue(item.RecordId), Fields: fields})
+		}
+		if !larkcore.BoolValue(resp.Data.HasMore) {
+			return records, nil
+		}
+		pageToken = larkcore.StringValue(resp.Data.PageToken)
+	}
+}
+
+// resolveBitableValues 将人员、附件、关联等字段的值解析为可读的值。
+// 关联字段需要用到关联数据表的记录，所以要在读取完所有数据表后再解析。
+func resolveBitableValues(tables []*bitableTable) {
+	// 数据表ID -> 记录ID -> 索引列的值
+	primaryValues := map[string]map[string]string{}
+	for _, table := range tables {
+		values := make(map[string]string, len(table.Records))
+		for _, record := range table.Records {
+			values[record.RecordID] = bitableText(record.Fields[table.primaryField])
+		}
+		primaryValues[table.TableID] = values
+	}
+	for _, table := range tables {
+		for _, field := range table.Fields {
+			for _, record := range table.Records {
+				value, ok := record.Fields[field.FieldName]
+				if !ok || value == nil {
+					continue
+				}
+				switch field.Type {
+				case bitableFieldText:
+					record.Fields[field.FieldName] = bitableText(value)
+				case bitableFieldUser, bitableFieldCreatedUser, bitableFieldModifiedUser:
+					record.Fields[field.FieldName] = bitableUsers(value)
+				case bitableFieldAttachment:
+					record.Fields[field.FieldName] = bitableAttachments(value)
+				case bitableFieldSingleLink, bitableFieldDuplexLink:
+					var linkedTableID string
+					if field.Property != nil {
+						linkedTableID = larkcore.StringValue(field.Property.TableId)
+					}
+					record.Fields[field.FieldName] = bitableLinks(value, primaryValues[linkedTableID])
+				}
+			}
+		}
+	}
+}
+
+// bitableText 将字段值转为文本，多行文本可能是由多个文本片段（如文本、@人、链接）组成的数组。
+func bitableText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		// 文本片段直接拼接，其他值（如多选）用逗号连接
+		parts := make([]string, 0, len(v))
+		isSegments := true
+		for _, item := range v {
+			segment, ok := item.(map[string]any)
+			if _, hasText := segment["text"]; !ok || !hasText {
+				isSegments = false
+			}
+			parts = append(parts, bitableText(item))
+		}
+		if isSegments {
+			return strings.Join(parts, "")
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		if text, ok := v["text"]; ok {
+			return cast.ToString(text)
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return cast.ToString(v)
+	}
+}
+
+// bitableUsers 将人员字段解析为姓名列表。
+func bitableUsers(value any) []string {
+	names := []string{}
+	for _, item := range toAnySlice(value) {
+		user, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name := cast.ToString(user["name"])
+		if name == "" {
+			name = cast.ToString(user["id"])
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// bitableAttachments 将附件字段解析为文件信息列表。
+func bitableAttachments(value any) []*bitableAttachment {
+	attachments := []*bitableAttachment{}
+	for _, item := range toAnySlice(value) {
+		file, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		attachments = append(attachments, &bitableAttachment{
+			Name:      cast.ToString(file["name"]),
+			Type:      cast.ToString(file["type"]),
+			Size:      cast.ToInt64(file["size"]),
+			FileToken: cast.ToString(file["file_token"]),
+		})
+	}
+	return attachments
+}
+
+// bitableLinks 将关联字段解析为关联记录的索引列的值，找不到关联记录时保留记录ID。
+// 关联字段的值可能是 {"link_record_ids": [...]}、记录ID数组或 [{"record_ids": [...]}] 格式。
+func bitableLinks(value any, primaryValues map[string]string) []string {
+	var recordIDs []string
+	switch v := value.(type) {
+	case map[string]any:
+		recordIDs = cast.ToStringSlice(v["link_record_ids"])
+	default:
+		for _, item := range toAnySlice(v) {
+			if link, ok := item.(map[string]any); ok {
+				recordIDs = append(recordIDs, cast.ToStringSlice(link["record_ids"])...)
+				continue
+			}
+			recordIDs = append(recordIDs, cast.ToString(item))
+		}
+	}
+	texts := make([]string, 0, len(recordIDs))
+	for _, id := range recordIDs {
+		if text, ok := primaryValues[id]; ok {
+			texts = append(texts, text)
+			continue
+		}
+		texts = append(texts, id)
+	}
+	return texts
+}
+
+// toAnySlice 单个对象也作为只有一个元素的数组处理。
+func toAnySlice(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case nil:
+		return nil
+	default:
+		return []any{v}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+func TestExporter_convertBitable(t *testing.T) {
+	mockClient := NewMockClient(t)
+	mockProgram := NewMockProgram(t)
+	e := &exporter{client: mockClient, program: mockProgram, media: &mediaStore{}}
+	di := &DocumentInfo{Name: "多维表格", Token: "appToken", Type: constant.DocTypeBitable, FilePath: "/tmp/多维表格.json"}
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything).Return().Maybe()
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+	unmarshal := func(data string, v any) {
+		require.NoError(t, json.Unmarshal([]byte(data), v))
+	}
+	var tables larkbitable.ListAppTableRespData
+	unmarshal(`{"has_more": false, "items": [{"table_id": "tbl1", "name": "任务"}, {"table_id": "tbl2", "name": "项目"}]}`, &tables)
+	mockClient.EXPECT().BitableTableList(mock.Anything, mock.Anything).
+		Return(&larkbitable.ListAppTableResp{Data: &tables}, nil).Once()
+
+	var fields1, fields2 larkbitable.ListAppTableFieldRespData
+	unmarshal(`{"has_more": false, "items": [
+  {"field_id": "fld1", "field_name": "标题", "type": 1, "ui_type": "Text", "is_primary": true},
+  {"field_id": "fld2", "field_name": "状态", "type": 3, "ui_type": "SingleSelect", "property": {"options": [{"id": "opt1", "name": "完成", "color": 0}]}},
+  {"field_id": "fld3", "field_name": "负责人", "type": 11, "ui_type": "User"},
+  {"field_id": "fld4", "field_name": "附件", "type": 17, "ui_type": "Attachment"},
+  {"field_id": "fld5", "field_name": "项目", "type": 18, "ui_type": "SingleLink", "property": {"table_id": "tbl2"}}
+]}`, &fields1)
+	unmarshal(`{"has_more": false, "items": [{"field_id": "fld1", "field_name": "项目名", "type": 1, "ui_type": "Text", "is_primary": true}]}`, &fields2)
+	mockClient.EXPECT().BitableFieldList(mock.Anything, larkbitable.NewListAppTableFieldReqBuilder().
+		AppToken("appToken").TableId("tbl1").PageToken("").PageSize(100).Build()).
+		Return(&larkbitable.ListAppTableFieldResp{Data: &fields1}, nil).Once()
+	mockClient.EXPECT().BitableFieldList(mock.Anything, larkbitable.NewListAppTableFieldReqBuilder().
+		AppToken("appToken").TableId("tbl2").PageToken("").PageSize(100).Build()).
+		Return(&larkbitable.ListAppTableFieldResp{Data: &fields2}, nil).Once()
+
+	// 第一个数据表的记录分两页
+	var records1, records2, records3 larkbitable.ListAppTableRecordRespData
+	unmarshal(`{"has_more": true, "page_token": "next", "items": [
+  {"record_id": "rec1", "fields": {
+    "标题": [{"type": "text", "text": "写"}, {"type": "text", "text": "文档"}],
+    "状态": "完成",
+    "负责人": [{"id": "ou_1", "name": "张三", "email": "zhangsan@example.com"}],
+    "附件": [{"file_token": "boxToken", "name": "a.png", "size": 1024, "type": "image/png", "tmp_url": "https://tmp"}],
+    "项目": {"link_record_ids": ["recP1", "recP9"]}
+  }}
+]}`, &records1)
+	unmarshal(`{"has_more": false, "items": [{"record_id": "rec2"}]}`, &records2)
+	unmarshal(`{"has_more": false, "items": [{"record_id": "recP1", "fields": {"项目名": "项目A"}}]}`, &records3)
+	mockClient.EXPECT().BitableRecordList(mock.Anything, larkbitable.NewListAppTableRecordReqBuilder().
+		AppToken("appToken").TableId("tbl1").PageToken("").PageSize(500).Build()).
+		Return(&larkbitable.ListAppTableRecordResp{Data: &records1}, nil).Once()
+	mockClient.EXPECT().BitableRecordList(mock.Anything, larkbitable.NewListAppTableRecordReqBuilder().
+		AppToken("appToken").TableId("tbl1").PageToken("next").PageSize(500).Build()).
+		Return(&larkbitable.ListAppTableRecordResp{Data: &records2}, nil).Once()
+	mockClient.EXPECT().BitableRecordList(mock.Anything, larkbitable.NewListAppTableRecordReqBuilder().
+		AppToken("appToken").TableId("tbl2").PageToken("").PageSize(500).Build()).
+		Return(&larkbitable.ListAppTableRecordResp{Data: &records3}, nil).Once()
+
+	file, length, err := e.doConvert(context.Background(), di)
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), length)
+	require.JSONEq(t, `{
+  "name": "多维表格",
+  "token": "appToken",
+  "tables": [
+    {
+      "tableId": "tbl1",
+      "name": "任务",
+      "fields": [
+        {"fieldId": "fld1", "fieldName": "标题", "type": 1, "uiType": "Text", "isPrimary": true},
+        {"fieldId": "fld2", "fieldName": "状态", "type": 3, "uiType": "SingleSelect", "isPrimary": false,
+         "property": {"options": [{"id": "opt1", "name": "完成", "color": 0}]}},
+        {"fieldId": "fld3", "fieldName": "负责人", "type": 11, "uiType": "User", "isPrimary": false},
+        {"fieldId": "fld4", "fieldName": "附件", "type": 17, "uiType": "Attachment", "isPrimary": false},
+        {"fieldId": "fld5", "fieldName": "项目", "type": 18, "uiType": "SingleLink", "isPrimary": false,
+         "property": {"table_id": "tbl2"}}
+      ],
+      "records": [
+        {"recordId": "rec1", "fields": {
+          "标题": "写文档",
+          "状态": "完成",
+          "负责人": ["张三"],
+          "附件": [{"name": "a.png", "type": "image/png", "size": 1024, "fileToken": "boxToken"}],
+          "项目": ["项目A", "recP9"]
+        }},
+        {"recordId": "rec2", "fields": {}}
+      ]
+    },
+    {
+      "tableId": "tbl2",
+      "name": "项目",
+      "fields": [{"fieldId": "fld1", "fieldName": "项目名", "type": 1, "uiType": "Text", "isPrimary": true}],
+      "records": [{"recordId": "recP1", "fields": {"项目名": "项目A"}}]
+    }
+  ]
+}`, string(data))
+}
+
+func TestExporter_convertBitable_error(t *testing.T) {
+	mockClient := NewMockClient(t)
+	mockProgram := NewMockProgram(t)
+	e := &exporter{client: mockClient, program: mockProgram, media: &mediaStore{}}
+	di := &DocumentInfo{Name: "多维表格", Token: "appToken", Type: constant.DocTypeBitable, FilePath: "/tmp/多维表格.json"}
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything).Return().Maybe()
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+	// 列出数据表失败
+	mockClient.EXPECT().BitableTableList(mock.Anything, mock.Anything).Return(nil, errors.New("API调用失败")).Once()
+	_, _, err := e.doConvert(context.Background(), di)
+	require.EqualError(t, err, "API调用失败")
+
+	// 列出字段失败
+	tables := &larkbitable.ListAppTableRespData{Items: []*larkbitable.AppTable{{TableId: larkcore.StringPtr("tbl1")}}}
+	mockClient.EXPECT().BitableTableList(mock.Anything, mock.Anything).Return(&larkbitable.ListAppTableResp{Data: tables}, nil).Times(2)
+	resp := &larkbitable.ListAppTableFieldResp{
+		ApiResp:   &larkcore.ApiResp{StatusCode: 403},
+		CodeError: larkcore.CodeError{Code: 91403, Msg: "Forbidden"},
+	}
+	mockClient.EXPECT().BitableFieldList(mock.Anything, mock.Anything).Return(checkResp(resp, nil)).Once()
+	_, _, err = e.doConvert(context.Background(), di)
+	require.ErrorContains(t, err, "操作: 列出字段, 响应错误: msg:Forbidden,code:91403")
+
+	// 列出记录失败
+	mockClient.EXPECT().BitableFieldList(mock.Anything, mock.Anything).Return(&larkbitable.ListAppTableFieldResp{Data: &larkbitable.ListAppTableFieldRespData{}}, nil).Once()
+	mockClient.EXPECT().BitableRecordList(mock.Anything, mock.Anything).Return(nil, errors.New("API调用失败")).Once()
+	_, _, err = e.doConvert(context.Background(), di)
+	require.EqualError(t, err, "API调用失败")
+}
+
+func TestBitableText(t *testing.T) {
+	require.Empty(t, bitableText(nil))
+	require.Equal(t, "文本", bitableText("文本"))
+	require.Equal(t, "1.5", bitableText(1.5))
+	require.Equal(t, "a@张三", bitableText([]any{map[string]any{"text": "a"}, map[string]any{"type": "mention", "text": "@张三"}}))
+	require.Equal(t, "选项1,选项2", bitableText([]any{"选项1", "选项2"}))
+	require.Equal(t, "链接", bitableText(map[string]any{"link": "https://example.com", "text": "链接"}))
+	require.Equal(t, `{"a":1}`, bitableText(map[string]any{"a": 1}))
+}
+
+func TestBitableUsers(t *testing.T) {
+	require.Equal(t, []string{"张三", "ou_2"}, bitableUsers([]any{
+		map[string]any{"id": "ou_1", "name": "张三"},
+		map[string]any{"id": "ou_2"},
+		"invalid",
+	}))
+	require.Equal(t, []string{"李四"}, bitableUsers(map[string]any{"id": "ou_3", "name": "李四"}))
+	require.Empty(t, bitableUsers(nil))
+}
+
+func TestBitableAttachments(t *testing.T) {
+	require.Equal(t, []*bitableAttachment{{Name: "a.pdf", Type: "application/pdf", Size: 10, FileToken: "token"}},
+		bitableAttachments([]any{map[string]any{"name": "a.pdf", "type": "application/pdf", "size": 10, "file_token": "token"}, "invalid"}))
+	require.Empty(t, bitableAttachments(nil))
+}
+
+func TestBitableLinks(t *testing.T) {
+	primaryValues := map[string]string{"rec1": "记录1"}
+	require.Equal(t, []string{"记录1", "rec2"}, bitableLinks(map[string]any{"link_record_ids": []any{"rec1", "rec2"}}, primaryValues))
+	require.Equal(t, []string{"记录1"}, bitableLinks([]any{"rec1"}, primaryValues))
+	require.Equal(t, []string{"记录1", "rec3"}, bitableLinks([]any{map[string]any{"record_ids": []any{"rec1", "rec3"}}}, primaryValues))
+	require.Empty(t, bitableLinks(nil, nil))
+}
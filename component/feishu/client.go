@@ -16,16 +16,20 @@ package feishu
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	lark "github.com/larksuite/oapi-sdk-go/v3"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+	larksheets "github.com/larksuite/oapi-sdk-go/v3/service/sheets/v3"
 	larkwiki "github.com/larksuite/oapi-sdk-go/v3/service/wiki/v2"
+	"github.com/samber/lo"
 	"github.com/samber/oops"
 	"github.com/xlab/treeprint"
 
@@ -35,10 +39,15 @@ import (
 	"github.com/acyumi/xdoc/component/progress"
 )
 
+// authErrorCodes 访问凭证缺失、无效或过期的错误码，见 https://open.feishu.cn/document/server-docs/api-call-guide/generic-error-code
+var authErrorCodes = map[int]bool{99991661: true, 99991663: true, 99991664: true, 99991668: true}
+
 type ClientImpl struct {
 	*lark.Client
-	Args        *Args
-	TaskCreator func(args *Args, docs []*DocumentNode) cloud.Task
+	Args               *Args
+	TaskCreator        func(args *Args, docs []*DocumentNode) cloud.Task
+	ProgramConstructor func(progress.Stats) progress.IProgram // 不为空时使用它展示进度，而不是按Args.Progress创建
+	limiter            rateLimiter                            // 客户端限流器，所有接口调用前都要先取得令牌
 }
 
 func NewClient(args *Args) cloud.Client[*Args] {
@@ -50,6 +59,7 @@ func NewClient(args *Args) cloud.Client[*Args] {
 func (c *ClientImpl) SetArgs(args *Args) {
 	c.Client = lark.NewClient(args.AppID, args.AppSecret)
 	c.Args = args
+	c.limiter = newRateLimiter(args.RateLimits)
 }
 
 func (c *ClientImpl) GetArgs() *Args {
@@ -64,62 +74,179 @@ func (c ClientImpl) Validate() error {
 		))
 }
 
-func (c *ClientImpl) DownloadDocuments(docSources []*cloud.DocumentSource) error {
-	fmt.Println("阶段1: 读取飞书云文档信息")
-	fmt.Println("--------------------------")
-	var dns []*DocumentNode
-	for _, ds := range docSources {
-		dn, err := c.QueryDocuments(ds.Type, ds.Token)
+func (c *ClientImpl) DownloadDocuments(ctx context.Context, docSources []*cloud.DocumentSource) error {
+	if c.Args.RetryFailed != "" {
+		return c.retryFailed(ctx)
+	}
+	logOutput := c.Args.LogOutput(os.Stdout)
+	app.Fprintln(logOutput, "阶段1: 读取飞书云文档信息")
+	app.Fprintln(logOutput, "--------------------------")
+	dns, err := c.discoverDocuments(ctx, docSources)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	// 读取上一次的文档树，用于沿用重名文档的文件名、增量导出和清理本地文件
+	previous, err := loadDocumentTree(c.Args.SaveDir)
+	prune := c.Args.Prune != "" && c.Args.Prune != PruneOff
+	if err != nil && (c.Args.Incremental || prune) {
+		return oops.Wrap(err)
+	}
+	// 调整文件名，计算文件保存路径
+	resolveNames(dns, c.Args.DuplicateNames, previous)
+	infoList := documentNodesToInfoList(dns, c.Args.SaveDir)
+	// 按过滤条件标记不导出的文档，已排除的文档仍然保留在文档树中，避免清理本地文件时被当作已删除
+	excludedCount := filterDocuments(dns, c.Args)
+	// 指定了路径模板时按模板重新计算文件保存路径
+	dedupedCount := applyPathTemplate(dns, c.Args.SaveDir, c.Args.PathTemplate)
+	// 截断超长的保存路径
+	limitedCount := newPathSanitizer(c.Args).limitPaths(infoList, c.Args.SaveDir)
+
+	// 增量导出，对比上一次的文档树，跳过未变更的文档
+	var skippedCount int
+	if c.Args.Incremental {
+		var err error
+		if skippedCount, err = markUnchanged(dns, previous); err != nil {
+			return oops.Wrap(err)
+		}
+	}
+
+	// 对比上一次的文档树中属于本次文档源的部分，找出云文档已删除或已移动的本地文件
+	var staleFiles, staleDirs []string
+	if prune {
+		var err error
+		if staleFiles, staleDirs, err = findStaleFiles(infoList, scopePrevious(dns, previous), c.Args.SaveDir); err != nil {
+			return oops.Wrap(err)
+		}
+	}
+
+	// 只列出将被清理的文件时不保存文档树，保证下次执行时仍能对比出这些文件
+	listOnly := c.Args.ListOnly || c.Args.Prune == PruneDryRun
+	if !prune || !listOnly {
+		// 将查询到的文档树信息保存到document-tree.json文件中
+		err := saveDocumentTree(dns, c.Args.SaveDir)
 		if err != nil {
 			return oops.Wrap(err)
 		}
-		dns = append(dns, dn)
 	}
-	// 去重，可能dns中的树是互相包含的关系
-	dns = deduplication(dns)
 
-	// 将查询到的文档树信息保存到文件中
-	// 创建目录
-	err := app.Fs.MkdirAll(c.Args.SaveDir, 0o755)
-	if err != nil {
-		return oops.Wrap(err)
+	app.Fprintln(logOutput, "预计将目录或文件保存如下:")
+	tree := treeprint.NewWithRoot(c.Args.SaveDir)
+	totalCount, canDownloadCount := printTree(logOutput, tree, dns, 0, 0)
+	app.Fprintf(logOutput, "\n查询总数量: %d, 可下载文档数量: %d\n", totalCount, canDownloadCount)
+	if c.Args.PathTemplate != "" {
+		app.Fprintf(logOutput, "按路径模板保存文件: %s\n", c.Args.PathTemplate)
 	}
-	// 将文档树信息保存到document-tree.json文件中
-	filePath := filepath.Join(c.Args.SaveDir, "document-tree.json")
-	diBytes, err := app.MarshalIndent(dns, "", "  ")
-	if err != nil {
+	if dedupedCount > 0 {
+		app.Fprintf(logOutput, "按路径模板保存路径重复已追加token的文档数量: %d\n", dedupedCount)
+	}
+	if limitedCount > 0 {
+		app.Fprintf(logOutput, "保存路径过长已截断的文档数量: %d\n", limitedCount)
+	}
+	if c.Args.hasFilters() {
+		app.Fprintf(logOutput, "按过滤条件排除的文档数量: %d\n", excludedCount)
+	}
+	if c.Args.Incremental {
+		app.Fprintf(logOutput, "增量导出, 未变更跳过的文档数量: %d\n", skippedCount)
+	}
+	if prune {
+		app.Fprintln(logOutput, "--------------------------")
+		printStaleFiles(logOutput, c.Args.Prune, staleFiles, staleDirs)
+	}
+	app.Fprintln(logOutput, "--------------------------")
+	app.Fprintf(logOutput, "阶段1, 耗时: %s\n", time.Since(c.Args.StartTime).String())
+	app.Fprintln(logOutput, "----------------------------------------------")
+	if listOnly {
+		return nil
+	}
+	if prune {
+		if err := pruneFiles(logOutput, c.Args.Prune, staleFiles, staleDirs, c.Args.SaveDir); err != nil {
+			return oops.Wrap(err)
+		}
+		app.Fprintln(logOutput, "----------------------------------------------")
+	}
+
+	// 导出任务使用同一棵文档树，跳过已排除的文档，最终状态直接写回文档树
+	if c.Args.hasFilters() && canDownloadCount == 0 {
+		app.Fprintln(logOutput, "按过滤条件排除后没有需要导出的文档")
+		return nil
+	}
+	// 部分文档导出失败时仍然改写已下载文档的链接，再返回错误
+	err = c.runTask(ctx, dns)
+	if err != nil && !isPartialFailure(err) {
 		return oops.Wrap(err)
 	}
-	err = app.Fs.WriteFile(filePath, diBytes, 0o644)
+	// 下载完成后，将文档之间的链接改写为本地相对路径
+	if er := rewriteLinks(logOutput, infoList, c.Args.RewriteDocxLinks); er != nil {
+		return oops.Wrap(er)
+	}
+	return oops.Wrap(err)
+}
+
+// runTask 按进度展示方式创建任务，批量导出下载文档树中的文档。
+func (c *ClientImpl) runTask(ctx context.Context, dns []*DocumentNode) error {
+	if c.ProgramConstructor != nil {
+		c.Args.QuitAutomatically = true
+		task := c.CreateTask(dns, c.ProgramConstructor)
+		return oops.Wrap(doExportAndDownload(ctx, task))
+	}
+	// 非终端的进度输出无法手动退出，全部下载完成后自动退出
+	mode := progress.ResolveMode(c.Args.Progress)
+	if mode != progress.ModeTUI {
+		c.Args.QuitAutomatically = true
+	}
+	out, closeOut, err := progress.OpenOutput(c.Args.ProgressFile)
 	if err != nil {
-		return oops.Wrapf(err, "写入文件失败")
+		return oops.Wrap(err)
 	}
+	defer closeOut()
+	task := c.CreateTask(dns, progress.NewProgramConstructor(mode, out))
+	return oops.Wrap(doExportAndDownload(ctx, task))
+}
 
-	fmt.Println("预计将目录或文件保存如下:")
-	tree := treeprint.NewWithRoot(c.Args.SaveDir)
-	totalCount, canDownloadCount := printTree(os.Stdout, tree, dns, 0, 0)
-	fmt.Printf("\n查询总数量: %d, 可下载文档数量: %d\n", totalCount, canDownloadCount)
-	fmt.Println("--------------------------")
-	fmt.Printf("阶段1, 耗时: %s\n", time.Since(c.Args.StartTime).String())
-	fmt.Println("----------------------------------------------")
-	if c.Args.ListOnly {
-		return nil
+// discoverDocuments 读取所有文档源的文档树，超过discover-timeout时返回超时错误。
+func (c *ClientImpl) discoverDocuments(ctx context.Context, docSources []*cloud.DocumentSource) ([]*DocumentNode, error) {
+	if c.Args.DiscoverTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Args.DiscoverTimeout)
+		defer cancel()
+	}
+	dns, err := c.queryAndSplitDocuments(ctx, docSources)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, oops.Errorf("读取云文档信息超时: %s", c.Args.DiscoverTimeout)
 	}
+	return dns, err
+}
 
-	task := c.CreateTask(dns, progress.NewProgram)
-	return doExportAndDownload(task)
+// queryAndSplitDocuments 查询所有文档源的文档树，去重后将表格按工作表拆分。
+func (c *ClientImpl) queryAndSplitDocuments(ctx context.Context, docSources []*cloud.DocumentSource) ([]*DocumentNode, error) {
+	var dns []*DocumentNode
+	for _, ds := range docSources {
+		dn, err := c.QueryDocuments(ctx, ds.Type, ds.Token)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		dns = append(dns, dn)
+	}
+	// 去重，可能dns中的树是互相包含的关系
+	dns = deduplication(dns)
+	// 导出为csv的表格按工作表拆分导出
+	if err := c.splitSheets(ctx, dns); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	return dns, nil
 }
 
-func (c *ClientImpl) QueryDocuments(typ, token string) (dn *DocumentNode, err error) {
+func (c *ClientImpl) QueryDocuments(ctx context.Context, typ, token string) (dn *DocumentNode, err error) {
+	logOutput := c.Args.LogOutput(os.Stdout)
 	switch typ {
 	case "/wiki":
-		fmt.Printf("飞书云文档源: 知识库, 类型: %s, token: %s\n", typ, token)
-		dn, err = c.QueryWikiDocuments(token)
+		app.Fprintf(logOutput, "飞书云文档源: 知识库, 类型: %s, token: %s\n", typ, token)
+		dn, err = c.QueryWikiDocuments(ctx, token)
 	case "/wiki/settings":
-		fmt.Printf("飞书云文档源: 知识库, 类型: %s, token: %s\n", typ, token)
-		dn, err = c.QueryWikiSpaceDocuments(token)
+		app.Fprintf(logOutput, "飞书云文档源: 知识库, 类型: %s, token: %s\n", typ, token)
+		dn, err = c.QueryWikiSpaceDocuments(ctx, token)
 	case "/drive/folder", "/docs", "/docx", "/sheets", "/file":
-		fmt.Printf("飞书云文档源: 云空间, 类型: %s, token: %s\n", typ, token)
+		app.Fprintf(logOutput, "飞书云文档源: 云空间, 类型: %s, token: %s\n", typ, token)
 		var docType constant.DocType
 		switch typ {
 		case "/docs":
@@ -134,7 +261,7 @@ func (c *ClientImpl) QueryDocuments(typ, token string) (dn *DocumentNode, err er
 			// "/drive/folder"
 			docType = constant.DocTypeFolder
 		}
-		dn, err = c.QueryDriveDocuments(docType, token)
+		dn, err = c.QueryDriveDocuments(ctx, docType, token)
 	default:
 		err = oops.Code("InvalidArgument").Errorf("不支持的飞书云文档类型: %s\n", typ)
 	}
@@ -150,6 +277,11 @@ func (c *ClientImpl) CreateTask(docs []*DocumentNode, programConstructor func(pr
 
 func checkResp[R error](resp R, err error) (R, error) {
 	if err != nil {
+		// 飞书SDK只有在获取访问凭证失败时才直接返回CodeError
+		var codeError larkcore.CodeError
+		if errors.As(err, &codeError) {
+			return resp, oops.Code("Unauthenticated").Wrap(err)
+		}
 		return resp, err
 	}
 	r, ok := any(resp).(interface{ Success() bool })
@@ -161,7 +293,8 @@ func checkResp[R error](resp R, err error) (R, error) {
 		if codeError == nil {
 			return resp, nil
 		}
-		return resp, oops.Errorf("logId: %s, error response: \n%s", getLogID(resp), larkcore.Prettify(codeError))
+		return resp, oops.Code(lo.Ternary(authErrorCodes[codeError.Code], "Unauthenticated", "")).
+			Errorf("logId: %s, error response: \n%s", getLogID(resp), larkcore.Prettify(codeError))
 	}
 	return resp, nil
 }
@@ -180,47 +313,122 @@ func getLogID(err error) string {
 
 // DriveBatchQuery 批量查询文件元信息。
 func (c *ClientImpl) DriveBatchQuery(ctx context.Context, req *larkdrive.BatchQueryMetaReq, options ...larkcore.RequestOptionFunc) (*larkdrive.BatchQueryMetaResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDrive); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.Meta.BatchQuery(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 // DriveList 获取文件夹中的文件清单。
 func (c *ClientImpl) DriveList(ctx context.Context, req *larkdrive.ListFileReq, options ...larkcore.RequestOptionFunc) (*larkdrive.ListFileResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDrive); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.File.List(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) DriveDownload(ctx context.Context, req *larkdrive.DownloadFileReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadFileResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDownload); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.File.Download(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
+func (c *ClientImpl) DriveDownloadMedia(ctx context.Context, req *larkdrive.DownloadMediaReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadMediaResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDownload); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Drive.V1.Media.Download(ctx, req, options...)
+	return checkResp(resp, err)
+}
+
 func (c *ClientImpl) WikiGetNode(ctx context.Context, req *larkwiki.GetNodeSpaceReq, options ...larkcore.RequestOptionFunc) (*larkwiki.GetNodeSpaceResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitWiki); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Wiki.V2.Space.GetNode(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) WikiGetSpace(ctx context.Context, req *larkwiki.GetSpaceReq, options ...larkcore.RequestOptionFunc) (*larkwiki.GetSpaceResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitWiki); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Wiki.V2.Space.Get(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) WikiNodeList(ctx context.Context, req *larkwiki.ListSpaceNodeReq, options ...larkcore.RequestOptionFunc) (*larkwiki.ListSpaceNodeResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitWiki); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Wiki.V2.SpaceNode.List(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) ExportCreate(ctx context.Context, req *larkdrive.CreateExportTaskReq, options ...larkcore.RequestOptionFunc) (*larkdrive.CreateExportTaskResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitExport); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.ExportTask.Create(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) ExportGet(ctx context.Context, req *larkdrive.GetExportTaskReq, options ...larkcore.RequestOptionFunc) (*larkdrive.GetExportTaskResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitExport); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.ExportTask.Get(ctx, req, options...)
 	return checkResp(resp, err)
 }
 
 func (c *ClientImpl) ExportDownload(ctx context.Context, req *larkdrive.DownloadExportTaskReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadExportTaskResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDownload); err != nil {
+		return nil, oops.Wrap(err)
+	}
 	resp, err := c.Drive.V1.ExportTask.Download(ctx, req, options...)
 	return checkResp(resp, err)
 }
+
+func (c *ClientImpl) DocxBlockList(ctx context.Context, req *larkdocx.ListDocumentBlockReq, options ...larkcore.RequestOptionFunc) (*larkdocx.ListDocumentBlockResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitDocx); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Docx.V1.DocumentBlock.List(ctx, req, options...)
+	return checkResp(resp, err)
+}
+
+func (c *ClientImpl) SheetsQuery(ctx context.Context, req *larksheets.QuerySpreadsheetSheetReq, options ...larkcore.RequestOptionFunc) (*larksheets.QuerySpreadsheetSheetResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitSheets); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Sheets.V3.SpreadsheetSheet.Query(ctx, req, options...)
+	return checkResp(resp, err)
+}
+
+func (c *ClientImpl) BitableTableList(ctx context.Context, req *larkbitable.ListAppTableReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitBitable); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Bitable.V1.AppTable.List(ctx, req, options...)
+	return checkResp(resp, err)
+}
+
+func (c *ClientImpl) BitableFieldList(ctx context.Context, req *larkbitable.ListAppTableFieldReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableFieldResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitBitable); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Bitable.V1.AppTableField.List(ctx, req, options...)
+	return checkResp(resp, err)
+}
+
+func (c *ClientImpl) BitableRecordList(ctx context.Context, req *larkbitable.ListAppTableRecordReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableRecordResp, error) {
+	if err := c.limiter.wait(ctx, RateLimitBitable); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	resp, err := c.Bitable.V1.AppTableRecord.List(ctx, req, options...)
+	return checkResp(resp, err)
+}
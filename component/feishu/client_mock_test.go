@@ -7,10 +7,16 @@ import (
 
 	cloud "github.com/acyumi/xdoc/component/cloud"
 
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
 
+	larksheets "github.com/larksuite/oapi-sdk-go/v3/service/sheets/v3"
+
 	larkwiki "github.com/larksuite/oapi-sdk-go/v3/service/wiki/v2"
 
 	mock "github.com/stretchr/testify/mock"
@@ -31,6 +37,228 @@ func (_m *MockClient) EXPECT() *MockClient_Expecter {
 	return &MockClient_Expecter{mock: &_m.Mock}
 }
 
+// BitableFieldList provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) BitableFieldList(ctx context.Context, req *larkbitable.ListAppTableFieldReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableFieldResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BitableFieldList")
+	}
+
+	var r0 *larkbitable.ListAppTableFieldResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableFieldReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableFieldResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableFieldReq, ...larkcore.RequestOptionFunc) *larkbitable.ListAppTableFieldResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larkbitable.ListAppTableFieldResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larkbitable.ListAppTableFieldReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_BitableFieldList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BitableFieldList'
+type MockClient_BitableFieldList_Call struct {
+	*mock.Call
+}
+
+// BitableFieldList is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larkbitable.ListAppTableFieldReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) BitableFieldList(ctx any, req any, options ...any) *MockClient_BitableFieldList_Call {
+	return &MockClient_BitableFieldList_Call{Call: _e.mock.On("BitableFieldList",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_BitableFieldList_Call) Run(run func(ctx context.Context, req *larkbitable.ListAppTableFieldReq, options ...larkcore.RequestOptionFunc)) *MockClient_BitableFieldList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larkbitable.ListAppTableFieldReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_BitableFieldList_Call) Return(_a0 *larkbitable.ListAppTableFieldResp, _a1 error) *MockClient_BitableFieldList_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_BitableFieldList_Call) RunAndReturn(run func(context.Context, *larkbitable.ListAppTableFieldReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableFieldResp, error)) *MockClient_BitableFieldList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BitableRecordList provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) BitableRecordList(ctx context.Context, req *larkbitable.ListAppTableRecordReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableRecordResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BitableRecordList")
+	}
+
+	var r0 *larkbitable.ListAppTableRecordResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableRecordReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableRecordResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableRecordReq, ...larkcore.RequestOptionFunc) *larkbitable.ListAppTableRecordResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larkbitable.ListAppTableRecordResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larkbitable.ListAppTableRecordReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_BitableRecordList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BitableRecordList'
+type MockClient_BitableRecordList_Call struct {
+	*mock.Call
+}
+
+// BitableRecordList is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larkbitable.ListAppTableRecordReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) BitableRecordList(ctx any, req any, options ...any) *MockClient_BitableRecordList_Call {
+	return &MockClient_BitableRecordList_Call{Call: _e.mock.On("BitableRecordList",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_BitableRecordList_Call) Run(run func(ctx context.Context, req *larkbitable.ListAppTableRecordReq, options ...larkcore.RequestOptionFunc)) *MockClient_BitableRecordList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larkbitable.ListAppTableRecordReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_BitableRecordList_Call) Return(_a0 *larkbitable.ListAppTableRecordResp, _a1 error) *MockClient_BitableRecordList_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_BitableRecordList_Call) RunAndReturn(run func(context.Context, *larkbitable.ListAppTableRecordReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableRecordResp, error)) *MockClient_BitableRecordList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BitableTableList provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) BitableTableList(ctx context.Context, req *larkbitable.ListAppTableReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BitableTableList")
+	}
+
+	var r0 *larkbitable.ListAppTableResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larkbitable.ListAppTableReq, ...larkcore.RequestOptionFunc) *larkbitable.ListAppTableResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larkbitable.ListAppTableResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larkbitable.ListAppTableReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_BitableTableList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BitableTableList'
+type MockClient_BitableTableList_Call struct {
+	*mock.Call
+}
+
+// BitableTableList is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larkbitable.ListAppTableReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) BitableTableList(ctx any, req any, options ...any) *MockClient_BitableTableList_Call {
+	return &MockClient_BitableTableList_Call{Call: _e.mock.On("BitableTableList",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_BitableTableList_Call) Run(run func(ctx context.Context, req *larkbitable.ListAppTableReq, options ...larkcore.RequestOptionFunc)) *MockClient_BitableTableList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larkbitable.ListAppTableReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_BitableTableList_Call) Return(_a0 *larkbitable.ListAppTableResp, _a1 error) *MockClient_BitableTableList_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_BitableTableList_Call) RunAndReturn(run func(context.Context, *larkbitable.ListAppTableReq, ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableResp, error)) *MockClient_BitableTableList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateTask provides a mock function with given fields: docs, programConstructor
 func (_m *MockClient) CreateTask(docs []*DocumentNode, programConstructor func(progress.Stats) progress.IProgram) cloud.Task {
 	ret := _m.Called(docs, programConstructor)
@@ -80,17 +308,91 @@ func (_c *MockClient_CreateTask_Call) RunAndReturn(run func([]*DocumentNode, fun
 	return _c
 }
 
-// DownloadDocuments provides a mock function with given fields: _a0
-func (_m *MockClient) DownloadDocuments(_a0 []*cloud.DocumentSource) error {
-	ret := _m.Called(_a0)
+// DocxBlockList provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) DocxBlockList(ctx context.Context, req *larkdocx.ListDocumentBlockReq, options ...larkcore.RequestOptionFunc) (*larkdocx.ListDocumentBlockResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DocxBlockList")
+	}
+
+	var r0 *larkdocx.ListDocumentBlockResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larkdocx.ListDocumentBlockReq, ...larkcore.RequestOptionFunc) (*larkdocx.ListDocumentBlockResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larkdocx.ListDocumentBlockReq, ...larkcore.RequestOptionFunc) *larkdocx.ListDocumentBlockResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larkdocx.ListDocumentBlockResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larkdocx.ListDocumentBlockReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DocxBlockList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DocxBlockList'
+type MockClient_DocxBlockList_Call struct {
+	*mock.Call
+}
+
+// DocxBlockList is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larkdocx.ListDocumentBlockReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) DocxBlockList(ctx any, req any, options ...any) *MockClient_DocxBlockList_Call {
+	return &MockClient_DocxBlockList_Call{Call: _e.mock.On("DocxBlockList",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_DocxBlockList_Call) Run(run func(ctx context.Context, req *larkdocx.ListDocumentBlockReq, options ...larkcore.RequestOptionFunc)) *MockClient_DocxBlockList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larkdocx.ListDocumentBlockReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_DocxBlockList_Call) Return(_a0 *larkdocx.ListDocumentBlockResp, _a1 error) *MockClient_DocxBlockList_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DocxBlockList_Call) RunAndReturn(run func(context.Context, *larkdocx.ListDocumentBlockReq, ...larkcore.RequestOptionFunc) (*larkdocx.ListDocumentBlockResp, error)) *MockClient_DocxBlockList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DownloadDocuments provides a mock function with given fields: ctx, dss
+func (_m *MockClient) DownloadDocuments(ctx context.Context, dss []*cloud.DocumentSource) error {
+	ret := _m.Called(ctx, dss)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DownloadDocuments")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]*cloud.DocumentSource) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, []*cloud.DocumentSource) error); ok {
+		r0 = rf(ctx, dss)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -104,14 +406,15 @@ type MockClient_DownloadDocuments_Call struct {
 }
 
 // DownloadDocuments is a helper method to define mock.On call
-//   - _a0 []*cloud.DocumentSource
-func (_e *MockClient_Expecter) DownloadDocuments(_a0 any) *MockClient_DownloadDocuments_Call {
-	return &MockClient_DownloadDocuments_Call{Call: _e.mock.On("DownloadDocuments", _a0)}
+//   - ctx context.Context
+//   - dss []*cloud.DocumentSource
+func (_e *MockClient_Expecter) DownloadDocuments(ctx any, dss any) *MockClient_DownloadDocuments_Call {
+	return &MockClient_DownloadDocuments_Call{Call: _e.mock.On("DownloadDocuments", ctx, dss)}
 }
 
-func (_c *MockClient_DownloadDocuments_Call) Run(run func(_a0 []*cloud.DocumentSource)) *MockClient_DownloadDocuments_Call {
+func (_c *MockClient_DownloadDocuments_Call) Run(run func(ctx context.Context, dss []*cloud.DocumentSource)) *MockClient_DownloadDocuments_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]*cloud.DocumentSource))
+		run(args[0].(context.Context), args[1].([]*cloud.DocumentSource))
 	})
 	return _c
 }
@@ -121,7 +424,7 @@ func (_c *MockClient_DownloadDocuments_Call) Return(_a0 error) *MockClient_Downl
 	return _c
 }
 
-func (_c *MockClient_DownloadDocuments_Call) RunAndReturn(run func([]*cloud.DocumentSource) error) *MockClient_DownloadDocuments_Call {
+func (_c *MockClient_DownloadDocuments_Call) RunAndReturn(run func(context.Context, []*cloud.DocumentSource) error) *MockClient_DownloadDocuments_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -274,6 +577,80 @@ func (_c *MockClient_DriveDownload_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// DriveDownloadMedia provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) DriveDownloadMedia(ctx context.Context, req *larkdrive.DownloadMediaReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadMediaResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DriveDownloadMedia")
+	}
+
+	var r0 *larkdrive.DownloadMediaResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larkdrive.DownloadMediaReq, ...larkcore.RequestOptionFunc) (*larkdrive.DownloadMediaResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larkdrive.DownloadMediaReq, ...larkcore.RequestOptionFunc) *larkdrive.DownloadMediaResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larkdrive.DownloadMediaResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larkdrive.DownloadMediaReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DriveDownloadMedia_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DriveDownloadMedia'
+type MockClient_DriveDownloadMedia_Call struct {
+	*mock.Call
+}
+
+// DriveDownloadMedia is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larkdrive.DownloadMediaReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) DriveDownloadMedia(ctx any, req any, options ...any) *MockClient_DriveDownloadMedia_Call {
+	return &MockClient_DriveDownloadMedia_Call{Call: _e.mock.On("DriveDownloadMedia",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_DriveDownloadMedia_Call) Run(run func(ctx context.Context, req *larkdrive.DownloadMediaReq, options ...larkcore.RequestOptionFunc)) *MockClient_DriveDownloadMedia_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larkdrive.DownloadMediaReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_DriveDownloadMedia_Call) Return(_a0 *larkdrive.DownloadMediaResp, _a1 error) *MockClient_DriveDownloadMedia_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DriveDownloadMedia_Call) RunAndReturn(run func(context.Context, *larkdrive.DownloadMediaReq, ...larkcore.RequestOptionFunc) (*larkdrive.DownloadMediaResp, error)) *MockClient_DriveDownloadMedia_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DriveList provides a mock function with given fields: ctx, req, options
 func (_m *MockClient) DriveList(ctx context.Context, req *larkdrive.ListFileReq, options ...larkcore.RequestOptionFunc) (*larkdrive.ListFileResp, error) {
 	_va := make([]any, len(options))
@@ -650,6 +1027,80 @@ func (_c *MockClient_SetArgs_Call) RunAndReturn(run func(*Args)) *MockClient_Set
 	return _c
 }
 
+// SheetsQuery provides a mock function with given fields: ctx, req, options
+func (_m *MockClient) SheetsQuery(ctx context.Context, req *larksheets.QuerySpreadsheetSheetReq, options ...larkcore.RequestOptionFunc) (*larksheets.QuerySpreadsheetSheetResp, error) {
+	_va := make([]any, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []any
+	_ca = append(_ca, ctx, req)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SheetsQuery")
+	}
+
+	var r0 *larksheets.QuerySpreadsheetSheetResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *larksheets.QuerySpreadsheetSheetReq, ...larkcore.RequestOptionFunc) (*larksheets.QuerySpreadsheetSheetResp, error)); ok {
+		return rf(ctx, req, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *larksheets.QuerySpreadsheetSheetReq, ...larkcore.RequestOptionFunc) *larksheets.QuerySpreadsheetSheetResp); ok {
+		r0 = rf(ctx, req, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*larksheets.QuerySpreadsheetSheetResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *larksheets.QuerySpreadsheetSheetReq, ...larkcore.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, req, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_SheetsQuery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SheetsQuery'
+type MockClient_SheetsQuery_Call struct {
+	*mock.Call
+}
+
+// SheetsQuery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *larksheets.QuerySpreadsheetSheetReq
+//   - options ...larkcore.RequestOptionFunc
+func (_e *MockClient_Expecter) SheetsQuery(ctx any, req any, options ...any) *MockClient_SheetsQuery_Call {
+	return &MockClient_SheetsQuery_Call{Call: _e.mock.On("SheetsQuery",
+		append([]any{ctx, req}, options...)...)}
+}
+
+func (_c *MockClient_SheetsQuery_Call) Run(run func(ctx context.Context, req *larksheets.QuerySpreadsheetSheetReq, options ...larkcore.RequestOptionFunc)) *MockClient_SheetsQuery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]larkcore.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(larkcore.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*larksheets.QuerySpreadsheetSheetReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_SheetsQuery_Call) Return(_a0 *larksheets.QuerySpreadsheetSheetResp, _a1 error) *MockClient_SheetsQuery_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_SheetsQuery_Call) RunAndReturn(run func(context.Context, *larksheets.QuerySpreadsheetSheetReq, ...larkcore.RequestOptionFunc) (*larksheets.QuerySpreadsheetSheetResp, error)) *MockClient_SheetsQuery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Validate provides a mock function with no fields
 func (_m *MockClient) Validate() error {
 	ret := _m.Called()
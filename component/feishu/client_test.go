@@ -16,26 +16,34 @@ package feishu
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/h2non/gock"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+	larksheets "github.com/larksuite/oapi-sdk-go/v3/service/sheets/v3"
 	larkwiki "github.com/larksuite/oapi-sdk-go/v3/service/wiki/v2"
 	"github.com/samber/oops"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/acyumi/xdoc/component/app"
 	"github.com/acyumi/xdoc/component/argument"
 	"github.com/acyumi/xdoc/component/cloud"
+	"github.com/acyumi/xdoc/component/constant"
 	"github.com/acyumi/xdoc/component/progress"
 )
 
@@ -92,10 +100,13 @@ func (s *ClientImplTestSuite) TestClientImpl_Validate() {
 			client: func() *ClientImpl {
 				var c ClientImpl
 				c.SetArgs(&Args{
-					AppID:     "cli_xxx",
-					AppSecret: "xxx",
-					DocURLs:   []string{"x"},
-					SaveDir:   "/tmp",
+					AppID:           "cli_xxx",
+					AppSecret:       "xxx",
+					DocURLs:         []string{"x"},
+					SaveDir:         "/tmp",
+					ExportWorkers:   DefaultExportWorkers,
+					DownloadWorkers: DefaultDownloadWorkers,
+					QueueSize:       DefaultQueueSize,
 				})
 				return &c
 			}(),
@@ -163,13 +174,64 @@ func (s *ClientImplTestSuite) TestClientImpl_DownloadDocuments() {
 			setupMock: func(mt *MockTask, name string) {
 				s.mockWikiSettingsServer("6946843325487912366")
 				s.args.ListOnly = false
+				s.args.Progress = progress.ModePlain
 				s.mockTask.EXPECT().Validate().Return(nil).Once()
-				s.mockTask.EXPECT().Run().Return(nil).Once()
+				s.mockTask.EXPECT().Run(mock.Anything).Return(nil).Once()
 				s.mockTask.EXPECT().Close().Return().Once()
 			},
 			teardownMock: func(mt *MockTask, name string) {
+				// 纯文本进度输出时自动退出
+				s.True(s.args.QuitAutomatically, name)
 				s.args.SaveDir = ""
 				s.args.ListOnly = false
+				s.args.Progress = ""
+				s.args.QuitAutomatically = false
+				defer gock.Off()
+				s.True(gock.IsDone(), name)
+			},
+			wantError: "",
+		},
+		{
+			name:  "清理云文档已删除的本地文件",
+			typ:   "/wiki/settings",
+			token: "6946843325487912366",
+			setupMock: func(mt *MockTask, name string) {
+				s.mockWikiSettingsServer("6946843325487912366")
+				s.args.Prune = PruneDelete
+				newDoc := func(name, filePath string) *DocumentNode {
+					return &DocumentNode{DocumentInfo: DocumentInfo{Name: name, Type: constant.DocTypeDocx,
+						FileExtension: constant.FileExtDocx, FilePath: filePath}}
+				}
+				previous := []*DocumentNode{
+					// 本次文档源的旧文档
+					{
+						DocumentInfo: DocumentInfo{Type: constant.DocTypeFolder, Token: "6946843325487912366", FilePath: "/tmp/old"},
+						Children:     []*DocumentNode{newDoc("旧文档", "/tmp/old/旧文档.docx")},
+					},
+					// 不在本次文档源中的文档不清理
+					{
+						DocumentInfo: DocumentInfo{Type: constant.DocTypeFolder, Token: "other", FilePath: "/tmp/other"},
+						Children:     []*DocumentNode{newDoc("其他", "/tmp/other/其他.docx")},
+					},
+				}
+				s.Require().NoError(saveDocumentTree(previous, "/tmp"), name)
+				s.Require().NoError(app.Fs.MkdirAll("/tmp/old", 0o755), name)
+				s.Require().NoError(app.Fs.WriteFile("/tmp/old/旧文档.docx", []byte("old"), 0o644), name)
+				s.Require().NoError(app.Fs.MkdirAll("/tmp/other", 0o755), name)
+				s.Require().NoError(app.Fs.WriteFile("/tmp/other/其他.docx", []byte("other"), 0o644), name)
+				s.mockTask.EXPECT().Validate().Return(nil).Once()
+				s.mockTask.EXPECT().Run(mock.Anything).Return(nil).Once()
+				s.mockTask.EXPECT().Close().Return().Once()
+			},
+			teardownMock: func(mt *MockTask, name string) {
+				s.args.SaveDir = ""
+				s.args.Prune = ""
+				yes, err := app.Fs.Exists("/tmp/old")
+				s.Require().NoError(err, name)
+				s.False(yes, name)
+				yes, err = app.Fs.Exists("/tmp/other/其他.docx")
+				s.Require().NoError(err, name)
+				s.True(yes, name)
 				defer gock.Off()
 				s.True(gock.IsDone(), name)
 			},
@@ -395,6 +457,18 @@ func (s *ClientImplTestSuite) TestClientImpl_DownloadDocuments() {
 			},
 			wantError: "logId: \x1b]8;;https://open.feishu.cn/search?q=xyz\x1b\\, error response: \n{\n  Code: 500,\n  Msg: \"something wrong\"\n}",
 		},
+		{
+			name:  "读取文档树超时",
+			typ:   "/wiki/settings",
+			token: "6946843325487912366",
+			setupMock: func(mt *MockTask, name string) {
+				s.args.DiscoverTimeout = time.Nanosecond
+			},
+			teardownMock: func(mt *MockTask, name string) {
+				s.args.DiscoverTimeout = 0
+			},
+			wantError: "读取云文档信息超时: 1ns",
+		},
 		{
 			name:  "不支持的类型",
 			typ:   "/xxx",
@@ -414,7 +488,8 @@ func (s *ClientImplTestSuite) TestClientImpl_DownloadDocuments() {
 				useFs(s.memFs)
 			}()
 			tt.setupMock(s.mockTask, tt.name)
-			err := s.client.DownloadDocuments([]*cloud.DocumentSource{{Type: tt.typ, Token: tt.token}})
+			err := s.client.DownloadDocuments(context.Background(), []*cloud.DocumentSource{{Type: tt.typ, Token: tt.token}})
+			tt.teardownMock(s.mockTask, tt.name)
 			if err != nil || tt.wantError != "" {
 				s.Require().Error(err, tt.name)
 				s.IsType(oops.OopsError{}, err, tt.name)
@@ -503,7 +578,287 @@ func (s *ClientImplTestSuite) TestClientImpl_CreateTask() {
 	task = s.client.CreateTask(nil, nil)
 	err = task.Validate()
 	s.Require().Error(err)
-	s.Require().EqualError(err, "Client: Args: DocURLs: urls是必需参数; SaveDir: dir是必需参数..; Docs: cannot be blank; ProgramConstructor: cannot be blank.")
+	s.Require().EqualError(err, "Client: Args: DocURLs: urls是必需参数; DownloadWorkers: download-workers必须大于0; ExportWorkers: export-workers必须大于0; QueueSize: queue-size必须大于0; SaveDir: dir是必需参数..; Docs: cannot be blank; ProgramConstructor: cannot be blank.")
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_runTask() {
+	// 指定了ProgramConstructor时使用它展示进度，并且下载完成后自动退出
+	var gotProgram progress.IProgram
+	s.client.ProgramConstructor = func(progress.Stats) progress.IProgram {
+		gotProgram = progress.NewMemoryProgram()
+		return gotProgram
+	}
+	s.client.TaskCreator = nil
+	s.args.Progress = progress.ModeTUI
+	err := s.client.runTask(context.Background(), nil)
+	s.Require().EqualError(err, "Client: Args: DocURLs: urls是必需参数; DownloadWorkers: download-workers必须大于0; ExportWorkers: export-workers必须大于0; QueueSize: queue-size必须大于0; SaveDir: dir是必需参数..; Docs: cannot be blank.")
+	s.True(s.args.QuitAutomatically)
+	s.Nil(gotProgram, "校验失败时不创建进度程序")
+}
+
+// runDownloadTask 通过 CreateTask 创建真实的任务并执行，文档都直接下载，使用内存进度程序，下载请求由gock模拟。
+func (s *ClientImplTestSuite) runDownloadTask(dns []*DocumentNode) {
+	checkAuthenticated()
+	cleanSleep()
+	for _, di := range flattenDocumentNodes(dns) {
+		if !di.shouldDownload() {
+			continue
+		}
+		gock.New("https://open.feishu.cn").
+			Get(fmt.Sprintf("/open-apis/drive/v1/files/%s/download", di.Token)).
+			Reply(200).
+			BodyString("content of " + di.Token)
+	}
+	s.args.ExportWorkers = DefaultExportWorkers
+	s.args.DownloadWorkers = DefaultDownloadWorkers
+	s.args.QueueSize = DefaultQueueSize
+	s.args.QuitAutomatically = true
+	defer func() {
+		s.args.ExportWorkers = 0
+		s.args.DownloadWorkers = 0
+		s.args.QueueSize = 0
+		s.args.QuitAutomatically = false
+	}()
+	s.client.TaskCreator = nil
+	program := progress.NewMemoryProgram()
+	task := s.client.CreateTask(dns, func(progress.Stats) progress.IProgram { return program })
+	s.Require().NoError(task.Run(context.Background()))
+	s.True(gock.IsDone())
+	s.Equal(len(program.Files()), program.Summary().Completed)
+}
+
+// newDownloadTree 构造直接下载的文档树：space/doc.pdf。
+func newDownloadTree(docName string) []*DocumentNode {
+	return []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "space", Type: constant.DocTypeFolder, Token: "space_tok"},
+			Children: []*DocumentNode{
+				{DocumentInfo: DocumentInfo{Name: docName, Type: constant.DocTypePDF, Token: "doc_tok",
+					FileExtension: constant.FileExtPDF, CanDownload: true, DownloadDirectly: true}},
+			},
+		},
+	}
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_CreateTask_pathTemplate() {
+	s.args.SaveDir = "/tmp/template"
+	s.args.PathTemplate = "{type}/{title}_{token}.{ext}"
+	defer func() {
+		s.args.SaveDir = ""
+		s.args.PathTemplate = ""
+	}()
+	dns := newDownloadTree("doc")
+	_ = documentNodesToInfoList(dns, s.args.SaveDir)
+	applyPathTemplate(dns, s.args.SaveDir, s.args.PathTemplate)
+	s.runDownloadTask(dns)
+
+	// 文件按路径模板保存，而不是按文档层级保存
+	data, err := app.Fs.ReadFile("/tmp/template/pdf/doc_doc_tok.pdf")
+	s.Require().NoError(err)
+	s.Equal("content of doc_tok", string(data))
+	yes, err := app.Fs.Exists("/tmp/template/space/doc.pdf")
+	s.Require().NoError(err)
+	s.False(yes)
+	// 保存的文档树中也是按路径模板计算的路径，下次增量导出和清理本地文件时才能对应上
+	saved, err := loadDocumentTree(s.args.SaveDir)
+	s.Require().NoError(err)
+	doc := saved[0].Children[0]
+	s.Equal("/tmp/template/pdf/doc_doc_tok.pdf", doc.FilePath)
+	s.Equal(progress.StatusCompleted, doc.Status)
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_CreateTask_excluded() {
+	s.args.SaveDir = "/tmp/excluded"
+	s.args.Exclude = []string{"space/excluded.pdf"}
+	defer func() {
+		s.args.SaveDir = ""
+		s.args.Exclude = nil
+	}()
+	dns := newDownloadTree("doc")
+	dns[0].Children = append(dns[0].Children, &DocumentNode{DocumentInfo: DocumentInfo{Name: "excluded", Type: constant.DocTypePDF,
+		Token: "excluded_tok", FileExtension: constant.FileExtPDF, CanDownload: true, DownloadDirectly: true}})
+	_ = documentNodesToInfoList(dns, s.args.SaveDir)
+	s.Require().Equal(1, filterDocuments(dns, s.args))
+	s.runDownloadTask(dns)
+
+	// 最终状态直接写回传入的文档树，已排除的文档不导出
+	s.Equal(progress.StatusCompleted, dns[0].Children[0].Status)
+	s.Equal(progress.Status(""), dns[0].Children[1].Status)
+	yes, err := app.Fs.Exists("/tmp/excluded/space/excluded.pdf")
+	s.Require().NoError(err)
+	s.False(yes)
+	// 保存的文档树仍然包括已排除的文档，避免下次清理本地文件时被当作已删除
+	saved, err := loadDocumentTree(s.args.SaveDir)
+	s.Require().NoError(err)
+	s.Require().Len(saved[0].Children, 2)
+	s.True(saved[0].Children[1].Excluded)
+	s.Equal(progress.StatusCompleted, saved[0].Children[0].Status)
+	// 已排除的文档不列入导出报告
+	data, err := app.Fs.ReadFile("/tmp/excluded/export-report.json")
+	s.Require().NoError(err)
+	s.NotContains(string(data), "excluded_tok")
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_CreateTask_retryFailed() {
+	s.args.SaveDir = "/tmp/retry"
+	s.args.RetryFailed = "/tmp/retry/export-report.json"
+	defer func() {
+		s.args.SaveDir = ""
+		s.args.RetryFailed = ""
+	}()
+	// 上一次导出：doc.pdf失败，other.pdf已下载
+	_, err := saveReport(&report{Documents: []*reportDocument{
+		{Name: "doc", FilePath: "/tmp/retry/space/doc.pdf", Token: "doc_tok", CanDownload: true, Status: "failed", Error: "失败了"},
+		{Name: "other", FilePath: "/tmp/retry/space/other.pdf", Token: "other_tok", CanDownload: true, Status: "completed"},
+	}}, s.args.SaveDir, nil)
+	s.Require().NoError(err)
+	j, err := openJournal(s.args.SaveDir, false)
+	s.Require().NoError(err)
+	j.write(journalEntry{FilePath: "/tmp/retry/space/doc.pdf", Token: "doc_tok", Status: progress.StatusFailed})
+	j.write(journalEntry{FilePath: "/tmp/retry/space/other.pdf", Token: "other_tok", Status: progress.StatusCompleted})
+	j.close()
+
+	// 只重试doc.pdf
+	dns := newDownloadTree("doc")
+	_ = documentNodesToInfoList(dns, s.args.SaveDir)
+	s.runDownloadTask(dns)
+
+	// 导出报告仍然列出未重试的文档
+	rp, err := readReport("/tmp/retry/export-report.json")
+	s.Require().NoError(err)
+	s.Require().Len(rp.Documents, 2)
+	s.Equal("/tmp/retry/space/doc.pdf", rp.Documents[0].FilePath)
+	s.Equal("completed", rp.Documents[0].Status)
+	s.Empty(rp.Documents[0].Error)
+	s.Equal("/tmp/retry/space/other.pdf", rp.Documents[1].FilePath)
+	s.Equal("completed", rp.Documents[1].Status)
+	s.Equal(2, rp.Summary.Total)
+	s.Equal(2, rp.Summary.Completed)
+	s.Equal(0, rp.Summary.Failed)
+	// 导出日志追加记录，不清空上一次的记录
+	states, err := loadJournal(s.args.SaveDir)
+	s.Require().NoError(err)
+	s.Require().Contains(states, "/tmp/retry/space/other.pdf")
+	s.Equal(progress.StatusCompleted, states["/tmp/retry/space/other.pdf"].Status)
+	s.Equal(progress.StatusCompleted, states["/tmp/retry/space/doc.pdf"].Status)
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_CreateTask_limitPaths() {
+	s.args.SaveDir = "/tmp/long"
+	s.args.MaxPathLength = 40
+	defer func() {
+		s.args.SaveDir = ""
+		s.args.MaxPathLength = 0
+	}()
+	dns := newDownloadTree(strings.Repeat("d", 40))
+	infoList := documentNodesToInfoList(dns, s.args.SaveDir)
+	s.Require().Equal(1, newPathSanitizer(s.args).limitPaths(infoList, s.args.SaveDir))
+	s.runDownloadTask(dns)
+
+	// 文件按截断后的路径保存，而不是超长的原路径
+	filePath := dns[0].Children[0].FilePath
+	s.LessOrEqual(len(filePath), 40)
+	s.Regexp(`^/tmp/long/space/d+~[0-9a-f]{8}\.pdf$`, filepath.ToSlash(filePath))
+	data, err := app.Fs.ReadFile(filePath)
+	s.Require().NoError(err)
+	s.Equal("content of doc_tok", string(data))
+	yes, err := app.Fs.Exists(filepath.Join("/tmp/long/space", strings.Repeat("d", 40)+".pdf"))
+	s.Require().NoError(err)
+	s.False(yes)
+}
+
+func (s *ClientImplTestSuite) TestClientImpl_DownloadDocuments_jsonProgress() {
+	checkAuthenticated()
+	cleanSleep()
+	gock.New("https://open.feishu.cn").
+		Post("/open-apis/drive/v1/metas/batch_query").
+		Reply(200).
+		JSON(`{
+  "code": 0,
+  "data": {
+    "metas": [
+      {
+        "create_time": "1740147877",
+        "doc_token": "file_tok",
+        "doc_type": "file",
+        "latest_modify_time": "1740301528",
+        "owner_id": "ou_xxx",
+        "title": "a.pdf"
+      }
+    ]
+  },
+  "msg": "Success"
+}`)
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/drive/v1/files/file_tok/download").
+		Reply(200).
+		BodyString("content of file_tok")
+	s.args.DocURLs = []string{"https://sample.feishu.cn/file/file_tok"}
+	s.args.SaveDir = "/tmp/json"
+	s.args.Progress = progress.ModeJSON
+	s.args.ExportWorkers = DefaultExportWorkers
+	s.args.DownloadWorkers = DefaultDownloadWorkers
+	s.args.QueueSize = DefaultQueueSize
+	s.client.TaskCreator = nil
+	defer func() {
+		s.args.DocURLs = nil
+		s.args.SaveDir = ""
+		s.args.Progress = ""
+		s.args.ExportWorkers = 0
+		s.args.DownloadWorkers = 0
+		s.args.QueueSize = 0
+		s.args.QuitAutomatically = false
+	}()
+
+	var err error
+	stdout, stderr := captureOutput(s.T(), func() {
+		err = s.client.DownloadDocuments(context.Background(), []*cloud.DocumentSource{{Type: "/file", Token: "file_tok"}})
+	})
+	s.Require().NoError(err)
+	s.True(gock.IsDone())
+
+	// 标准输出的每一行都是JSON事件，人可读的日志都输出到标准错误
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	s.NotEmpty(lines)
+	for _, line := range lines {
+		s.True(json.Valid([]byte(line)), line)
+	}
+	s.Contains(stdout, `"type":"summary"`)
+	s.Contains(stderr, "阶段1: 读取飞书云文档信息")
+	s.Contains(stderr, "阶段2: 下载飞书云文档")
+	data, err := app.Fs.ReadFile("/tmp/json/a.pdf")
+	s.Require().NoError(err)
+	s.Equal("content of file_tok", string(data))
+}
+
+// captureOutput 执行fn，返回期间写到标准输出和标准错误的内容。
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	read := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		origin := *target
+		*target = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			*target = origin
+			_ = w.Close()
+			return <-done
+		}
+	}
+	restoreStdout := read(&os.Stdout)
+	restoreStderr := read(&os.Stderr)
+	defer func() {
+		stdout = restoreStdout()
+		stderr = restoreStderr()
+	}()
+	fn()
+	return
 }
 
 type MockSuccess struct {
@@ -527,6 +882,7 @@ func (s *ClientImplTestSuite) Test_checkResp() {
 		err       error
 		wantResp  error
 		wantError string
+		wantCode  string
 	}{
 		{
 			name:      "err不为空",
@@ -566,6 +922,28 @@ func (s *ClientImplTestSuite) Test_checkResp() {
 			},
 			wantError: "logId: \x1b]8;;https://open.feishu.cn/search?q=xyz\x1b\\, error response: \n{\n  Code: 1061002,\n  Msg: \"发生错误\"\n}",
 		},
+		{
+			name:      "获取访问凭证失败",
+			resp:      nil,
+			err:       larkcore.CodeError{Code: 10014, Msg: "app secret invalid"},
+			wantResp:  nil,
+			wantError: "msg:app secret invalid,code:10014",
+			wantCode:  "Unauthenticated",
+		},
+		{
+			name: "访问凭证无效",
+			resp: &larkdrive.BatchQueryMetaResp{
+				ApiResp:   &larkcore.ApiResp{StatusCode: 400},
+				CodeError: larkcore.CodeError{Code: 99991663, Msg: "Invalid access token for authorization."},
+			},
+			err: nil,
+			wantResp: &larkdrive.BatchQueryMetaResp{
+				ApiResp:   &larkcore.ApiResp{StatusCode: 400},
+				CodeError: larkcore.CodeError{Code: 99991663, Msg: "Invalid access token for authorization."},
+			},
+			wantError: "logId: \x1b]8;;https://open.feishu.cn/search?q=\x1b\\, error response: \n{\n  Code: 99991663,\n  Msg: \"Invalid access token for authorization.\"\n}",
+			wantCode:  "Unauthenticated",
+		},
 		{
 			name:      "非飞书的响应",
 			resp:      viper.ConfigFileNotFoundError{},
@@ -590,6 +968,10 @@ func (s *ClientImplTestSuite) Test_checkResp() {
 				return
 			}
 			s.Require().EqualError(err, tt.wantError, tt.name)
+			var oopsError oops.OopsError
+			if errors.As(err, &oopsError) {
+				s.Equal(tt.wantCode, oopsError.Code(), tt.name)
+			}
 		})
 	}
 }
@@ -788,6 +1170,26 @@ func (s *ClientImplTestSuite) TestClientImpl_DriveDownload() {
 	s.True(gock.IsDone())
 }
 
+// TestClientImpl_DriveDownloadMedia 测试素材下载功能。
+func (s *ClientImplTestSuite) TestClientImpl_DriveDownloadMedia() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/drive/v1/medias/mediaToken123/download").
+		PathParam("medias", "mediaToken123").
+		Reply(200).
+		AddHeader("Content-Disposition", `attachment; filename="image.png"`).
+		BodyString(`ImageContentXxx`)
+
+	req := larkdrive.NewDownloadMediaReqBuilder().FileToken("mediaToken123").Build()
+	resp, err := s.client.DriveDownloadMedia(context.Background(), req)
+	s.Require().NoError(err)
+	s.Equal("image.png", resp.FileName)
+	all, err := io.ReadAll(resp.File)
+	s.Require().NoError(err)
+	s.Equal(`ImageContentXxx`, string(all))
+	s.True(gock.IsDone())
+}
+
 // TestClientImpl_WikiGetNode 测试获取知识库节点。
 func (s *ClientImplTestSuite) TestClientImpl_WikiGetNode() {
 	checkAuthenticated()
@@ -983,3 +1385,146 @@ func (s *ClientImplTestSuite) TestClientImpl_ExportDownload() {
 	s.Equal(`FileContentXyz`, string(all))
 	s.True(gock.IsDone())
 }
+
+// TestClientImpl_DocxBlockList 测试获取文档所有块。
+func (s *ClientImplTestSuite) TestClientImpl_DocxBlockList() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/docx/v1/documents/docxToken/blocks").
+		PathParam("documents", "docxToken").
+		MatchParam("page_size", "500").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": false,
+        "items": [
+            {
+                "block_id": "docxToken",
+                "block_type": 1,
+                "children": ["blockId"],
+                "page": {"elements": [{"text_run": {"content": "标题"}}]},
+                "parent_id": ""
+            }
+        ]
+    }
+}`)
+	req := larkdocx.NewListDocumentBlockReqBuilder().DocumentId("docxToken").PageSize(500).Build()
+	resp, err := s.client.DocxBlockList(context.Background(), req)
+	s.Require().NoError(err)
+	s.Require().Len(resp.Data.Items, 1)
+	s.Equal("docxToken", larkcore.StringValue(resp.Data.Items[0].BlockId))
+	s.Equal([]string{"blockId"}, resp.Data.Items[0].Children)
+	s.False(larkcore.BoolValue(resp.Data.HasMore))
+	s.True(gock.IsDone())
+}
+
+// TestClientImpl_SheetsQuery 测试获取电子表格的工作表。
+func (s *ClientImplTestSuite) TestClientImpl_SheetsQuery() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/sheets/v3/spreadsheets/shtToken/sheets/query").
+		PathParam("spreadsheets", "shtToken").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "sheets": [
+            {"sheet_id": "sxj5ws", "title": "Sheet1", "index": 0, "hidden": false, "resource_type": "sheet"}
+        ]
+    }
+}`)
+	req := larksheets.NewQuerySpreadsheetSheetReqBuilder().SpreadsheetToken("shtToken").Build()
+	resp, err := s.client.SheetsQuery(context.Background(), req)
+	s.Require().NoError(err)
+	s.Require().Len(resp.Data.Sheets, 1)
+	s.Equal("sxj5ws", larkcore.StringValue(resp.Data.Sheets[0].SheetId))
+	s.Equal("Sheet1", larkcore.StringValue(resp.Data.Sheets[0].Title))
+	s.True(gock.IsDone())
+}
+
+// TestClientImpl_BitableTableList 测试列出多维表格的数据表。
+func (s *ClientImplTestSuite) TestClientImpl_BitableTableList() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/appToken/tables").
+		PathParam("apps", "appToken").
+		MatchParam("page_size", "100").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": false,
+        "page_token": "tblKz5D60T4JlfcT",
+        "total": 1,
+        "items": [
+            {"table_id": "tblKz5D60T4JlfcT", "revision": 1, "name": "数据表1"}
+        ]
+    }
+}`)
+	req := larkbitable.NewListAppTableReqBuilder().AppToken("appToken").PageSize(100).Build()
+	resp, err := s.client.BitableTableList(context.Background(), req)
+	s.Require().NoError(err)
+	s.Require().Len(resp.Data.Items, 1)
+	s.Equal("tblKz5D60T4JlfcT", larkcore.StringValue(resp.Data.Items[0].TableId))
+	s.Equal("数据表1", larkcore.StringValue(resp.Data.Items[0].Name))
+	s.True(gock.IsDone())
+}
+
+// TestClientImpl_BitableFieldList 测试列出多维表格数据表的字段。
+func (s *ClientImplTestSuite) TestClientImpl_BitableFieldList() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/appToken/tables/tblToken/fields").
+		MatchParam("page_size", "100").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": false,
+        "total": 1,
+        "items": [
+            {"field_id": "fldPTb0U2y", "field_name": "多行文本", "type": 1, "ui_type": "Text", "is_primary": true}
+        ]
+    }
+}`)
+	req := larkbitable.NewListAppTableFieldReqBuilder().AppToken("appToken").TableId("tblToken").PageSize(100).Build()
+	resp, err := s.client.BitableFieldList(context.Background(), req)
+	s.Require().NoError(err)
+	s.Require().Len(resp.Data.Items, 1)
+	s.Equal("多行文本", larkcore.StringValue(resp.Data.Items[0].FieldName))
+	s.Equal(1, larkcore.IntValue(resp.Data.Items[0].Type))
+	s.True(larkcore.BoolValue(resp.Data.Items[0].IsPrimary))
+	s.True(gock.IsDone())
+}
+
+// TestClientImpl_BitableRecordList 测试列出多维表格数据表的记录。
+func (s *ClientImplTestSuite) TestClientImpl_BitableRecordList() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/appToken/tables/tblToken/records").
+		MatchParam("page_size", "500").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": false,
+        "total": 1,
+        "items": [
+            {"record_id": "recyOaMB2F", "fields": {"多行文本": "内容"}}
+        ]
+    }
+}`)
+	req := larkbitable.NewListAppTableRecordReqBuilder().AppToken("appToken").TableId("tblToken").PageSize(500).Build()
+	resp, err := s.client.BitableRecordList(context.Background(), req)
+	s.Require().NoError(err)
+	s.Require().Len(resp.Data.Items, 1)
+	s.Equal("recyOaMB2F", larkcore.StringValue(resp.Data.Items[0].RecordId))
+	s.Equal(map[string]any{"多行文本": "内容"}, resp.Data.Items[0].Fields)
+	s.True(gock.IsDone())
+}
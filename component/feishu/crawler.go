@@ -0,0 +1,151 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+)
+
+const discoverReportInterval = 200 * time.Millisecond // 刷新已发现文档数量的间隔
+
+// discoverWorkers 读取文档树时同时请求的协程数量。
+var discoverWorkers = 5
+
+// crawlJob 遍历任务，一般是查询某个父节点的一页子节点，子节点还有子节点时再添加新的遍历任务。
+type crawlJob func(ctx context.Context) error
+
+// crawler 有界并发的广度优先遍历器，用于读取知识库和云空间的文档树。
+// 同一个父节点的分页由上一页的任务添加下一页的任务，所以同一时刻只有一个任务在修改该父节点的子节点，子节点顺序与接口返回顺序一致。
+type crawler struct {
+	out        io.Writer // 输出已发现文档数量，为nil时不输出
+	mu         sync.Mutex
+	cond       *sync.Cond
+	jobs       []crawlJob   // 等待执行的遍历任务，先进先出
+	active     int          // 正在执行的遍历任务数量
+	discovered atomic.Int64 // 已发现的文档数量
+}
+
+func newCrawler(out io.Writer) *crawler {
+	cr := &crawler{out: out}
+	cr.cond = sync.NewCond(&cr.mu)
+	return cr
+}
+
+// push 添加遍历任务。
+func (cr *crawler) push(job crawlJob) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.jobs = append(cr.jobs, job)
+	cr.cond.Signal()
+}
+
+// discover 累加已发现的文档数量。
+func (cr *crawler) discover(n int) {
+	cr.discovered.Add(int64(n))
+}
+
+// run 启动workers个协程执行遍历任务，直到所有任务执行完成，任意任务出错或ctx取消时停止并返回错误。
+func (cr *crawler) run(ctx context.Context, workers int) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	// ctx取消时唤醒等待任务的协程
+	stop := context.AfterFunc(ctx, func() {
+		cr.mu.Lock()
+		defer cr.mu.Unlock()
+		cr.cond.Broadcast()
+	})
+	defer stop()
+
+	done := make(chan struct{})
+	reported := cr.report(done)
+	var wg sync.WaitGroup
+	for range max(1, workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cr.work(ctx, cancel)
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-reported
+
+	if err := context.Cause(ctx); err != nil {
+		return oops.Wrap(err)
+	}
+	return nil
+}
+
+// work 循环取出遍历任务执行，没有等待执行的任务且没有正在执行的任务时退出。
+func (cr *crawler) work(ctx context.Context, cancel context.CancelCauseFunc) {
+	for {
+		cr.mu.Lock()
+		for len(cr.jobs) == 0 && cr.active > 0 && ctx.Err() == nil {
+			cr.cond.Wait()
+		}
+		if ctx.Err() != nil || len(cr.jobs) == 0 {
+			cr.cond.Broadcast()
+			cr.mu.Unlock()
+			return
+		}
+		job := cr.jobs[0]
+		cr.jobs[0] = nil
+		cr.jobs = cr.jobs[1:]
+		cr.active++
+		cr.mu.Unlock()
+
+		if err := job(ctx); err != nil {
+			cancel(err)
+		}
+
+		cr.mu.Lock()
+		cr.active--
+		if cr.active == 0 && len(cr.jobs) == 0 {
+			cr.cond.Broadcast()
+		}
+		cr.mu.Unlock()
+	}
+}
+
+// report 定时刷新输出已发现的文档数量，done关闭后输出最终数量并换行，返回的通道在输出完成后关闭。
+func (cr *crawler) report(done <-chan struct{}) <-chan struct{} {
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		if cr.out == nil {
+			return
+		}
+		ticker := time.NewTicker(discoverReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				app.Fprintf(cr.out, "\r已发现文档数量: %d\n", cr.discovered.Load())
+				return
+			case <-ticker.C:
+				app.Fprintf(cr.out, "\r已发现文档数量: %d", cr.discovered.Load())
+			}
+		}
+	}()
+	return reported
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_run(t *testing.T) {
+	// 模拟一棵每个节点有3个子节点、深度为4的树，每个任务处理一个节点
+	var out bytes.Buffer
+	cr := newCrawler(&out)
+	var mu sync.Mutex
+	var visited []int
+	var running, maxRunning atomic.Int32
+	var visit func(depth, id int) crawlJob
+	visit = func(depth, id int) crawlJob {
+		return func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			mu.Lock()
+			visited = append(visited, id)
+			mu.Unlock()
+			if depth == 4 {
+				return nil
+			}
+			for i := range 3 {
+				cr.push(visit(depth+1, id*3+i+1))
+			}
+			cr.discover(3)
+			return nil
+		}
+	}
+	cr.push(visit(1, 0))
+	err := cr.run(context.Background(), 4)
+	require.NoError(t, err)
+	require.Len(t, visited, 1+3+9+27)
+	require.LessOrEqual(t, maxRunning.Load(), int32(4))
+	require.Equal(t, int64(39), cr.discovered.Load())
+	require.True(t, strings.HasSuffix(out.String(), "\r已发现文档数量: 39\n"), out.String())
+}
+
+func TestCrawler_run_error(t *testing.T) {
+	cr := newCrawler(nil)
+	var executed atomic.Int32
+	var job crawlJob
+	job = func(ctx context.Context) error {
+		if executed.Add(1) == 3 {
+			return errors.New("请求失败")
+		}
+		// 出错后不再执行新的任务
+		cr.push(job)
+		cr.push(job)
+		return nil
+	}
+	cr.push(job)
+	err := cr.run(context.Background(), 1)
+	require.EqualError(t, err, "请求失败")
+	require.Equal(t, int32(3), executed.Load())
+}
+
+func TestCrawler_run_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cr := newCrawler(nil)
+	var executed atomic.Bool
+	cr.push(func(ctx context.Context) error {
+		executed.Store(true)
+		return nil
+	})
+	err := cr.run(ctx, 2)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, executed.Load())
+}
+
+func TestCrawler_run_empty(t *testing.T) {
+	var out bytes.Buffer
+	err := newCrawler(&out).run(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "\r已发现文档数量: 0\n", out.String())
+}
@@ -15,10 +15,12 @@
 package feishu
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
@@ -28,8 +30,14 @@ import (
 	"github.com/acyumi/xdoc/component/app"
 	"github.com/acyumi/xdoc/component/cloud"
 	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
 )
 
+const documentTreeFile = "document-tree.json" // 文档树信息文件名，保存在 SaveDir 中
+
+// statusLock 保护 DocumentInfo.Status 的并发读写（导出下载协程写入，保存文档树时读取）。
+var statusLock sync.Mutex
+
 type DocumentInfo struct {
 	Name          string           `json:"name"          yaml:"name" bson:"name" gorm:"dddd"` // 文档名
 	Type          constant.DocType `json:"type"          yaml:"type" bson:"name" gorm:"dddd"` // 文档类型
@@ -38,12 +46,22 @@ type DocumentInfo struct {
 	CanDownload   bool             `json:"canDownload"`                                       // 是否可下载
 
 	DownloadDirectly bool   `json:"downloadDirectly"` // 是否使用【下载文件】API直接下载
+	ConvertLocally   bool   `json:"convertLocally"`   // 是否读取文档内容后在本地转换，如docx转为md
 	URL              string `json:"url"`              // 在浏览器中查看的链接
 
 	NodeToken string `json:"nodeToken"` // 知识节点ID
 	SpaceID   string `json:"spaceId"`   // 知识空间ID
 
-	FilePath string // 文件保存路径
+	ModifiedTime string `json:"modifiedTime"`          // 文档最近编辑时间（Unix时间戳，秒）
+	CreatedTime  string `json:"createdTime,omitempty"` // 文档创建时间（Unix时间戳，秒）
+	OwnerID      string `json:"ownerId,omitempty"`     // 文档所有者ID
+
+	SplitBySheet bool   `json:"splitBySheet"` // 是否按工作表（数据表）拆分为多个文件导出，为true时本身作为目录，拆分出的文件作为子节点
+	SubID        string `json:"subId"`        // 拆分导出时的工作表ID或数据表ID
+
+	FilePath string          // 文件保存路径
+	Status   progress.Status `json:"status,omitempty"`   // 导出下载的最终状态，用于下次增量导出时判断是否需要跳过
+	Excluded bool            `json:"excluded,omitempty"` // 是否被过滤条件排除，已排除的文档不导出
 }
 
 type DocumentNode struct {
@@ -55,24 +73,19 @@ func (di *DocumentInfo) GetFileName() string {
 	return fmt.Sprintf("%s.%s", di.Name, di.FileExtension)
 }
 
+// isDir 是否作为本地目录保存，包括文件夹和按工作表拆分导出的表格。
+func (di *DocumentInfo) isDir() bool {
+	return di.Type == constant.DocTypeFolder || di.SplitBySheet
+}
+
 type exportResult struct {
 	*DocumentInfo
-	result *larkdrive.ExportTask // 如果 DocumentInfo.DownloadDirectly=true，则 result 为空
+	result *larkdrive.ExportTask // 如果 DocumentInfo.DownloadDirectly=true 或 DocumentInfo.ConvertLocally=true，则 result 为空
 }
 
-func cleanName(name string) string {
-	// windows目录或文件名不允许使用的字符比其他系统更多
-	// windows目录或文件名不允许使用这些字符: \/:*?"<>|
-	name = strings.ReplaceAll(name, `\`, "_")
-	name = strings.ReplaceAll(name, "/", "_")
-	name = strings.ReplaceAll(name, ":", "_")
-	name = strings.ReplaceAll(name, "*", "_")
-	name = strings.ReplaceAll(name, "?", "_")
-	name = strings.ReplaceAll(name, `"`, "_")
-	name = strings.ReplaceAll(name, "<", "_")
-	name = strings.ReplaceAll(name, ">", "_")
-	name = strings.ReplaceAll(name, "|", "_")
-	return name
+// cleanName 按 name-policy 和 unicode-form 将文档名转为合法的文件名。
+func (c *ClientImpl) cleanName(name string) string {
+	return newPathSanitizer(c.Args).name(name)
 }
 
 func setFileExtension(dn *DocumentNode, args *Args) {
@@ -105,12 +118,34 @@ func setFileExtension(dn *DocumentNode, args *Args) {
 	// doc：旧版飞书文档。支持导出扩展名为 docx 和 pdf 的文件。已不推荐使用。
 	// sheet：飞书电子表格。支持导出扩展名为 xlsx 和 csv 的文件。
 	// bitable：飞书多维表格。支持导出扩展名为 xlsx 和 csv 格式的文件。
+	// 导出为 csv 时一次只能导出一个工作表（数据表），由 splitSheets 拆分为多个子节点导出。
+	// 多维表格还支持在本地转换为 json。
 	case constant.DocTypeDocx, constant.DocTypeDoc:
 		dn.CanDownload = true
 		setOrDefault(constant.FileExtDocx)
+		// md 不是导出任务支持的格式，只有新版文档可以通过读取文档块在本地转换
+		if dn.FileExtension == constant.FileExtMarkdown {
+			switch {
+			case dn.DownloadDirectly:
+				// 上传的docx文件直接下载，保持原扩展名
+				dn.FileExtension = constant.FileExt(dn.Type)
+			case dn.Type == constant.DocTypeDocx:
+				dn.ConvertLocally = true
+			default:
+				dn.CanDownload = false
+			}
+		}
 	case constant.DocTypeBitable, constant.DocTypeSheet:
 		dn.CanDownload = true
 		setOrDefault(constant.FileExtXlsx)
+		// json 不是导出任务支持的格式，只有多维表格可以通过读取数据表的字段和记录在本地转换
+		if dn.FileExtension == constant.FileExtJSON {
+			if dn.Type == constant.DocTypeBitable && !dn.DownloadDirectly {
+				dn.ConvertLocally = true
+			} else {
+				dn.CanDownload = false
+			}
+		}
 	default:
 		setOrDefault(constant.FileExt(dn.Type))
 	}
@@ -172,11 +207,21 @@ func documentNodeToInfoList(dn *DocumentNode) []*DocumentInfo {
 	return infoList
 }
 
-// documentNodesToInfoList 多棵完整的文档树转为一维列表。
+// flattenDocumentNodes 多棵文档树转为一维列表，沿用已经计算好的文件保存路径。
+func flattenDocumentNodes(dns []*DocumentNode) []*DocumentInfo {
+	var infoList []*DocumentInfo
+	for _, dn := range dns {
+		infoList = append(infoList, documentNodeToInfoList(dn)...)
+	}
+	return infoList
+}
+
+// documentNodesToInfoList 多棵完整的文档树转为一维列表，并按文档层级计算文件保存路径。
+// 指定了路径模板时还需要调用 applyPathTemplate 重新计算，之后只能通过 flattenDocumentNodes 转为列表。
 func documentNodesToInfoList(dns []*DocumentNode, saveDir string) []*DocumentInfo {
 	var infoList []*DocumentInfo
 	for _, dn := range dns {
-		if dn.Type == constant.DocTypeFolder {
+		if dn.isDir() {
 			dn.FilePath = filepath.Join(saveDir, dn.Name)
 		} else {
 			dn.FilePath = filepath.Join(saveDir, dn.Name+"."+string(dn.FileExtension))
@@ -187,9 +232,9 @@ func documentNodesToInfoList(dns []*DocumentNode, saveDir string) []*DocumentInf
 	return infoList
 }
 
-// 递归打印目录结构及文件名，打印过程中会调整空文件名为"未命名xxxn.xxx"格式
+// 递归打印目录结构及文件名，文件名需要提前通过 resolveNames 调整好
 // tree：需要在调用前构造好传进来，以后也不要想着改造成传nil再在第一次处理时从函数内部构造
-// 返回值：tc: totalCount, cdc: canDownloadCount。
+// 返回值：tc: totalCount, cdc: canDownloadCount（不包括已排除的文档）。
 func printTree(logWriter io.Writer, tree treeprint.Tree, dns []*DocumentNode, totalCount, canDownloadCount int) (tc, cdc int) {
 	if totalCount == 0 {
 		root := tree
@@ -198,26 +243,36 @@ func printTree(logWriter io.Writer, tree treeprint.Tree, dns []*DocumentNode, to
 		}()
 		app.Fprint(logWriter, "\n")
 	}
-	temp := map[string]int{}
 	for _, child := range dns {
 		totalCount++
 		suffix := string(child.FileExtension)
-		if child.CanDownload {
-			canDownloadCount++
-		} else {
+		switch {
+		case !child.CanDownload:
 			suffix += "（不可下载）"
+		case !child.Excluded:
+			canDownloadCount++
+		}
+		if child.Status == progress.StatusSkipped {
+			suffix += "（未变更）"
+		}
+		dirName := child.Name
+		if child.Excluded {
+			// 文件和目录都标记出被过滤条件排除的节点
+			suffix += "（已排除）"
+			if child.isDir() {
+				dirName += "（已排除）"
+			}
 		}
-		child.Name = getName(child.Name, child.Type, temp)
 		if len(child.Children) > 0 {
-			if child.Type != constant.DocTypeFolder {
+			if !child.isDir() {
 				tree.AddNode(child.Name + "." + suffix) // 文件
 			}
-			branch := tree.AddBranch(child.Name) // 目录
+			branch := tree.AddBranch(dirName) // 目录
 			totalCount, canDownloadCount = printTree(logWriter, branch, child.Children, totalCount, canDownloadCount)
 			continue
 		}
 		if child.Type == constant.DocTypeFolder {
-			tree.AddNode(child.Name) // 目录
+			tree.AddNode(dirName) // 目录
 			continue
 		}
 		tree.AddNode(child.Name + "." + suffix) // 文件
@@ -225,38 +280,82 @@ func printTree(logWriter io.Writer, tree treeprint.Tree, dns []*DocumentNode, to
 	return totalCount, canDownloadCount
 }
 
-func getName(name string, typ constant.DocType, duplicateNameIndexMap map[string]int) string {
-	if name != "" {
-		index, ok := duplicateNameIndexMap[name]
-		if !ok {
-			duplicateNameIndexMap[name] = 0
-			return name
+// loadDocumentTree 读取上一次保存在 saveDir 中的文档树信息，文件不存在时返回nil。
+func loadDocumentTree(saveDir string) ([]*DocumentNode, error) {
+	filePath := filepath.Join(saveDir, documentTreeFile)
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return nil, oops.Wrap(err)
+	}
+	data, err := app.Fs.ReadFile(filePath)
+	if err != nil {
+		return nil, oops.Wrapf(err, "读取文件失败")
+	}
+	var dns []*DocumentNode
+	if err = json.Unmarshal(data, &dns); err != nil {
+		return nil, oops.Wrapf(err, "解析文件失败: %s", filePath)
+	}
+	return dns, nil
+}
+
+// saveDocumentTree 将文档树信息保存到 saveDir 中的 document-tree.json 文件。
+func saveDocumentTree(dns []*DocumentNode, saveDir string) error {
+	err := app.Fs.MkdirAll(saveDir, 0o755)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	statusLock.Lock()
+	diBytes, err := app.MarshalIndent(dns, "", "  ")
+	statusLock.Unlock()
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	filePath := filepath.Join(saveDir, documentTreeFile)
+	err = app.Fs.WriteFile(filePath, diBytes, 0o644)
+	if err != nil {
+		return oops.Wrapf(err, "写入文件失败")
+	}
+	return nil
+}
+
+// markUnchanged 对比上一次的文档树，将未变更的可下载文档标记为已跳过，返回跳过的数量。
+// 未变更是指：文件保存路径、token、类型和最近编辑时间都相同，上一次已下载完成，且本地文件仍然存在。
+// 需要在 documentNodesToInfoList 计算好文件保存路径后调用。
+func markUnchanged(dns, previous []*DocumentNode) (int, error) {
+	prevMap := map[string]*DocumentInfo{}
+	for _, dn := range previous {
+		for _, di := range documentNodeToInfoList(dn) {
+			prevMap[di.FilePath] = di
 		}
-		duplicateNameIndexMap[name] = index + 1
-		return fmt.Sprintf("%s%d", name, index+1)
 	}
-	unnamedType := string("[Unnamed]" + typ)
-	unnamedIndex := duplicateNameIndexMap[unnamedType] + 1
-	duplicateNameIndexMap[unnamedType] = unnamedIndex
-	switch typ {
-	case constant.DocTypeDocx:
-		return fmt.Sprintf("未命名新版文档%d", unnamedIndex)
-	case constant.DocTypeDoc:
-		return fmt.Sprintf("未命名旧版文档%d", unnamedIndex)
-	case constant.DocTypeSheet:
-		return fmt.Sprintf("未命名电子表格%d", unnamedIndex)
-	case constant.DocTypeBitable:
-		return fmt.Sprintf("未命名多维表格%d", unnamedIndex)
-	case constant.DocTypeMindNote:
-		return fmt.Sprintf("未命名思维笔记%d", unnamedIndex)
-	case constant.DocTypeSlides:
-		return fmt.Sprintf("未命名幻灯片%d", unnamedIndex)
-	default:
-		return fmt.Sprintf("未命名飞书文档%d", unnamedIndex)
+	var count int
+	for _, dn := range dns {
+		for _, di := range documentNodeToInfoList(dn) {
+			prev, ok := prevMap[di.FilePath]
+			if !ok || !di.CanDownload || di.Excluded || di.ModifiedTime == "" {
+				continue
+			}
+			if prev.Token != di.Token || prev.Type != di.Type || prev.ModifiedTime != di.ModifiedTime {
+				continue
+			}
+			if prev.Status != progress.StatusCompleted && prev.Status != progress.StatusSkipped {
+				continue
+			}
+			yes, err := app.Fs.Exists(di.FilePath)
+			if err != nil {
+				return count, oops.Wrap(err)
+			}
+			if !yes {
+				continue
+			}
+			di.Status = progress.StatusSkipped
+			count++
+		}
 	}
+	return count, nil
 }
 
-func doExportAndDownload(task cloud.Task) error {
+func doExportAndDownload(ctx context.Context, task cloud.Task) error {
 	err := task.Validate()
 	if err != nil {
 		return oops.Wrap(err)
@@ -264,6 +363,6 @@ func doExportAndDownload(task cloud.Task) error {
 	defer app.Sleep(time.Second * 2)
 	defer task.Close()
 
-	err = task.Run()
+	err = task.Run(ctx)
 	return oops.Wrap(err)
 }
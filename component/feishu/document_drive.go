@@ -16,6 +16,7 @@ package feishu
 
 import (
 	"context"
+	"os"
 
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
@@ -24,7 +25,7 @@ import (
 	"github.com/acyumi/xdoc/component/constant"
 )
 
-func (c *ClientImpl) QueryDriveDocuments(typ constant.DocType, token string) (*DocumentNode, error) {
+func (c *ClientImpl) QueryDriveDocuments(ctx context.Context, typ constant.DocType, token string) (*DocumentNode, error) {
 	// 调用【获取文件夹元数据】接口
 	// https://open.feishu.cn/document/server-docs/docs/drive-v1/folder/get-folder-meta
 	// 创建请求对象
@@ -41,7 +42,7 @@ func (c *ClientImpl) QueryDriveDocuments(typ constant.DocType, token string) (*D
 		Build()
 	// 发起请求
 	resp, err := SendWithRetry(func(_ int) (*larkdrive.BatchQueryMetaResp, error) {
-		return c.DriveBatchQuery(context.Background(), req)
+		return c.DriveBatchQuery(ctx, req)
 	})
 	// 处理错误
 	if err != nil {
@@ -57,13 +58,16 @@ func (c *ClientImpl) QueryDriveDocuments(typ constant.DocType, token string) (*D
 	meta := resp.Data.Metas[0]
 	dn := &DocumentNode{
 		DocumentInfo: DocumentInfo{
-			Name:  larkcore.StringValue(meta.Title),
-			Type:  typ,
-			Token: token,
+			Name:         c.cleanName(larkcore.StringValue(meta.Title)),
+			Type:         typ,
+			Token:        token,
+			ModifiedTime: larkcore.StringValue(meta.LatestModifyTime),
+			CreatedTime:  larkcore.StringValue(meta.CreateTime),
+			OwnerID:      larkcore.StringValue(meta.OwnerId),
 		},
 	}
 	if typ == constant.DocTypeFolder {
-		err = c.fetchDriveDescendant(dn, true, token, "")
+		err = c.fetchDriveDescendant(ctx, dn, true, token, "")
 		if err != nil {
 			return nil, oops.Wrap(err)
 		}
@@ -74,10 +78,20 @@ func (c *ClientImpl) QueryDriveDocuments(typ constant.DocType, token string) (*D
 	return dn, nil
 }
 
-func (c *ClientImpl) fetchDriveDescendant(dn *DocumentNode, hasChild bool, folderToken, pageToken string) error {
+// fetchDriveDescendant 并发地广度优先查询文件夹中的所有文件，从pageToken指定的页开始查询。
+func (c *ClientImpl) fetchDriveDescendant(ctx context.Context, dn *DocumentNode, hasChild bool, folderToken, pageToken string) error {
 	if !hasChild {
 		return nil
 	}
+	cr := newCrawler(c.Args.LogOutput(os.Stdout))
+	cr.push(func(ctx context.Context) error {
+		return c.fetchDriveChildren(ctx, cr, dn, folderToken, pageToken)
+	})
+	return cr.run(ctx, discoverWorkers)
+}
+
+// fetchDriveChildren 查询文件夹中的一页文件，子文件夹和下一页作为新的遍历任务。
+func (c *ClientImpl) fetchDriveChildren(ctx context.Context, cr *crawler, dn *DocumentNode, folderToken, pageToken string) error {
 	// 调用【获取文件夹中的文件清单】接口
 	// https://open.feishu.cn/document/server-docs/docs/drive-v1/folder/list
 	// 创建请求对象
@@ -90,33 +104,32 @@ func (c *ClientImpl) fetchDriveDescendant(dn *DocumentNode, hasChild bool, folde
 		Build()
 	// 发起请求
 	resp, err := SendWithRetry(func(_ int) (*larkdrive.ListFileResp, error) {
-		return c.DriveList(context.Background(), req)
+		return c.DriveList(ctx, req)
 	})
 	// 处理错误
 	if err != nil {
 		return oops.Wrap(err)
 	}
 
-	// 查到的子节点添加到doc中，然后再递归查询子节点
+	// 查到的子节点添加到doc中，子文件夹再添加遍历任务
 	for _, file := range resp.Data.Files {
 		child := c.fileToDocumentNode(file)
 		dn.Children = append(dn.Children, child)
-		// 如果类型是文件夹那就递归遍历
 		if larkcore.StringValue(file.Type) != string(constant.DocTypeFolder) {
 			continue
 		}
-		err = c.fetchDriveDescendant(child, true, larkcore.StringValue(file.Token), "")
-		if err != nil {
-			return oops.Wrap(err)
-		}
+		childToken := larkcore.StringValue(file.Token)
+		cr.push(func(ctx context.Context) error {
+			return c.fetchDriveChildren(ctx, cr, child, childToken, "")
+		})
 	}
+	cr.discover(len(resp.Data.Files))
 
 	if larkcore.BoolValue(resp.Data.HasMore) {
-		pageToken = larkcore.StringValue(resp.Data.NextPageToken)
-		err = c.fetchDriveDescendant(dn, true, folderToken, pageToken)
-		if err != nil {
-			return oops.Wrap(err)
-		}
+		nextPageToken := larkcore.StringValue(resp.Data.NextPageToken)
+		cr.push(func(ctx context.Context) error {
+			return c.fetchDriveChildren(ctx, cr, dn, folderToken, nextPageToken)
+		})
 	}
 
 	return nil
@@ -126,8 +139,11 @@ func (c *ClientImpl) fileToDocumentNode(file *larkdrive.File) *DocumentNode {
 	var dn = &DocumentNode{}
 	// 先判断文件夹类型，看是否可以下载，然后再判断有没有子节点
 	dn.Name = larkcore.StringValue(file.Name)
-	dn.Name = cleanName(dn.Name)
+	dn.Name = c.cleanName(dn.Name)
 	dn.URL = larkcore.StringValue(file.Url)
+	dn.ModifiedTime = larkcore.StringValue(file.ModifiedTime)
+	dn.CreatedTime = larkcore.StringValue(file.CreatedTime)
+	dn.OwnerID = larkcore.StringValue(file.OwnerId)
 	// 如果是快捷方式，则获取快捷方式的目标文件
 	dn.Type = constant.DocType(larkcore.StringValue(file.Type))
 	if dn.Type == constant.DocTypeShortcut {
@@ -15,6 +15,7 @@
 package feishu
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -42,6 +43,8 @@ type DocumentDriveTestSuite struct {
 func (s *DocumentDriveTestSuite) SetupSuite() {
 	initBackOff = testInitBackOff
 	cleanSleep()
+	// 单协程读取文档树，保证请求顺序固定
+	discoverWorkers = 1
 }
 
 func (s *DocumentDriveTestSuite) SetupTest() {
@@ -59,6 +62,7 @@ func (s *DocumentDriveTestSuite) TearDownTest() {
 
 func (s *DocumentDriveTestSuite) TearDownSuite() {
 	initBackOff = initExponentialBackOff
+	discoverWorkers = 5
 }
 
 func (s *DocumentDriveTestSuite) TestQueryDriveDocuments() {
@@ -214,6 +218,9 @@ func (s *DocumentDriveTestSuite) TestQueryDriveDocuments() {
 					Name:             "sampletitle",
 					Type:             "folder",
 					Token:            "folderToken",
+					ModifiedTime:     "1652066345",
+					CreatedTime:      "1652066345",
+					OwnerID:          "ou_b13d41c02edc52ce66aaae67bf1abcef",
 					FileExtension:    "",
 					CanDownload:      false,
 					DownloadDirectly: false,
@@ -228,6 +235,9 @@ func (s *DocumentDriveTestSuite) TestQueryDriveDocuments() {
 							Name:             "test docx",
 							Type:             "docx",
 							Token:            "boxbc0dGSMu23m7QkC1bvabcef",
+							ModifiedTime:     "1679277808",
+							CreatedTime:      "1679277808",
+							OwnerID:          "ou_20b31734443364ec8a1df89fdf325b44",
 							FileExtension:    "docx",
 							CanDownload:      true,
 							DownloadDirectly: false,
@@ -347,6 +357,9 @@ func (s *DocumentDriveTestSuite) TestQueryDriveDocuments() {
 					Name:             "sampletitle",
 					Type:             "file",
 					Token:            "fileToken",
+					ModifiedTime:     "1652066345",
+					CreatedTime:      "1652066345",
+					OwnerID:          "ou_b13d41c02edc52ce66aaae67bf1abcef",
 					FileExtension:    "file",
 					CanDownload:      true,
 					DownloadDirectly: true,
@@ -387,7 +400,7 @@ func (s *DocumentDriveTestSuite) TestQueryDriveDocuments() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.name)
-			actual, err := s.client.QueryDriveDocuments(tt.typ, tt.token)
+			actual, err := s.client.QueryDriveDocuments(context.Background(), tt.typ, tt.token)
 			tt.teardownMock(tt.name)
 			if err != nil || tt.wantError != "" {
 				s.Require().EqualError(err, tt.wantError, tt.name)
@@ -692,6 +705,9 @@ func (s *DocumentDriveTestSuite) Test_fetchDriveDescendant() {
 							Name:             "test folder",
 							Type:             "folder",
 							Token:            "boxbc0dGSMu23m7QkC1bvabcef",
+							ModifiedTime:     "1679277808",
+							CreatedTime:      "1679277808",
+							OwnerID:          "ou_20b31734443364ec8a1df89fdf325b44",
 							FileExtension:    "folder",
 							CanDownload:      false,
 							DownloadDirectly: false,
@@ -809,7 +825,7 @@ func (s *DocumentDriveTestSuite) Test_fetchDriveDescendant() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.name, tt.dn, tt.hasChild, tt.folderToken, tt.pageToken)
-			err := s.client.fetchDriveDescendant(tt.dn, tt.hasChild, tt.folderToken, tt.pageToken)
+			err := s.client.fetchDriveDescendant(context.Background(), tt.dn, tt.hasChild, tt.folderToken, tt.pageToken)
 			tt.teardownMock(tt.name)
 			if err != nil || tt.wantError != "" {
 				s.Require().Error(err, tt.name)
@@ -870,6 +886,9 @@ func (s *DocumentDriveTestSuite) TestClientImpl_fileToDocumentNode() {
 					FilePath:         "",
 					CanDownload:      true,
 					DownloadDirectly: false,
+					ModifiedTime:     "ModifiedTime",
+					CreatedTime:      "CreatedTime",
+					OwnerID:          "OwnerId",
 				},
 			},
 		},
@@ -903,6 +922,9 @@ func (s *DocumentDriveTestSuite) TestClientImpl_fileToDocumentNode() {
 					FilePath:         "",
 					CanDownload:      true,
 					DownloadDirectly: false,
+					ModifiedTime:     "ModifiedTime",
+					CreatedTime:      "CreatedTime",
+					OwnerID:          "OwnerId",
 				},
 			},
 		},
@@ -0,0 +1,142 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"sort"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	larksheets "github.com/larksuite/oapi-sdk-go/v3/service/sheets/v3"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// subSheet 电子表格的工作表或多维表格的数据表。
+type subSheet struct {
+	id   string // 工作表ID或数据表ID
+	name string // 工作表标题或数据表名字
+}
+
+// splitSheets 递归将导出为csv的电子表格和多维表格按工作表（数据表）拆分。
+// 导出任务一次只能将一个工作表（数据表）导出为csv，所以每个工作表（数据表）作为一个子节点单独导出，
+// 保存为 <表格名>/<工作表名>.csv，表格本身作为目录。
+func (c *ClientImpl) splitSheets(ctx context.Context, dns []*DocumentNode) error {
+	for _, dn := range dns {
+		if err := c.splitSheets(ctx, dn.Children); err != nil {
+			return oops.Wrap(err)
+		}
+		if !needSplitBySheet(&dn.DocumentInfo) {
+			continue
+		}
+		var subs []subSheet
+		var err error
+		if dn.Type == constant.DocTypeSheet {
+			subs, err = c.querySheets(ctx, dn.Token)
+		} else {
+			subs, err = c.queryBitableTables(ctx, dn.Token, "")
+		}
+		if err != nil {
+			return oops.Wrapf(err, "查询工作表失败: %s", dn.Name)
+		}
+		children := make([]*DocumentNode, 0, len(subs)+len(dn.Children))
+		for _, sub := range subs {
+			children = append(children, &DocumentNode{
+				DocumentInfo: DocumentInfo{
+					Name:          c.cleanName(sub.name),
+					Type:          dn.Type,
+					Token:         dn.Token,
+					FileExtension: dn.FileExtension,
+					CanDownload:   true,
+					URL:           dn.URL,
+					ModifiedTime:  dn.ModifiedTime,
+					CreatedTime:   dn.CreatedTime,
+					OwnerID:       dn.OwnerID,
+					SubID:         sub.id,
+				},
+			})
+		}
+		dn.Children = append(children, dn.Children...)
+		dn.CanDownload = false
+		dn.SplitBySheet = true
+	}
+	return nil
+}
+
+// needSplitBySheet 是否需要按工作表（数据表）拆分导出，直接下载的文件不需要拆分。
+func needSplitBySheet(di *DocumentInfo) bool {
+	if !di.CanDownload || di.DownloadDirectly || di.FileExtension != constant.FileExtCSV {
+		return false
+	}
+	return di.Type == constant.DocTypeSheet || di.Type == constant.DocTypeBitable
+}
+
+// querySheets 查询电子表格的所有工作表，按工作表的索引位置排序。
+func (c *ClientImpl) querySheets(ctx context.Context, token string) ([]subSheet, error) {
+	// 调用【获取工作表】接口
+	// https://open.feishu.cn/document/server-docs/docs/sheets-v3/spreadsheet-sheet/query
+	req := larksheets.NewQuerySpreadsheetSheetReqBuilder().SpreadsheetToken(token).Build()
+	resp, err := SendWithRetry(func(_ int) (*larksheets.QuerySpreadsheetSheetResp, error) {
+		return c.SheetsQuery(ctx, req)
+	})
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	sheets := resp.Data.Sheets
+	sort.SliceStable(sheets, func(i, j int) bool {
+		return larkcore.IntValue(sheets[i].Index) < larkcore.IntValue(sheets[j].Index)
+	})
+	var subs []subSheet
+	for _, sheet := range sheets {
+		// 嵌入电子表格的多维表格等其他类型不能导出为csv
+		resourceType := larkcore.StringValue(sheet.ResourceType)
+		if resourceType != "" && resourceType != "sheet" {
+			continue
+		}
+		subs = append(subs, subSheet{id: larkcore.StringValue(sheet.SheetId), name: larkcore.StringValue(sheet.Title)})
+	}
+	return subs, nil
+}
+
+// queryBitableTables 分页查询多维表格的所有数据表。
+func (c *ClientImpl) queryBitableTables(ctx context.Context, token, pageToken string) ([]subSheet, error) {
+	// 调用【列出数据表】接口
+	// https://open.feishu.cn/document/server-docs/docs/bitable-v1/app-table/list
+	req := larkbitable.NewListAppTableReqBuilder().
+		AppToken(token).
+		PageToken(pageToken).
+		PageSize(100).
+		Build()
+	resp, err := SendWithRetry(func(_ int) (*larkbitable.ListAppTableResp, error) {
+		return c.BitableTableList(ctx, req)
+	})
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	var subs []subSheet
+	for _, table := range resp.Data.Items {
+		subs = append(subs, subSheet{id: larkcore.StringValue(table.TableId), name: larkcore.StringValue(table.Name)})
+	}
+	if larkcore.BoolValue(resp.Data.HasMore) {
+		more, err := c.queryBitableTables(ctx, token, larkcore.StringValue(resp.Data.PageToken))
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		subs = append(subs, more...)
+	}
+	return subs, nil
+}
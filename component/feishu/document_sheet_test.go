@@ -0,0 +1,192 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+func TestDocumentSheetSuite(t *testing.T) {
+	suite.Run(t, new(DocumentSheetTestSuite))
+}
+
+type DocumentSheetTestSuite struct {
+	suite.Suite
+	client *ClientImpl
+}
+
+func (s *DocumentSheetTestSuite) SetupSuite() {
+	initBackOff = testInitBackOff
+	cleanSleep()
+}
+
+func (s *DocumentSheetTestSuite) SetupTest() {
+	s.client = NewClient(&Args{
+		AppID:     "cli_xxx",
+		AppSecret: "xxx",
+		Args: &argument.Args{
+			StartTime: time.Now(),
+		},
+	}).(*ClientImpl)
+}
+
+func (s *DocumentSheetTestSuite) TearDownTest() {
+	gock.Off()
+}
+
+func (s *DocumentSheetTestSuite) TearDownSuite() {
+	initBackOff = initExponentialBackOff
+}
+
+func (s *DocumentSheetTestSuite) TestSplitSheets() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/sheets/v3/spreadsheets/shtToken/sheets/query").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "sheets": [
+            {"sheet_id": "sheet2", "title": "第二页", "index": 1, "resource_type": "sheet"},
+            {"sheet_id": "sheet1", "title": "a/b", "index": 0, "resource_type": "sheet"},
+            {"sheet_id": "bitable", "title": "嵌入的多维表格", "index": 2, "resource_type": "bitable"}
+        ]
+    }
+}`)
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/bascnToken/tables").
+		MatchParam("page_size", "100").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": true,
+        "page_token": "next",
+        "items": [{"table_id": "tbl1", "name": "数据表1"}]
+    }
+}`)
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/bascnToken/tables").
+		MatchParam("page_token", "next").
+		Reply(200).
+		JSON(`{
+    "code": 0,
+    "msg": "success",
+    "data": {
+        "has_more": false,
+        "items": [{"table_id": "tbl2", "name": "数据表2"}]
+    }
+}`)
+
+	wikiChild := &DocumentNode{DocumentInfo: DocumentInfo{Name: "子文档", Type: constant.DocTypeDocx, Token: "docx", CanDownload: true}}
+	sheet := &DocumentNode{
+		DocumentInfo: DocumentInfo{Name: "表格", Type: constant.DocTypeSheet, Token: "shtToken", FileExtension: constant.FileExtCSV, CanDownload: true,
+			ModifiedTime: "1", CreatedTime: "0", OwnerID: "ou_xxx"},
+		Children: []*DocumentNode{wikiChild},
+	}
+	bitable := &DocumentNode{DocumentInfo: DocumentInfo{Name: "多维表格", Type: constant.DocTypeBitable, Token: "bascnToken", FileExtension: constant.FileExtCSV, CanDownload: true}}
+	xlsx := &DocumentNode{DocumentInfo: DocumentInfo{Name: "xlsx", Type: constant.DocTypeSheet, Token: "xlsx", FileExtension: constant.FileExtXlsx, CanDownload: true}}
+	folder := &DocumentNode{
+		DocumentInfo: DocumentInfo{Name: "目录", Type: constant.DocTypeFolder, Token: "folder"},
+		Children:     []*DocumentNode{sheet, bitable, xlsx},
+	}
+	err := s.client.splitSheets(context.Background(), []*DocumentNode{folder})
+	s.Require().NoError(err)
+	s.True(gock.IsDone())
+
+	s.False(sheet.CanDownload)
+	s.True(sheet.SplitBySheet)
+	s.Require().Len(sheet.Children, 3)
+	s.Equal(DocumentInfo{Name: "a_b", Type: constant.DocTypeSheet, Token: "shtToken", FileExtension: constant.FileExtCSV,
+		CanDownload: true, ModifiedTime: "1", CreatedTime: "0", OwnerID: "ou_xxx", SubID: "sheet1"}, sheet.Children[0].DocumentInfo)
+	s.Equal("sheet2", sheet.Children[1].SubID)
+	s.Equal("第二页", sheet.Children[1].Name)
+	s.Same(wikiChild, sheet.Children[2], "工作表排在原有子节点的前面")
+
+	s.False(bitable.CanDownload)
+	s.True(bitable.SplitBySheet)
+	s.Require().Len(bitable.Children, 2)
+	s.Equal("tbl1", bitable.Children[0].SubID)
+	s.Equal("数据表2", bitable.Children[1].Name)
+
+	s.True(xlsx.CanDownload)
+	s.False(xlsx.SplitBySheet)
+	s.Empty(xlsx.Children)
+
+	// 按工作表拆分的表格作为目录
+	infoList := documentNodesToInfoList([]*DocumentNode{folder}, "/tmp")
+	var paths []string
+	for _, di := range infoList {
+		paths = append(paths, di.FilePath)
+	}
+	s.Equal([]string{
+		"/tmp/目录",
+		"/tmp/目录/表格",
+		"/tmp/目录/表格/a_b.csv",
+		"/tmp/目录/表格/第二页.csv",
+		"/tmp/目录/表格/子文档.",
+		"/tmp/目录/多维表格",
+		"/tmp/目录/多维表格/数据表1.csv",
+		"/tmp/目录/多维表格/数据表2.csv",
+		"/tmp/目录/xlsx.xlsx",
+	}, paths)
+}
+
+func (s *DocumentSheetTestSuite) TestSplitSheets_error() {
+	checkAuthenticated()
+	gock.New("https://open.feishu.cn").
+		Get("/open-apis/bitable/v1/apps/bascnToken/tables").
+		Reply(400).
+		AddHeader(larkcore.HttpHeaderKeyLogId, "xyz").
+		JSON(`{"code": 91402, "msg": "NOTEXIST"}`)
+	bitable := &DocumentNode{DocumentInfo: DocumentInfo{Name: "多维表格", Type: constant.DocTypeBitable, Token: "bascnToken", FileExtension: constant.FileExtCSV, CanDownload: true}}
+	err := s.client.splitSheets(context.Background(), []*DocumentNode{{Children: []*DocumentNode{bitable}}})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "查询工作表失败: 多维表格")
+	s.True(gock.IsDone())
+	s.True(bitable.CanDownload)
+}
+
+func TestNeedSplitBySheet(t *testing.T) {
+	tests := []struct {
+		name string
+		di   DocumentInfo
+		want bool
+	}{
+		{"电子表格csv", DocumentInfo{Type: constant.DocTypeSheet, FileExtension: constant.FileExtCSV, CanDownload: true}, true},
+		{"多维表格csv", DocumentInfo{Type: constant.DocTypeBitable, FileExtension: constant.FileExtCSV, CanDownload: true}, true},
+		{"电子表格xlsx", DocumentInfo{Type: constant.DocTypeSheet, FileExtension: constant.FileExtXlsx, CanDownload: true}, false},
+		{"直接下载的文件", DocumentInfo{Type: constant.DocTypeSheet, FileExtension: constant.FileExtCSV, CanDownload: true, DownloadDirectly: true}, false},
+		{"不可下载", DocumentInfo{Type: constant.DocTypeSheet, FileExtension: constant.FileExtCSV}, false},
+		{"其他类型", DocumentInfo{Type: constant.DocTypeDocx, FileExtension: constant.FileExtCSV, CanDownload: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, needSplitBySheet(&tt.di))
+		})
+	}
+}
@@ -15,15 +15,20 @@
 package feishu
 
 import (
+	"context"
 	"errors"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/xlab/treeprint"
 
+	"github.com/acyumi/xdoc/component/app"
 	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
 )
 
 func TestDocumentInfo_GetFileName(t *testing.T) {
@@ -74,11 +79,17 @@ func TestCleanName(t *testing.T) {
 			input:    "test\\/:*?\"<>|",
 			expected: "test_________",
 		},
+		{
+			name:     "Test with reserved name",
+			input:    "con",
+			expected: "con_",
+		},
 	}
 
+	c := &ClientImpl{Args: &Args{}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := cleanName(tt.input)
+			actual := c.cleanName(tt.input)
 			require.Equal(t, tt.expected, actual, tt.name)
 		})
 	}
@@ -184,6 +195,66 @@ func TestSetFileExtension(t *testing.T) {
 	}
 }
 
+func TestSetFileExtension_convertLocally(t *testing.T) {
+	args := &Args{FileExtensions: map[constant.DocType]constant.FileExt{
+		constant.DocTypeDocx:    constant.FileExtMarkdown,
+		constant.DocTypeDoc:     constant.FileExtMarkdown,
+		constant.DocTypeBitable: constant.FileExtJSON,
+		constant.DocTypeSheet:   constant.FileExtJSON,
+	}}
+	tests := []struct {
+		name            string
+		documentNode    DocumentNode
+		wantExt         constant.FileExt
+		wantCanDownload bool
+		wantConvert     bool
+	}{
+		{
+			name:            "新版文档在本地转换",
+			documentNode:    DocumentNode{DocumentInfo: DocumentInfo{Type: constant.DocTypeDocx}},
+			wantExt:         constant.FileExtMarkdown,
+			wantCanDownload: true,
+			wantConvert:     true,
+		},
+		{
+			name:            "旧版文档不支持",
+			documentNode:    DocumentNode{DocumentInfo: DocumentInfo{Type: constant.DocTypeDoc}},
+			wantExt:         constant.FileExtMarkdown,
+			wantCanDownload: false,
+			wantConvert:     false,
+		},
+		{
+			name:            "上传的docx文件直接下载",
+			documentNode:    DocumentNode{DocumentInfo: DocumentInfo{Type: constant.DocTypeFile, Name: "example.docx"}},
+			wantExt:         constant.FileExtDocx,
+			wantCanDownload: true,
+			wantConvert:     false,
+		},
+		{
+			name:            "多维表格在本地转换为json",
+			documentNode:    DocumentNode{DocumentInfo: DocumentInfo{Type: constant.DocTypeBitable}},
+			wantExt:         constant.FileExtJSON,
+			wantCanDownload: true,
+			wantConvert:     true,
+		},
+		{
+			name:            "电子表格不支持json",
+			documentNode:    DocumentNode{DocumentInfo: DocumentInfo{Type: constant.DocTypeSheet}},
+			wantExt:         constant.FileExtJSON,
+			wantCanDownload: false,
+			wantConvert:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFileExtension(&tt.documentNode, args)
+			require.Equal(t, tt.wantExt, tt.documentNode.FileExtension)
+			require.Equal(t, tt.wantCanDownload, tt.documentNode.CanDownload)
+			require.Equal(t, tt.wantConvert, tt.documentNode.ConvertLocally)
+		})
+	}
+}
+
 func Test_deduplication(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1274,6 +1345,106 @@ func TestPrintTree(t *testing.T) {
     ├─ folder3.docx
     └─ folder3
         └─ file4.docx
+`,
+		},
+		{
+			name: "Test with skipped file",
+			documentNodes: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{
+						Name:          "test1",
+						Type:          constant.DocTypeDocx,
+						FileExtension: constant.FileExtDocx,
+						CanDownload:   true,
+						Status:        progress.StatusSkipped,
+					},
+				},
+				{
+					DocumentInfo: DocumentInfo{
+						Name:          "test2",
+						Type:          constant.DocTypeDocx,
+						FileExtension: constant.FileExtDocx,
+						CanDownload:   true,
+					},
+				},
+			},
+			expectedOutput: `
+/tmp
+├─ test1.docx（未变更）
+└─ test2.docx
+`,
+		},
+		{
+			name: "Test with excluded file",
+			documentNodes: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{
+						Name: "folder1",
+						Type: constant.DocTypeFolder,
+					},
+					Children: []*DocumentNode{
+						{
+							DocumentInfo: DocumentInfo{
+								Name:          "test1",
+								Type:          constant.DocTypeDocx,
+								FileExtension: constant.FileExtDocx,
+								CanDownload:   true,
+								Excluded:      true,
+							},
+						},
+						{
+							DocumentInfo: DocumentInfo{
+								Name:          "test2",
+								Type:          constant.DocTypeDocx,
+								FileExtension: constant.FileExtDocx,
+								CanDownload:   true,
+							},
+						},
+					},
+				},
+				{
+					DocumentInfo: DocumentInfo{
+						Name:     "folder2",
+						Type:     constant.DocTypeFolder,
+						Excluded: true,
+					},
+				},
+			},
+			expectedOutput: `
+/tmp
+├─ folder1
+│   ├─ test1.docx（已排除）
+│   └─ test2.docx
+└─ folder2（已排除）
+`,
+		},
+		{
+			name: "Test with sheet split by sheets",
+			documentNodes: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{
+						Name:          "表格",
+						Type:          constant.DocTypeSheet,
+						FileExtension: constant.FileExtCSV,
+						SplitBySheet:  true,
+					},
+					Children: []*DocumentNode{
+						{
+							DocumentInfo: DocumentInfo{
+								Name:          "工作表1",
+								Type:          constant.DocTypeSheet,
+								FileExtension: constant.FileExtCSV,
+								CanDownload:   true,
+								SubID:         "sheet1",
+							},
+						},
+					},
+				},
+			},
+			expectedOutput: `
+/tmp
+└─ 表格
+    └─ 工作表1.csv
 `,
 		},
 	}
@@ -1291,87 +1462,6 @@ func TestPrintTree(t *testing.T) {
 	}
 }
 
-func TestGetName(t *testing.T) {
-	tests := []struct {
-		name                  string
-		inputName             string
-		inputType             constant.DocType
-		duplicateNameIndexMap map[string]int
-		expected              string
-	}{
-		{
-			name:                  "Test with normal name",
-			inputName:             "test",
-			inputType:             "docx",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "test",
-		},
-		{
-			name:                  "Test with duplicate name",
-			inputName:             "test",
-			inputType:             "docx",
-			duplicateNameIndexMap: map[string]int{"test": 0},
-			expected:              "test1",
-		},
-		{
-			name:                  "Test with docx type empty name",
-			inputName:             "",
-			inputType:             "docx",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名新版文档1",
-		},
-		{
-			name:                  "Test with doc type empty name",
-			inputName:             "",
-			inputType:             "doc",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名旧版文档1",
-		},
-		{
-			name:                  "Test with sheet type empty name",
-			inputName:             "",
-			inputType:             "sheet",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名电子表格1",
-		},
-		{
-			name:                  "Test with bitable type empty name",
-			inputName:             "",
-			inputType:             "bitable",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名多维表格1",
-		},
-		{
-			name:                  "Test with mindnote type empty name",
-			inputName:             "",
-			inputType:             "mindnote",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名思维笔记1",
-		},
-		{
-			name:                  "Test with slides type empty name",
-			inputName:             "",
-			inputType:             "slides",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名幻灯片1",
-		},
-		{
-			name:                  "Test with unknown type empty name",
-			inputName:             "",
-			inputType:             "unknown",
-			duplicateNameIndexMap: map[string]int{},
-			expected:              "未命名飞书文档1",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			actual := getName(tt.inputName, tt.inputType, tt.duplicateNameIndexMap)
-			require.Equal(t, tt.expected, actual)
-		})
-	}
-}
-
 func TestDoExportAndDownload(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -1395,7 +1485,7 @@ func TestDoExportAndDownload(t *testing.T) {
 			name: "任务运行失败应关闭资源",
 			setupMock: func(m *MockTask) {
 				m.EXPECT().Validate().Return(nil).Once()
-				m.EXPECT().Run().Return(errors.New("network error")).Once()
+				m.EXPECT().Run(mock.Anything).Return(errors.New("network error")).Once()
 				m.EXPECT().Close().Return().Once()
 			},
 			wantErr:         `network error`,
@@ -1407,7 +1497,7 @@ func TestDoExportAndDownload(t *testing.T) {
 			name: "成功执行应正常关闭",
 			setupMock: func(m *MockTask) {
 				m.EXPECT().Validate().Return(nil).Once()
-				m.EXPECT().Run().Return(nil).Once()
+				m.EXPECT().Run(mock.Anything).Return(nil).Once()
 				m.EXPECT().Close().Return().Once()
 			},
 			wantErr:         ``,
@@ -1424,7 +1514,7 @@ func TestDoExportAndDownload(t *testing.T) {
 			tt.setupMock(mt)
 
 			// 执行测试
-			err := doExportAndDownload(mt)
+			err := doExportAndDownload(context.Background(), mt)
 
 			// 断言错误
 			if err != nil {
@@ -1438,7 +1528,7 @@ func TestDoExportAndDownload(t *testing.T) {
 				mt.AssertNotCalled(t, "Validate")
 			}
 			if tt.expectRan {
-				mt.AssertCalled(t, "Run")
+				mt.AssertCalled(t, "Run", mock.Anything)
 			} else {
 				mt.AssertNotCalled(t, "Run")
 			}
@@ -1450,3 +1540,144 @@ func TestDoExportAndDownload(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveAndLoadDocumentTree(t *testing.T) {
+	useMemMapFs()
+	// 文件不存在时返回nil
+	dns, err := loadDocumentTree("/tmp/docs")
+	require.NoError(t, err)
+	require.Nil(t, dns)
+
+	want := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{
+				Name:         "root",
+				Type:         constant.DocTypeFolder,
+				Token:        "token0",
+				ModifiedTime: "1642402428",
+				FilePath:     "/tmp/docs/root",
+			},
+			Children: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{
+						Name:          "file1",
+						Type:          constant.DocTypeDocx,
+						Token:         "token1",
+						FileExtension: constant.FileExtDocx,
+						CanDownload:   true,
+						ModifiedTime:  "1642402429",
+						FilePath:      "/tmp/docs/root/file1.docx",
+						Status:        progress.StatusCompleted,
+					},
+				},
+			},
+		},
+	}
+	err = saveDocumentTree(want, "/tmp/docs")
+	require.NoError(t, err)
+	data, err := app.Fs.ReadFile("/tmp/docs/document-tree.json")
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"modifiedTime": "1642402429"`)
+	require.Contains(t, string(data), `"status": "c"`)
+
+	got, err := loadDocumentTree("/tmp/docs")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// 文件内容不合法
+	err = app.Fs.WriteFile("/tmp/docs/document-tree.json", []byte("{"), 0o644)
+	require.NoError(t, err)
+	_, err = loadDocumentTree("/tmp/docs")
+	require.ErrorContains(t, err, "解析文件失败: /tmp/docs/document-tree.json")
+
+	// 只读文件系统写入失败
+	useFs(&afero.Afero{Fs: afero.NewReadOnlyFs(afero.NewMemMapFs())})
+	err = saveDocumentTree(want, "/tmp/docs")
+	require.Error(t, err)
+	useMemMapFs()
+}
+
+func TestMarkUnchanged(t *testing.T) {
+	newInfo := func(token, modifiedTime string, status progress.Status) DocumentInfo {
+		return DocumentInfo{
+			Name:          "file",
+			Type:          constant.DocTypeDocx,
+			Token:         token,
+			FileExtension: constant.FileExtDocx,
+			CanDownload:   true,
+			ModifiedTime:  modifiedTime,
+			FilePath:      "/tmp/docs/file.docx",
+			Status:        status,
+		}
+	}
+	tests := []struct {
+		name       string
+		current    DocumentInfo
+		previous   DocumentInfo
+		fileExists bool
+		wantCount  int
+		wantStatus progress.Status
+	}{
+		{
+			name:       "未变更且文件存在",
+			current:    newInfo("token", "1", ""),
+			previous:   newInfo("token", "1", progress.StatusCompleted),
+			fileExists: true,
+			wantCount:  1,
+			wantStatus: progress.StatusSkipped,
+		},
+		{
+			name:       "上一次也是跳过",
+			current:    newInfo("token", "1", ""),
+			previous:   newInfo("token", "1", progress.StatusSkipped),
+			fileExists: true,
+			wantCount:  1,
+			wantStatus: progress.StatusSkipped,
+		},
+		{
+			name:       "编辑时间变更",
+			current:    newInfo("token", "2", ""),
+			previous:   newInfo("token", "1", progress.StatusCompleted),
+			fileExists: true,
+		},
+		{
+			name:       "token变更",
+			current:    newInfo("token2", "1", ""),
+			previous:   newInfo("token", "1", progress.StatusCompleted),
+			fileExists: true,
+		},
+		{
+			name:       "没有编辑时间",
+			current:    newInfo("token", "", ""),
+			previous:   newInfo("token", "", progress.StatusCompleted),
+			fileExists: true,
+		},
+		{
+			name:       "上一次下载失败",
+			current:    newInfo("token", "1", ""),
+			previous:   newInfo("token", "1", progress.StatusFailed),
+			fileExists: true,
+		},
+		{
+			name:       "本地文件已删除",
+			current:    newInfo("token", "1", ""),
+			previous:   newInfo("token", "1", progress.StatusCompleted),
+			fileExists: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemMapFs()
+			if tt.fileExists {
+				err := app.Fs.WriteFile(tt.current.FilePath, []byte("test"), 0o644)
+				require.NoError(t, err)
+			}
+			dns := []*DocumentNode{{DocumentInfo: tt.current}}
+			previous := []*DocumentNode{{DocumentInfo: tt.previous}}
+			count, err := markUnchanged(dns, previous)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCount, count)
+			require.Equal(t, tt.wantStatus, dns[0].Status)
+		})
+	}
+}
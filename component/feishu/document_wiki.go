@@ -16,7 +16,7 @@ package feishu
 
 import (
 	"context"
-	"time"
+	"os"
 
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	larkwiki "github.com/larksuite/oapi-sdk-go/v3/service/wiki/v2"
@@ -25,12 +25,12 @@ import (
 	"github.com/acyumi/xdoc/component/constant"
 )
 
-func (c *ClientImpl) QueryWikiDocuments(token string) (*DocumentNode, error) {
+func (c *ClientImpl) QueryWikiDocuments(ctx context.Context, token string) (*DocumentNode, error) {
 	// 创建请求对象
 	req := larkwiki.NewGetNodeSpaceReqBuilder().Token(token).ObjType(`wiki`).Build()
 	// 发起请求
 	resp, err := SendWithRetry(func(_ int) (*larkwiki.GetNodeSpaceResp, error) {
-		return c.WikiGetNode(context.Background(), req)
+		return c.WikiGetNode(ctx, req)
 	})
 	// 处理错误
 	if err != nil {
@@ -41,7 +41,7 @@ func (c *ClientImpl) QueryWikiDocuments(token string) (*DocumentNode, error) {
 	dn := c.wikiNodeToDocumentNode(node)
 
 	hasChild := larkcore.BoolValue(node.HasChild)
-	err = c.fetchWikiDescendant(dn, hasChild, dn.SpaceID, dn.NodeToken, "")
+	err = c.fetchWikiDescendant(ctx, dn, hasChild, dn.SpaceID, dn.NodeToken, "")
 	if err != nil {
 		return nil, oops.Wrap(err)
 	}
@@ -49,19 +49,19 @@ func (c *ClientImpl) QueryWikiDocuments(token string) (*DocumentNode, error) {
 	return dn, nil
 }
 
-func (c *ClientImpl) QueryWikiSpaceDocuments(spaceID string) (*DocumentNode, error) {
+func (c *ClientImpl) QueryWikiSpaceDocuments(ctx context.Context, spaceID string) (*DocumentNode, error) {
 	req := larkwiki.NewGetSpaceReqBuilder().SpaceId(spaceID).Lang(`zh`).Build()
 	// 发起请求
 	resp, err := SendWithRetry(func(_ int) (*larkwiki.GetSpaceResp, error) {
-		return c.WikiGetSpace(context.Background(), req)
+		return c.WikiGetSpace(ctx, req)
 	})
 	// 处理错误
 	if err != nil {
 		return nil, oops.Wrap(err)
 	}
-	name := larkcore.StringValue(resp.Data.Space.Name)
+	name := c.cleanName(larkcore.StringValue(resp.Data.Space.Name))
 	var dn = &DocumentNode{DocumentInfo: DocumentInfo{Name: name, SpaceID: spaceID, Token: spaceID, Type: constant.DocTypeFolder}}
-	err = c.fetchWikiDescendant(dn, true, dn.SpaceID, dn.NodeToken, "")
+	err = c.fetchWikiDescendant(ctx, dn, true, dn.SpaceID, dn.NodeToken, "")
 	if err != nil {
 		return nil, oops.Wrap(err)
 	}
@@ -69,15 +69,22 @@ func (c *ClientImpl) QueryWikiSpaceDocuments(spaceID string) (*DocumentNode, err
 	return dn, nil
 }
 
-func (c *ClientImpl) fetchWikiDescendant(dn *DocumentNode, hasChild bool,
+// fetchWikiDescendant 并发地广度优先查询知识库节点的所有子孙节点，从pageToken指定的页开始查询。
+func (c *ClientImpl) fetchWikiDescendant(ctx context.Context, dn *DocumentNode, hasChild bool,
 	spaceID, parentNodeToken, pageToken string) error {
 	if !hasChild {
 		return nil
 	}
-	timeout := time.Minute
-	if time.Since(c.Args.StartTime) > time.Minute {
-		return oops.Errorf("获取知识库文档信息超时: %s", timeout.String())
-	}
+	cr := newCrawler(c.Args.LogOutput(os.Stdout))
+	cr.push(func(ctx context.Context) error {
+		return c.fetchWikiChildren(ctx, cr, dn, spaceID, parentNodeToken, pageToken)
+	})
+	return cr.run(ctx, discoverWorkers)
+}
+
+// fetchWikiChildren 查询知识库节点的一页子节点，有子节点的子节点和下一页作为新的遍历任务。
+func (c *ClientImpl) fetchWikiChildren(ctx context.Context, cr *crawler, dn *DocumentNode,
+	spaceID, parentNodeToken, pageToken string) error {
 	// 调用【获取知识空间子节点列表】接口
 	// https://open.feishu.cn/document/server-docs/docs/wiki-v2/space-node/list
 	// 创建请求对象
@@ -89,33 +96,32 @@ func (c *ClientImpl) fetchWikiDescendant(dn *DocumentNode, hasChild bool,
 		Build()
 	// 发起请求
 	resp, err := SendWithRetry(func(_ int) (*larkwiki.ListSpaceNodeResp, error) {
-		return c.WikiNodeList(context.Background(), req)
+		return c.WikiNodeList(ctx, req)
 	})
 	// 处理错误
 	if err != nil {
 		return oops.Wrap(err)
 	}
 
-	// 查到的子节点添加到doc中，然后再递归查询子节点
+	// 查到的子节点添加到doc中，有子节点的再添加遍历任务
 	for _, node := range resp.Data.Items {
 		// 先判断文档类型，看是否可以下载
 		child := c.wikiNodeToDocumentNode(node)
 		dn.Children = append(dn.Children, child)
 		// 然后再判断有没有子节点
-		hasChild := larkcore.BoolValue(node.HasChild)
-		err = c.fetchWikiDescendant(child, hasChild, spaceID, child.NodeToken, "")
-		if err == nil {
-			continue
+		if larkcore.BoolValue(node.HasChild) {
+			cr.push(func(ctx context.Context) error {
+				return c.fetchWikiChildren(ctx, cr, child, spaceID, child.NodeToken, "")
+			})
 		}
-		return oops.Wrap(err)
 	}
+	cr.discover(len(resp.Data.Items))
 
 	if larkcore.BoolValue(resp.Data.HasMore) {
-		pageToken = larkcore.StringValue(resp.Data.PageToken)
-		err = c.fetchWikiDescendant(dn, true, spaceID, parentNodeToken, pageToken)
-		if err != nil {
-			return oops.Wrap(err)
-		}
+		nextPageToken := larkcore.StringValue(resp.Data.PageToken)
+		cr.push(func(ctx context.Context) error {
+			return c.fetchWikiChildren(ctx, cr, dn, spaceID, parentNodeToken, nextPageToken)
+		})
 	}
 
 	return nil
@@ -124,9 +130,12 @@ func (c *ClientImpl) fetchWikiDescendant(dn *DocumentNode, hasChild bool,
 func (c *ClientImpl) wikiNodeToDocumentNode(node *larkwiki.Node) *DocumentNode {
 	var dn = &DocumentNode{}
 	dn.Name = larkcore.StringValue(node.Title)
-	dn.Name = cleanName(dn.Name)
+	dn.Name = c.cleanName(dn.Name)
 	dn.Type = constant.DocType(larkcore.StringValue(node.ObjType))
 	dn.Token = larkcore.StringValue(node.ObjToken)
+	dn.ModifiedTime = larkcore.StringValue(node.ObjEditTime)
+	dn.CreatedTime = larkcore.StringValue(node.ObjCreateTime)
+	dn.OwnerID = larkcore.StringValue(node.Owner)
 	setFileExtension(dn, c.Args)
 	// 取节点token
 	dn.NodeToken = larkcore.StringValue(node.NodeToken)
@@ -15,6 +15,7 @@
 package feishu
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -41,6 +42,8 @@ type DocumentWikiTestSuite struct {
 func (s *DocumentWikiTestSuite) SetupSuite() {
 	initBackOff = testInitBackOff
 	cleanSleep()
+	// 单协程读取文档树，保证请求顺序固定
+	discoverWorkers = 1
 }
 
 func (s *DocumentWikiTestSuite) SetupTest() {
@@ -58,6 +61,7 @@ func (s *DocumentWikiTestSuite) TearDownTest() {
 
 func (s *DocumentWikiTestSuite) TearDownSuite() {
 	initBackOff = initExponentialBackOff
+	discoverWorkers = 5
 }
 
 func (s *DocumentWikiTestSuite) TestQueryWikiDocuments() {
@@ -180,6 +184,9 @@ func (s *DocumentWikiTestSuite) TestQueryWikiDocuments() {
 					URL:              "",
 					NodeToken:        "wikcnKQ1k3pxxxxxx8Vabcef",
 					SpaceID:          "6946843325487912356",
+					ModifiedTime:     "1642402428",
+					CreatedTime:      "1642402428",
+					OwnerID:          "ou_xxxxx",
 					FilePath:         "",
 				},
 				Children: []*DocumentNode{
@@ -194,6 +201,9 @@ func (s *DocumentWikiTestSuite) TestQueryWikiDocuments() {
 							URL:              "",
 							NodeToken:        "wikcnKQ1k3pxxxxxx8Vabceg",
 							SpaceID:          "6946843325487912356",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							FilePath:         "",
 						},
 					},
@@ -304,6 +314,9 @@ func (s *DocumentWikiTestSuite) TestQueryWikiDocuments() {
 					URL:              "",
 					NodeToken:        "wikcnKQ1k3pxxxxxx8Vabcef",
 					SpaceID:          "6946843325487912356",
+					ModifiedTime:     "1642402428",
+					CreatedTime:      "1642402428",
+					OwnerID:          "ou_xxxxx",
 					FilePath:         "",
 				},
 			},
@@ -312,7 +325,7 @@ func (s *DocumentWikiTestSuite) TestQueryWikiDocuments() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.name, tt.token)
-			actual, err := s.client.QueryWikiDocuments(tt.token)
+			actual, err := s.client.QueryWikiDocuments(context.Background(), tt.token)
 			tt.teardownMock(tt.name, tt.token)
 			if err != nil || tt.wantError != "" {
 				s.Require().EqualError(err, tt.wantError, tt.name)
@@ -437,6 +450,9 @@ func (s *DocumentWikiTestSuite) TestQueryWikiSpaceDocuments() {
 							URL:              "",
 							NodeToken:        "wikcnKQ1k3pxxxxxx8Vabceg",
 							SpaceID:          "6946843325487912366",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							FilePath:         "",
 						},
 					},
@@ -546,7 +562,7 @@ func (s *DocumentWikiTestSuite) TestQueryWikiSpaceDocuments() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.name, tt.spaceID)
-			actual, err := s.client.QueryWikiSpaceDocuments(tt.spaceID)
+			actual, err := s.client.QueryWikiSpaceDocuments(context.Background(), tt.spaceID)
 			tt.teardownMock(tt.name, tt.spaceID)
 			if err != nil || tt.wantError != "" {
 				s.Require().EqualError(err, tt.wantError, tt.name)
@@ -564,6 +580,7 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 		spaceID         string
 		parentNodeToken string
 		pageToken       string
+		cancel          bool
 		setupMock       func(name string, dn *DocumentNode, hasChild bool, spaceID, parentNodeToken, pageToken string)
 		teardownMock    func(name string)
 		want            *DocumentNode
@@ -608,7 +625,7 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 			wantError: "",
 		},
 		{
-			name: "获取超时",
+			name: "读取被中断",
 			dn: &DocumentNode{
 				DocumentInfo: DocumentInfo{
 					Token:            "Token",
@@ -625,11 +642,10 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 			spaceID:         "space_id",
 			parentNodeToken: "Token",
 			pageToken:       "PageToken",
+			cancel:          true,
 			setupMock: func(name string, dn *DocumentNode, hasChild bool, spaceID, parentNodeToken, pageToken string) {
-				s.client.Args.StartTime = time.Now().Add(-time.Hour)
 			},
 			teardownMock: func(name string) {
-				s.client.Args.StartTime = time.Now()
 			},
 			want: &DocumentNode{
 				DocumentInfo: DocumentInfo{
@@ -645,7 +661,7 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 					FilePath:         "",
 				},
 			},
-			wantError: "获取知识库文档信息超时: 1m0s",
+			wantError: "context canceled",
 		},
 		{
 			name: "请求成功，没有递归",
@@ -788,6 +804,9 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 							FileExtension:    "docx",
 							FilePath:         "",
 							SpaceID:          "6946843325487912356",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							CanDownload:      true,
 							DownloadDirectly: false,
 						},
@@ -891,6 +910,9 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 							FileExtension:    "docx",
 							FilePath:         "",
 							SpaceID:          "6946843325487912356",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							CanDownload:      true,
 							DownloadDirectly: false,
 						},
@@ -1025,6 +1047,9 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 							URL:              "",
 							NodeToken:        "wikcnKQ1k3pxxxxxx8Vabcef",
 							SpaceID:          "6946843325487912356",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							FilePath:         "",
 						},
 					},
@@ -1150,6 +1175,9 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 							URL:              "",
 							NodeToken:        "wikcnKQ1k3pxxxxxx8Vabcef",
 							SpaceID:          "6946843325487912356",
+							ModifiedTime:     "1642402428",
+							CreatedTime:      "1642402428",
+							OwnerID:          "ou_xxxxx",
 							FilePath:         "",
 						},
 					},
@@ -1161,7 +1189,12 @@ func (s *DocumentWikiTestSuite) Test_fetchWikiDescendant() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.name, tt.dn, tt.hasChild, tt.spaceID, tt.parentNodeToken, tt.pageToken)
-			err := s.client.fetchWikiDescendant(tt.dn, tt.hasChild, tt.spaceID, tt.parentNodeToken, tt.pageToken)
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+			err := s.client.fetchWikiDescendant(ctx, tt.dn, tt.hasChild, tt.spaceID, tt.parentNodeToken, tt.pageToken)
 			tt.teardownMock(tt.name)
 			if err != nil || tt.wantError != "" {
 				s.Require().Error(err, tt.name)
@@ -1228,6 +1261,9 @@ func (s *DocumentWikiTestSuite) TestClientImpl_wikiNodeToDocumentNode() {
 					DownloadDirectly: false,
 					NodeToken:        "NodeToken",
 					SpaceID:          "SpaceId",
+					ModifiedTime:     "ObjEditTime",
+					CreatedTime:      "ObjCreateTime",
+					OwnerID:          "Owner",
 				},
 			},
 		},
@@ -15,43 +15,51 @@
 package feishu
 
 import (
+	"bytes"
 	"context"
 	"io"
-	"math/rand"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
 	"github.com/samber/oops"
 	"github.com/spf13/cast"
 
 	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
 	"github.com/acyumi/xdoc/component/progress"
 )
 
+// exportPollInterval 查询导出任务结果的间隔。
+const exportPollInterval = 3 * time.Second
+
 type exporter struct {
-	client    Client            //
-	program   progress.IProgram //
-	completed *atomic.Bool      //
+	client  Client            //
+	program progress.IProgram //
+	media   *mediaStore       // 文档中的图片和附件，整个导出过程中按token去重
 }
 
 // doExport 创建导出任务。
-func (e *exporter) doExport(di *DocumentInfo) (string, error) {
+func (e *exporter) doExport(ctx context.Context, di *DocumentInfo) (string, error) {
 	// 发送请求创建导出任务
-	exportTask := larkdrive.NewExportTaskBuilder().
+	builder := larkdrive.NewExportTaskBuilder().
 		FileExtension(string(di.FileExtension)).
 		Token(di.Token).
-		Type(string(di.Type)).
-		Build()
+		Type(string(di.Type))
+	if di.SubID != "" {
+		// 导出为csv时，需要指定导出的工作表或数据表
+		builder.SubId(di.SubID)
+	}
+	exportTask := builder.Build()
 	req := larkdrive.NewCreateExportTaskReqBuilder().
 		ExportTask(exportTask).
 		Build()
 	req.ExportTask = exportTask
 	resp, err := SendWithRetry(func(count int) (*larkdrive.CreateExportTaskResp, error) {
 		e.program.Update(di.FilePath, 0, progress.StatusExporting, "请求%d次", count)
-		return e.client.ExportCreate(context.Background(), req)
+		return e.client.ExportCreate(ctx, req)
 	})
 	if err != nil {
 		if resp != nil && !resp.Success() {
@@ -63,16 +71,16 @@ func (e *exporter) doExport(di *DocumentInfo) (string, error) {
 }
 
 // checkExport 查询导出任务结果。
-func (e *exporter) checkExport(di *DocumentInfo, ticket string) (*exportResult, progress.Status, error) {
+func (e *exporter) checkExport(ctx context.Context, di *DocumentInfo, ticket string) (*exportResult, progress.Status, error) {
 	for i := 0; i < 5; i++ {
-		if e.completed.Load() {
+		if ctx.Err() != nil {
 			return nil, progress.StatusInterrupted, nil
 		}
 		// 发送请求查询导出任务结果
 		req := larkdrive.NewGetExportTaskReqBuilder().Ticket(ticket).Token(di.Token).Build()
 		resp, err := SendWithRetry(func(count int) (*larkdrive.GetExportTaskResp, error) {
 			e.program.Update(di.FilePath, 0.10, progress.StatusExporting, "查询%d次", count)
-			return e.client.ExportGet(context.Background(), req)
+			return e.client.ExportGet(ctx, req)
 		})
 		if err != nil {
 			if resp != nil && !resp.Success() {
@@ -93,18 +101,20 @@ func (e *exporter) checkExport(di *DocumentInfo, ticket string) (*exportResult,
 			return nil, progress.StatusFailed, oops.New(strings.ReplaceAll(jobErrorMsg, "\n", " "))
 		}
 		e.program.Update(di.FilePath, 0.10, progress.StatusExporting, "等待完成导出任务")
-		// 随机睡眠1到5秒
-		app.Sleep(time.Second * time.Duration(rand.Intn(4)+1))
+		// 等待一段时间再查询，接口请求频率由客户端限流器控制
+		if err := app.SleepContext(ctx, exportPollInterval); err != nil {
+			return nil, progress.StatusInterrupted, nil
+		}
 	}
 	return nil, progress.StatusFailed, oops.New("经过多次尝试取不到导出任务结果")
 }
 
 // doDownloadExported 下载导出的文件。
-func (e *exporter) doDownloadExported(filePath, fileToken string) (io.Reader, error) {
+func (e *exporter) doDownloadExported(ctx context.Context, filePath, fileToken string) (io.Reader, error) {
 	req := larkdrive.NewDownloadExportTaskReqBuilder().FileToken(fileToken).Build()
 	resp, err := SendWithRetry(func(count int) (*larkdrive.DownloadExportTaskResp, error) {
 		e.program.Update(filePath, 0.18, progress.StatusDownloading, "请求%d次", count)
-		return e.client.ExportDownload(context.Background(), req)
+		return e.client.ExportDownload(ctx, req)
 	})
 	if err != nil {
 		if resp != nil && !resp.Success() {
@@ -116,11 +126,11 @@ func (e *exporter) doDownloadExported(filePath, fileToken string) (io.Reader, er
 }
 
 // doDownloadDirectly 不需要经过导出操作，直接下载文件。
-func (e *exporter) doDownloadDirectly(filePath, fileToken string) (io.Reader, int64, error) {
+func (e *exporter) doDownloadDirectly(ctx context.Context, filePath, fileToken string) (io.Reader, int64, error) {
 	req := larkdrive.NewDownloadFileReqBuilder().FileToken(fileToken).Build()
 	resp, err := SendWithRetry(func(count int) (*larkdrive.DownloadFileResp, error) {
 		e.program.Update(filePath, 0.18, progress.StatusDownloading, "请求%d次", count)
-		return e.client.DriveDownload(context.Background(), req)
+		return e.client.DriveDownload(ctx, req)
 	})
 	if err != nil {
 		if resp != nil && !resp.Success() {
@@ -131,3 +141,54 @@ func (e *exporter) doDownloadDirectly(filePath, fileToken string) (io.Reader, in
 	contentLength := resp.Header.Get("Content-Length")
 	return resp.File, cast.ToInt64(contentLength), nil
 }
+
+// doConvert 不需要经过导出操作，读取文档内容后在本地转换。
+// 目前支持将新版文档docx转换为md，将多维表格bitable转换为json。
+func (e *exporter) doConvert(ctx context.Context, di *DocumentInfo) (io.Reader, int64, error) {
+	var data []byte
+	var err error
+	switch di.Type {
+	case constant.DocTypeBitable:
+		data, err = e.convertBitable(ctx, di)
+	default:
+		data, err = e.convertDocx(ctx, di)
+	}
+	if err != nil {
+		return nil, 0, oops.Wrap(err)
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// convertDocx 通过文档块将新版文档docx转换为md，文档中的图片和附件下载到文档所在目录的 assets 目录中。
+func (e *exporter) convertDocx(ctx context.Context, di *DocumentInfo) ([]byte, error) {
+	var blocks []*larkdocx.Block
+	var pageToken string
+	for {
+		req := larkdocx.NewListDocumentBlockReqBuilder().
+			DocumentId(di.Token).
+			PageSize(500).
+			PageToken(pageToken).
+			DocumentRevisionId(-1).
+			Build()
+		resp, err := SendWithRetry(func(count int) (*larkdocx.ListDocumentBlockResp, error) {
+			e.program.Update(di.FilePath, 0.18, progress.StatusDownloading, "读取文档块%d个, 请求%d次", len(blocks), count)
+			return e.client.DocxBlockList(ctx, req)
+		})
+		if err != nil {
+			if resp != nil && !resp.Success() {
+				return nil, oops.New(toErrMsg(resp, "获取文档所有块"))
+			}
+			return nil, oops.Wrap(err)
+		}
+		blocks = append(blocks, resp.Data.Items...)
+		if !larkcore.BoolValue(resp.Data.HasMore) {
+			break
+		}
+		pageToken = larkcore.StringValue(resp.Data.PageToken)
+	}
+	media, err := e.downloadMedia(ctx, di, collectMedia(blocks))
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	return renderMarkdown(blocks, media), nil
+}
@@ -16,13 +16,14 @@ package feishu
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
-	"sync/atomic"
 	"testing"
 
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
 	"github.com/samber/oops"
 	"github.com/stretchr/testify/mock"
@@ -43,13 +44,17 @@ type exporterTestSuite struct {
 	mockProgram *MockProgram
 }
 
+func (s *exporterTestSuite) SetupSuite() {
+	cleanSleep()
+}
+
 func (s *exporterTestSuite) SetupTest() {
 	s.mockClient = &MockClient{}
 	s.mockProgram = &MockProgram{}
 	s.task = exporter{
-		client:    s.mockClient, // 模拟Client
-		program:   s.mockProgram,
-		completed: &atomic.Bool{},
+		client:  s.mockClient, // 模拟Client
+		program: s.mockProgram,
+		media:   &mediaStore{},
 	}
 }
 
@@ -89,6 +94,31 @@ func (s *exporterTestSuite) Test_exporter_doExport() {
 			expectedTicket: "export_ticket_123",
 			expectedError:  nil,
 		},
+		{
+			name: "成功创建导出任务[按工作表导出csv]",
+			di: &DocumentInfo{
+				Token:         "sheet_token",
+				Type:          constant.DocTypeSheet,
+				FileExtension: constant.FileExtCSV,
+				SubID:         "sheet_id",
+			},
+			setupMock: func(di *DocumentInfo) {
+				s.mockProgram.EXPECT().Update(mock.Anything, 0.0, progress.StatusExporting, "请求%d次", mock.Anything).Return().Once()
+				s.mockClient.EXPECT().ExportCreate(
+					mock.Anything,
+					mock.MatchedBy(func(req *larkdrive.CreateExportTaskReq) bool {
+						return larkcore.StringValue(req.ExportTask.Token) == di.Token &&
+							larkcore.StringValue(req.ExportTask.SubId) == di.SubID
+					}),
+				).Return(&larkdrive.CreateExportTaskResp{
+					Data: &larkdrive.CreateExportTaskRespData{
+						Ticket: larkcore.StringPtr("export_ticket_456"),
+					},
+				}, nil).Once()
+			},
+			expectedTicket: "export_ticket_456",
+			expectedError:  nil,
+		},
 		{
 			name: "客户端返回错误",
 			di: &DocumentInfo{
@@ -163,7 +193,7 @@ func (s *exporterTestSuite) Test_exporter_doExport() {
 			tt.setupMock(tt.di)
 
 			// 执行测试
-			ticket, err := s.task.doExport(tt.di)
+			ticket, err := s.task.doExport(context.Background(), tt.di)
 
 			// 验证结果
 			if tt.expectedError != nil {
@@ -185,7 +215,6 @@ func (s *exporterTestSuite) Test_exporter_doExport() {
 
 // TestCheckExport 测试导出任务状态检查。
 func (s *exporterTestSuite) Test_exporter_checkExport() {
-	s.task.completed = &atomic.Bool{}
 	// s.task.queue = make(chan *exportResult, 20)
 	// s.task.wait = make(chan struct{})
 	// 测试用例
@@ -202,6 +231,7 @@ func (s *exporterTestSuite) Test_exporter_checkExport() {
 		name           string
 		di             *DocumentInfo
 		ticket         string
+		interrupted    bool // 查询前取消ctx
 		setupMock      func(di *DocumentInfo, ticket string)
 		expectedResult *exportResult
 		expectedStat   progress.Status
@@ -237,12 +267,11 @@ func (s *exporterTestSuite) Test_exporter_checkExport() {
 			expectedError: nil,
 		},
 		{
-			name:   "直接中断",
-			di:     di,
-			ticket: "doc1_ticket",
-			setupMock: func(di *DocumentInfo, ticket string) {
-				s.task.completed.Store(true)
-			},
+			name:           "直接中断",
+			di:             di,
+			ticket:         "doc1_ticket",
+			interrupted:    true,
+			setupMock:      func(di *DocumentInfo, ticket string) {},
 			expectedResult: nil,
 			expectedStat:   progress.StatusInterrupted,
 			expectedError:  nil,
@@ -348,8 +377,12 @@ func (s *exporterTestSuite) Test_exporter_checkExport() {
 			tt.setupMock(tt.di, tt.ticket)
 
 			// 执行测试
-			result, status, err := s.task.checkExport(tt.di, tt.ticket)
-			defer s.task.completed.Store(false)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.interrupted {
+				cancel()
+			}
+			result, status, err := s.task.checkExport(ctx, tt.di, tt.ticket)
 
 			// 验证结果
 			s.Equal(tt.expectedStat, status)
@@ -372,6 +405,30 @@ func (s *exporterTestSuite) Test_exporter_checkExport() {
 }
 
 // TestDoDownloadExported 测试导出文件下载。
+func (s *exporterTestSuite) Test_exporter_checkExport_interruptedWhileWaiting() {
+	di := &DocumentInfo{Token: "doc1_token", FilePath: "doc1_path"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.mockProgram.EXPECT().Update(di.FilePath, 0.10, progress.StatusExporting, "查询%d次", mock.Anything).Once()
+	req := larkdrive.NewGetExportTaskReqBuilder().Ticket("doc1_ticket").Token(di.Token).Build()
+	s.mockClient.EXPECT().ExportGet(mock.Anything, req).Return(
+		&larkdrive.GetExportTaskResp{
+			Data: &larkdrive.GetExportTaskRespData{
+				Result: &larkdrive.ExportTask{JobStatus: larkcore.IntPtr(1)},
+			},
+		},
+		nil,
+	).Once()
+	// 等待下一次查询期间收到中断信号
+	s.mockProgram.EXPECT().Update(di.FilePath, 0.10, progress.StatusExporting, "等待完成导出任务").Run(
+		func(string, float64, progress.Status, ...any) { cancel() }).Once()
+
+	result, status, err := s.task.checkExport(ctx, di, "doc1_ticket")
+	s.Require().NoError(err)
+	s.Nil(result)
+	s.Equal(progress.StatusInterrupted, status, "不再等待和查询")
+}
+
 func (s *exporterTestSuite) Test_exporter_doDownloadExported() {
 	tests := []struct {
 		name          string
@@ -438,7 +495,7 @@ func (s *exporterTestSuite) Test_exporter_doDownloadExported() {
 		s.Run(tt.name, func() {
 			tt.setupMock(tt.filePath, tt.fileToken)
 
-			file, err := s.task.doDownloadExported("file_path", "file_token")
+			file, err := s.task.doDownloadExported(context.Background(), "file_path", "file_token")
 
 			if tt.expectedError != nil {
 				s.Require().Error(err, tt.name)
@@ -527,7 +584,7 @@ func (s *exporterTestSuite) Test_exporter_doDownloadDirectly() {
 			tt.setupMock(tt.filePath, tt.fileToken)
 
 			// 执行测试
-			file, length, err := s.task.doDownloadDirectly(tt.filePath, tt.fileToken)
+			file, length, err := s.task.doDownloadDirectly(context.Background(), tt.filePath, tt.fileToken)
 
 			// 验证结果
 			if tt.expectedError != nil {
@@ -553,3 +610,88 @@ func (s *exporterTestSuite) Test_exporter_doDownloadDirectly() {
 		})
 	}
 }
+
+func (s *exporterTestSuite) Test_exporter_doConvert() {
+	di := &DocumentInfo{Token: "docx_token", FilePath: "file_path.md"}
+	tests := []struct {
+		name          string
+		setupMock     func()
+		expected      string
+		expectedError error
+	}{
+		{
+			name: "分页读取文档块后转换",
+			setupMock: func() {
+				s.mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, "读取文档块%d个, 请求%d次", mock.Anything, mock.Anything).Return().Twice()
+				req1 := larkdocx.NewListDocumentBlockReqBuilder().DocumentId(di.Token).PageSize(500).PageToken("").DocumentRevisionId(-1).Build()
+				resp1 := &larkdocx.ListDocumentBlockResp{Data: &larkdocx.ListDocumentBlockRespData{
+					Items: []*larkdocx.Block{
+						{
+							BlockId:  larkcore.StringPtr("page"),
+							Children: []string{"text"},
+							Page:     &larkdocx.Text{Elements: []*larkdocx.TextElement{{TextRun: &larkdocx.TextRun{Content: larkcore.StringPtr("标题")}}}},
+						},
+					},
+					PageToken: larkcore.StringPtr("next"),
+					HasMore:   larkcore.BoolPtr(true),
+				}}
+				s.mockClient.EXPECT().DocxBlockList(mock.Anything, req1).Return(resp1, nil).Once()
+				req2 := larkdocx.NewListDocumentBlockReqBuilder().DocumentId(di.Token).PageSize(500).PageToken("next").DocumentRevisionId(-1).Build()
+				resp2 := &larkdocx.ListDocumentBlockResp{Data: &larkdocx.ListDocumentBlockRespData{
+					Items: []*larkdocx.Block{
+						{
+							BlockId: larkcore.StringPtr("text"),
+							Text:    &larkdocx.Text{Elements: []*larkdocx.TextElement{{TextRun: &larkdocx.TextRun{Content: larkcore.StringPtr("正文")}}}},
+						},
+					},
+					HasMore: larkcore.BoolPtr(false),
+				}}
+				s.mockClient.EXPECT().DocxBlockList(mock.Anything, req2).Return(resp2, nil).Once()
+			},
+			expected: "# 标题\n\n正文\n",
+		},
+		{
+			name: "客户端调用失败",
+			setupMock: func() {
+				s.mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, "读取文档块%d个, 请求%d次", mock.Anything, mock.Anything).Return().Once()
+				s.mockClient.EXPECT().DocxBlockList(mock.Anything, mock.Anything).Return(nil, errors.New("API调用失败")).Once()
+			},
+			expectedError: errors.New("API调用失败"),
+		},
+		{
+			name: "API响应不成功",
+			setupMock: func() {
+				s.mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusDownloading, "读取文档块%d个, 请求%d次", mock.Anything, mock.Anything).Return().Once()
+				resp := &larkdocx.ListDocumentBlockResp{
+					ApiResp: &larkcore.ApiResp{
+						Header:     http.Header{larkcore.HttpHeaderKeyLogId: []string{"1111111111"}},
+						StatusCode: 403,
+					},
+					CodeError: larkcore.CodeError{Code: 1770032, Msg: "forbidden"},
+				}
+				s.mockClient.EXPECT().DocxBlockList(mock.Anything, mock.Anything).Return(checkResp(resp, nil)).Once()
+			},
+			expectedError: errors.New("logId: \x1b]8;;https://open.feishu.cn/search?q=1111111111\x1b\\, 操作: 获取文档所有块, 响应错误: msg:forbidden,code:1770032"),
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setupMock()
+			file, length, err := s.task.doConvert(context.Background(), di)
+			if tt.expectedError != nil {
+				s.Require().Error(err, tt.name)
+				var actualError oops.OopsError
+				yes := errors.As(err, &actualError)
+				s.Require().True(yes, tt.name)
+				s.Equal(tt.expectedError.Error(), actualError.Error(), tt.name)
+				return
+			}
+			s.Require().NoError(err, tt.name)
+			actual, err := io.ReadAll(file)
+			s.Require().NoError(err, tt.name)
+			s.Equal(tt.expected, string(actual), tt.name)
+			s.Equal(int64(len(tt.expected)), length, tt.name)
+		})
+	}
+}
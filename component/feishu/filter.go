@@ -0,0 +1,235 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// timeFilterLayouts 时间过滤条件支持的日期时间格式，不带时区时使用本地时区。
+var timeFilterLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", time.DateOnly}
+
+// documentFilter 按路径、类型、层级、编辑时间和所有者过滤文档树。
+// 路径是文件保存路径相对于 SaveDir 的部分，以/分隔，与阶段1打印的目录结构一致，如 知识库/归档/周报.docx。
+type documentFilter struct {
+	saveDir        string
+	include        []*regexp.Regexp
+	exclude        []*regexp.Regexp
+	types          []constant.DocType
+	maxDepth       int
+	modifiedSince  time.Time // 为零值时不限制
+	modifiedBefore time.Time // 为零值时不限制
+	owners         []string
+}
+
+// hasFilters 是否指定了文档树的过滤条件。
+func (a *Args) hasFilters() bool {
+	return len(a.Include) > 0 || len(a.Exclude) > 0 || len(a.Types) > 0 || a.MaxDepth > 0 ||
+		a.ModifiedSince != "" || a.ModifiedBefore != "" || len(a.Owners) > 0
+}
+
+func newDocumentFilter(args *Args) *documentFilter {
+	// 相对时长以程序开始时间为基准，同一次导出中保持一致
+	now := time.Now()
+	if args.Args != nil && !args.StartTime.IsZero() {
+		now = args.StartTime
+	}
+	// 参数已经校验过，忽略解析错误
+	modifiedSince, _ := parseTimeFilter(args.ModifiedSince, now)
+	modifiedBefore, _ := parseTimeFilter(args.ModifiedBefore, now)
+	return &documentFilter{
+		saveDir:        args.SaveDir,
+		include:        lo.Map(args.Include, func(p string, _ int) *regexp.Regexp { return globToRegexp(p) }),
+		exclude:        lo.Map(args.Exclude, func(p string, _ int) *regexp.Regexp { return globToRegexp(p) }),
+		types:          lo.Map(args.Types, func(t string, _ int) constant.DocType { return constant.DocType(t) }),
+		maxDepth:       args.MaxDepth,
+		modifiedSince:  modifiedSince,
+		modifiedBefore: modifiedBefore,
+		owners:         args.Owners,
+	}
+}
+
+// filterDocuments 按过滤条件将不导出的节点标记为已排除，返回已排除的文档数量（不包括目录）。
+// 需要在 documentNodesToInfoList 计算好文件保存路径后调用。
+//   - 路径匹配 exclude 或超过 max-depth 的节点连同子树一起排除
+//   - 指定了 include 时，只保留路径匹配 include 的节点及其子树
+//   - 指定了 types 时，只保留这些类型的文档
+//   - 指定了 modified-since/modified-before 时，只保留在该时间范围内编辑过的文档，编辑时间未知的文档也排除
+//   - 指定了 owner 时，只保留这些用户所有的文档
+//   - 没有保留任何子节点的目录也标记为已排除
+func filterDocuments(dns []*DocumentNode, args *Args) int {
+	if !args.hasFilters() {
+		return 0
+	}
+	var count int
+	newDocumentFilter(args).mark(dns, 1, false, &count)
+	return count
+}
+
+// mark 递归标记已排除的节点，included 表示上级节点已匹配 include，返回是否保留了任何文档。
+func (f *documentFilter) mark(dns []*DocumentNode, depth int, included bool, count *int) (kept bool) {
+	for _, dn := range dns {
+		paths := f.paths(dn)
+		if (f.maxDepth > 0 && depth > f.maxDepth) || matchAny(f.exclude, paths...) {
+			*count += excludeAll(dn)
+			continue
+		}
+		selfIncluded := included || len(f.include) == 0 || matchAny(f.include, paths...)
+		childKept := f.mark(dn.Children, depth+1, selfIncluded, count)
+		kept = kept || childKept
+		if dn.isDir() {
+			// 空目录只在未匹配 include 时排除
+			if !childKept && (len(dn.Children) > 0 || !selfIncluded) {
+				dn.Excluded = true
+			}
+			continue
+		}
+		if selfIncluded && f.matchDocument(&dn.DocumentInfo) {
+			kept = true
+			continue
+		}
+		dn.Excluded = true
+		*count++
+	}
+	return kept
+}
+
+// matchDocument 文档是否满足类型、编辑时间和所有者的过滤条件。
+func (f *documentFilter) matchDocument(di *DocumentInfo) bool {
+	if len(f.types) > 0 && !lo.Contains(f.types, di.Type) {
+		return false
+	}
+	if len(f.owners) > 0 && !lo.Contains(f.owners, di.OwnerID) {
+		return false
+	}
+	if f.modifiedSince.IsZero() && f.modifiedBefore.IsZero() {
+		return true
+	}
+	sec, err := strconv.ParseInt(di.ModifiedTime, 10, 64)
+	if err != nil {
+		return false
+	}
+	modified := time.Unix(sec, 0)
+	if !f.modifiedSince.IsZero() && modified.Before(f.modifiedSince) {
+		return false
+	}
+	return f.modifiedBefore.IsZero() || modified.Before(f.modifiedBefore)
+}
+
+// paths 节点在文档树中的路径，有子节点的文档（如知识库中的文档）还包括其子节点所在的目录路径，
+// 如 知识库/归档.docx 和 知识库/归档，这样 "**/归档" 也能匹配到这个文档及其子树。
+func (f *documentFilter) paths(dn *DocumentNode) []string {
+	rel, err := filepath.Rel(f.saveDir, dn.FilePath)
+	if err != nil {
+		rel = dn.Name
+	}
+	paths := []string{filepath.ToSlash(rel)}
+	if !dn.isDir() && len(dn.Children) > 0 {
+		paths = append(paths, filepath.ToSlash(filepath.Join(filepath.Dir(rel), dn.Name)))
+	}
+	return paths
+}
+
+// excludeAll 将节点及其子树都标记为已排除，返回其中的文档数量。
+func excludeAll(dn *DocumentNode) int {
+	dn.Excluded = true
+	var count int
+	if !dn.isDir() {
+		count++
+	}
+	for _, child := range dn.Children {
+		count += excludeAll(child)
+	}
+	return count
+}
+
+// shouldDownload 是否需要导出下载，已被过滤条件排除的文档仍然保留在文档树中，但不导出。
+func (di *DocumentInfo) shouldDownload() bool {
+	return di.CanDownload && !di.Excluded
+}
+
+// matchAny 任意一个路径匹配任意一个模式。
+func matchAny(patterns []*regexp.Regexp, paths ...string) bool {
+	return lo.SomeBy(patterns, func(re *regexp.Regexp) bool {
+		return lo.SomeBy(paths, re.MatchString)
+	})
+}
+
+// globToRegexp 将glob模式转为正则表达式，匹配整个路径。
+// * 匹配路径中一级内的任意字符，? 匹配一个字符，** 匹配任意多级路径，**/ 也可以匹配零级。
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	runes := []rune(strings.Trim(pattern, "/"))
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				i++
+				sb.WriteString("(?:.*/)?")
+			} else {
+				sb.WriteString(".*")
+			}
+		case r == '*':
+			sb.WriteString("[^/]*")
+		case r == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// validateTimeFilter 校验 modified-since/modified-before 的格式。
+func validateTimeFilter(value any) error {
+	s, _ := value.(string)
+	_, err := parseTimeFilter(s, time.Now())
+	return err
+}
+
+// parseTimeFilter 解析时间过滤条件，为空时返回零值。
+// 可以是日期时间，如 2025-01-01、2025-01-01 08:00:00、2025-01-01T08:00:00+08:00，
+// 也可以是相对于now的时长，如 30d、12h、90m，表示从now往前推的时间。
+func parseTimeFilter(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeFilterLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, oops.Errorf("时间格式不合法, 应为日期时间如2025-01-01或相对时长如30d: %s", value)
+}
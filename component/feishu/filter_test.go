@@ -0,0 +1,302 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "*.docx", path: "a.docx", want: true},
+		{pattern: "*.docx", path: "知识库/a.docx", want: false},
+		{pattern: "知识库/*", path: "知识库/a.docx", want: true},
+		{pattern: "知识库/*", path: "知识库/a/b.docx", want: false},
+		{pattern: "知识库/**", path: "知识库/a/b.docx", want: true},
+		{pattern: "**/归档", path: "归档", want: true},
+		{pattern: "**/归档", path: "知识库/a/归档", want: true},
+		{pattern: "**/归档", path: "知识库/归档/a.docx", want: false},
+		{pattern: "/知识库/a?.docx/", path: "知识库/a1.docx", want: true},
+		{pattern: "知识库/a?.docx", path: "知识库/a/.docx", want: false},
+		{pattern: "a+b(1).docx", path: "a+b(1).docx", want: true},
+		{pattern: "a+b(1).docx", path: "aab(1).docx", want: false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, globToRegexp(tt.pattern).MatchString(tt.path), "%s => %s", tt.pattern, tt.path)
+	}
+}
+
+// newFilterTree 构造用于测试过滤条件的文档树。
+//
+//	└─ 知识库
+//	    ├─ a.docx       2025-01-01编辑，ou_a所有
+//	    ├─ a
+//	    │   └─ b.xlsx   2025-02-01编辑，ou_b所有
+//	    ├─ 归档
+//	    │   └─ c.docx   2024-01-01编辑，ou_a所有
+//	    └─ d.pdf        编辑时间未知，ou_b所有
+func newFilterTree() []*DocumentNode {
+	dns := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "知识库", Type: constant.DocTypeFolder, Token: "space"},
+			Children: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{Name: "a", Type: constant.DocTypeDocx, Token: "a", FileExtension: constant.FileExtDocx, CanDownload: true,
+						ModifiedTime: "1735689600", OwnerID: "ou_a"},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "b", Type: constant.DocTypeSheet, Token: "b", FileExtension: constant.FileExtXlsx, CanDownload: true,
+							ModifiedTime: "1738368000", OwnerID: "ou_b"}},
+					},
+				},
+				{
+					DocumentInfo: DocumentInfo{Name: "归档", Type: constant.DocTypeFolder, Token: "archive"},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "c", Type: constant.DocTypeDocx, Token: "c", FileExtension: constant.FileExtDocx, CanDownload: true,
+							ModifiedTime: "1704067200", OwnerID: "ou_a"}},
+					},
+				},
+				{DocumentInfo: DocumentInfo{Name: "d", Type: constant.DocTypeFile, Token: "d", FileExtension: constant.FileExtPDF,
+					CanDownload: true, DownloadDirectly: true, OwnerID: "ou_b"}},
+			},
+		},
+	}
+	_ = documentNodesToInfoList(dns, "/tmp/docs")
+	return dns
+}
+
+func TestFilterDocuments(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         *Args
+		wantCount    int
+		wantExcluded []string
+	}{
+		{
+			name:      "没有过滤条件",
+			args:      &Args{},
+			wantCount: 0,
+		},
+		{
+			name:         "排除目录",
+			args:         &Args{Exclude: []string{"**/归档"}},
+			wantCount:    1,
+			wantExcluded: []string{"archive", "c"},
+		},
+		{
+			name:         "只包含匹配的路径",
+			args:         &Args{Include: []string{"知识库/a/**"}},
+			wantCount:    3,
+			wantExcluded: []string{"a", "archive", "c", "d"},
+		},
+		{
+			name:         "匹配include的节点包含其子树",
+			args:         &Args{Include: []string{"知识库/归档"}},
+			wantCount:    3,
+			wantExcluded: []string{"a", "b", "d"},
+		},
+		{
+			name:         "按类型过滤",
+			args:         &Args{Types: []string{"docx"}},
+			wantCount:    2,
+			wantExcluded: []string{"b", "d"},
+		},
+		{
+			name:         "按层级过滤，没有保留子节点的目录也排除",
+			args:         &Args{MaxDepth: 2},
+			wantCount:    2,
+			wantExcluded: []string{"b", "archive", "c"},
+		},
+		{
+			name:         "按编辑时间过滤，编辑时间未知的文档也排除",
+			args:         &Args{ModifiedSince: "2025-01-01T00:00:00Z"},
+			wantCount:    2,
+			wantExcluded: []string{"archive", "c", "d"},
+		},
+		{
+			name:         "只导出在指定时间之前编辑过的文档",
+			args:         &Args{ModifiedBefore: "2025-01-15T00:00:00Z"},
+			wantCount:    2,
+			wantExcluded: []string{"b", "d"},
+		},
+		{
+			name: "相对时长以程序开始时间为基准",
+			args: &Args{
+				Args:          &argument.Args{StartTime: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)},
+				ModifiedSince: "30d",
+			},
+			wantCount:    3,
+			wantExcluded: []string{"a", "archive", "c", "d"},
+		},
+		{
+			name:         "按所有者过滤",
+			args:         &Args{Owners: []string{"ou_b"}},
+			wantCount:    2,
+			wantExcluded: []string{"a", "archive", "c"},
+		},
+		{
+			name:         "全部排除",
+			args:         &Args{Types: []string{"bitable"}},
+			wantCount:    4,
+			wantExcluded: []string{"space", "a", "b", "archive", "c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dns := newFilterTree()
+			tt.args.SaveDir = "/tmp/docs"
+			require.Equal(t, tt.wantCount, filterDocuments(dns, tt.args))
+			var excluded []string
+			for _, di := range documentNodesToInfoList(dns, "/tmp/docs") {
+				if di.Excluded {
+					excluded = append(excluded, di.Token)
+				}
+			}
+			require.ElementsMatch(t, tt.wantExcluded, excluded)
+		})
+	}
+}
+
+func TestFilterDocuments_wiki(t *testing.T) {
+	// 知识库中有子节点的是文档而不是目录，子节点保存在与文档同名（不带扩展名）的目录中
+	newWikiTree := func() []*DocumentNode {
+		dns := []*DocumentNode{
+			{
+				DocumentInfo: DocumentInfo{Name: "知识库", Type: constant.DocTypeFolder, Token: "space"},
+				Children: []*DocumentNode{
+					{
+						DocumentInfo: DocumentInfo{Name: "归档", Type: constant.DocTypeDocx, Token: "archive", FileExtension: constant.FileExtDocx, CanDownload: true},
+						Children: []*DocumentNode{
+							{DocumentInfo: DocumentInfo{Name: "x", Type: constant.DocTypeDocx, Token: "x", FileExtension: constant.FileExtDocx, CanDownload: true}},
+						},
+					},
+					{DocumentInfo: DocumentInfo{Name: "y", Type: constant.DocTypeDocx, Token: "y", FileExtension: constant.FileExtDocx, CanDownload: true}},
+				},
+			},
+		}
+		_ = documentNodesToInfoList(dns, "/tmp/docs")
+		return dns
+	}
+	tests := []struct {
+		name         string
+		args         *Args
+		wantCount    int
+		wantExcluded []string
+	}{
+		{
+			name:         "按子节点目录路径排除文档及其子树",
+			args:         &Args{Exclude: []string{"**/归档"}},
+			wantCount:    2,
+			wantExcluded: []string{"archive", "x"},
+		},
+		{
+			name:         "按文件路径排除文档及其子树",
+			args:         &Args{Exclude: []string{"知识库/归档.docx"}},
+			wantCount:    2,
+			wantExcluded: []string{"archive", "x"},
+		},
+		{
+			name:         "按子节点目录路径包含文档及其子树",
+			args:         &Args{Include: []string{"知识库/归档"}},
+			wantCount:    1,
+			wantExcluded: []string{"y"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dns := newWikiTree()
+			tt.args.SaveDir = "/tmp/docs"
+			require.Equal(t, tt.wantCount, filterDocuments(dns, tt.args))
+			var excluded []string
+			for _, di := range flattenDocumentNodes(dns) {
+				if di.Excluded {
+					excluded = append(excluded, di.Token)
+				}
+			}
+			require.ElementsMatch(t, tt.wantExcluded, excluded)
+		})
+	}
+}
+
+func TestDocumentInfo_shouldDownload(t *testing.T) {
+	dns := newFilterTree()
+	args := &Args{SaveDir: "/tmp/docs", Types: []string{"sheet"}}
+	require.Equal(t, 3, filterDocuments(dns, args))
+	var tokens []string
+	for _, di := range flattenDocumentNodes(dns) {
+		if di.shouldDownload() {
+			tokens = append(tokens, di.Token)
+		}
+	}
+	require.Equal(t, []string{"b"}, tokens)
+	// 已排除的文档仍然保留在文档树中
+	require.Len(t, dns[0].Children, 3)
+	require.True(t, dns[0].Children[0].CanDownload)
+
+	require.False(t, (&DocumentInfo{CanDownload: false}).shouldDownload())
+	require.True(t, (&DocumentInfo{CanDownload: true}).shouldDownload())
+}
+
+func TestArgs_hasFilters(t *testing.T) {
+	require.False(t, (&Args{}).hasFilters())
+	require.False(t, (&Args{Include: []string{}, Exclude: []string{}, Types: []string{}}).hasFilters())
+	require.True(t, (&Args{Include: []string{"*"}}).hasFilters())
+	require.True(t, (&Args{Exclude: []string{"*"}}).hasFilters())
+	require.True(t, (&Args{Types: []string{"docx"}}).hasFilters())
+	require.True(t, (&Args{MaxDepth: 1}).hasFilters())
+	require.True(t, (&Args{ModifiedSince: "30d"}).hasFilters())
+	require.True(t, (&Args{ModifiedBefore: "2025-01-01"}).hasFilters())
+	require.True(t, (&Args{Owners: []string{"ou_xxx"}}).hasFilters())
+}
+
+func TestParseTimeFilter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "2025-01-02", want: time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)},
+		{value: " 2025-01-02 08:30 ", want: time.Date(2025, 1, 2, 8, 30, 0, 0, time.Local)},
+		{value: "2025-01-02 08:30:15", want: time.Date(2025, 1, 2, 8, 30, 15, 0, time.Local)},
+		{value: "2025-01-02T08:30:15+08:00", want: time.Date(2025, 1, 2, 0, 30, 15, 0, time.UTC)},
+		{value: "30d", want: time.Date(2025, 1, 30, 12, 0, 0, 0, time.Local)},
+		{value: "0d", want: now},
+		{value: "12h", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)},
+		{value: "90m", want: time.Date(2025, 3, 1, 10, 30, 0, 0, time.Local)},
+		{value: "-1h", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "2025/01/02", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeFilter(tt.value, now)
+		if tt.wantErr {
+			require.EqualError(t, err, "时间格式不合法, 应为日期时间如2025-01-01或相对时长如30d: "+tt.value, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		require.True(t, tt.want.Equal(got), "%s: %s", tt.value, got)
+	}
+}
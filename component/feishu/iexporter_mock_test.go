@@ -3,10 +3,12 @@
 package feishu
 
 import (
+	context "context"
 	io "io"
 
-	progress "github.com/acyumi/xdoc/component/progress"
 	mock "github.com/stretchr/testify/mock"
+
+	progress "github.com/acyumi/xdoc/component/progress"
 )
 
 // MockExporter is an autogenerated mock type for the IExporter type
@@ -22,9 +24,9 @@ func (_m *MockExporter) EXPECT() *MockExporter_Expecter {
 	return &MockExporter_Expecter{mock: &_m.Mock}
 }
 
-// checkExport provides a mock function with given fields: di, ticket
-func (_m *MockExporter) checkExport(di *DocumentInfo, ticket string) (*exportResult, progress.Status, error) {
-	ret := _m.Called(di, ticket)
+// checkExport provides a mock function with given fields: ctx, di, ticket
+func (_m *MockExporter) checkExport(ctx context.Context, di *DocumentInfo, ticket string) (*exportResult, progress.Status, error) {
+	ret := _m.Called(ctx, di, ticket)
 
 	if len(ret) == 0 {
 		panic("no return value specified for checkExport")
@@ -33,25 +35,25 @@ func (_m *MockExporter) checkExport(di *DocumentInfo, ticket string) (*exportRes
 	var r0 *exportResult
 	var r1 progress.Status
 	var r2 error
-	if rf, ok := ret.Get(0).(func(*DocumentInfo, string) (*exportResult, progress.Status, error)); ok {
-		return rf(di, ticket)
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo, string) (*exportResult, progress.Status, error)); ok {
+		return rf(ctx, di, ticket)
 	}
-	if rf, ok := ret.Get(0).(func(*DocumentInfo, string) *exportResult); ok {
-		r0 = rf(di, ticket)
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo, string) *exportResult); ok {
+		r0 = rf(ctx, di, ticket)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*exportResult)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*DocumentInfo, string) progress.Status); ok {
-		r1 = rf(di, ticket)
+	if rf, ok := ret.Get(1).(func(context.Context, *DocumentInfo, string) progress.Status); ok {
+		r1 = rf(ctx, di, ticket)
 	} else {
 		r1 = ret.Get(1).(progress.Status)
 	}
 
-	if rf, ok := ret.Get(2).(func(*DocumentInfo, string) error); ok {
-		r2 = rf(di, ticket)
+	if rf, ok := ret.Get(2).(func(context.Context, *DocumentInfo, string) error); ok {
+		r2 = rf(ctx, di, ticket)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -65,15 +67,16 @@ type MockExporter_checkExport_Call struct {
 }
 
 // checkExport is a helper method to define mock.On call
+//   - ctx context.Context
 //   - di *DocumentInfo
 //   - ticket string
-func (_e *MockExporter_Expecter) checkExport(di any, ticket any) *MockExporter_checkExport_Call {
-	return &MockExporter_checkExport_Call{Call: _e.mock.On("checkExport", di, ticket)}
+func (_e *MockExporter_Expecter) checkExport(ctx any, di any, ticket any) *MockExporter_checkExport_Call {
+	return &MockExporter_checkExport_Call{Call: _e.mock.On("checkExport", ctx, di, ticket)}
 }
 
-func (_c *MockExporter_checkExport_Call) Run(run func(di *DocumentInfo, ticket string)) *MockExporter_checkExport_Call {
+func (_c *MockExporter_checkExport_Call) Run(run func(ctx context.Context, di *DocumentInfo, ticket string)) *MockExporter_checkExport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*DocumentInfo), args[1].(string))
+		run(args[0].(context.Context), args[1].(*DocumentInfo), args[2].(string))
 	})
 	return _c
 }
@@ -83,14 +86,80 @@ func (_c *MockExporter_checkExport_Call) Return(_a0 *exportResult, _a1 progress.
 	return _c
 }
 
-func (_c *MockExporter_checkExport_Call) RunAndReturn(run func(*DocumentInfo, string) (*exportResult, progress.Status, error)) *MockExporter_checkExport_Call {
+func (_c *MockExporter_checkExport_Call) RunAndReturn(run func(context.Context, *DocumentInfo, string) (*exportResult, progress.Status, error)) *MockExporter_checkExport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// doConvert provides a mock function with given fields: ctx, di
+func (_m *MockExporter) doConvert(ctx context.Context, di *DocumentInfo) (io.Reader, int64, error) {
+	ret := _m.Called(ctx, di)
+
+	if len(ret) == 0 {
+		panic("no return value specified for doConvert")
+	}
+
+	var r0 io.Reader
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo) (io.Reader, int64, error)); ok {
+		return rf(ctx, di)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo) io.Reader); ok {
+		r0 = rf(ctx, di)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.Reader)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *DocumentInfo) int64); ok {
+		r1 = rf(ctx, di)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *DocumentInfo) error); ok {
+		r2 = rf(ctx, di)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockExporter_doConvert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'doConvert'
+type MockExporter_doConvert_Call struct {
+	*mock.Call
+}
+
+// doConvert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - di *DocumentInfo
+func (_e *MockExporter_Expecter) doConvert(ctx any, di any) *MockExporter_doConvert_Call {
+	return &MockExporter_doConvert_Call{Call: _e.mock.On("doConvert", ctx, di)}
+}
+
+func (_c *MockExporter_doConvert_Call) Run(run func(ctx context.Context, di *DocumentInfo)) *MockExporter_doConvert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*DocumentInfo))
+	})
+	return _c
+}
+
+func (_c *MockExporter_doConvert_Call) Return(_a0 io.Reader, _a1 int64, _a2 error) *MockExporter_doConvert_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockExporter_doConvert_Call) RunAndReturn(run func(context.Context, *DocumentInfo) (io.Reader, int64, error)) *MockExporter_doConvert_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// doDownloadDirectly provides a mock function with given fields: filePath, fileToken
-func (_m *MockExporter) doDownloadDirectly(filePath string, fileToken string) (io.Reader, int64, error) {
-	ret := _m.Called(filePath, fileToken)
+// doDownloadDirectly provides a mock function with given fields: ctx, filePath, fileToken
+func (_m *MockExporter) doDownloadDirectly(ctx context.Context, filePath string, fileToken string) (io.Reader, int64, error) {
+	ret := _m.Called(ctx, filePath, fileToken)
 
 	if len(ret) == 0 {
 		panic("no return value specified for doDownloadDirectly")
@@ -99,25 +168,25 @@ func (_m *MockExporter) doDownloadDirectly(filePath string, fileToken string) (i
 	var r0 io.Reader
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string) (io.Reader, int64, error)); ok {
-		return rf(filePath, fileToken)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (io.Reader, int64, error)); ok {
+		return rf(ctx, filePath, fileToken)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) io.Reader); ok {
-		r0 = rf(filePath, fileToken)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) io.Reader); ok {
+		r0 = rf(ctx, filePath, fileToken)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(io.Reader)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) int64); ok {
-		r1 = rf(filePath, fileToken)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) int64); ok {
+		r1 = rf(ctx, filePath, fileToken)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(filePath, fileToken)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, filePath, fileToken)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -131,15 +200,16 @@ type MockExporter_doDownloadDirectly_Call struct {
 }
 
 // doDownloadDirectly is a helper method to define mock.On call
+//   - ctx context.Context
 //   - filePath string
 //   - fileToken string
-func (_e *MockExporter_Expecter) doDownloadDirectly(filePath any, fileToken any) *MockExporter_doDownloadDirectly_Call {
-	return &MockExporter_doDownloadDirectly_Call{Call: _e.mock.On("doDownloadDirectly", filePath, fileToken)}
+func (_e *MockExporter_Expecter) doDownloadDirectly(ctx any, filePath any, fileToken any) *MockExporter_doDownloadDirectly_Call {
+	return &MockExporter_doDownloadDirectly_Call{Call: _e.mock.On("doDownloadDirectly", ctx, filePath, fileToken)}
 }
 
-func (_c *MockExporter_doDownloadDirectly_Call) Run(run func(filePath string, fileToken string)) *MockExporter_doDownloadDirectly_Call {
+func (_c *MockExporter_doDownloadDirectly_Call) Run(run func(ctx context.Context, filePath string, fileToken string)) *MockExporter_doDownloadDirectly_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
@@ -149,14 +219,14 @@ func (_c *MockExporter_doDownloadDirectly_Call) Return(_a0 io.Reader, _a1 int64,
 	return _c
 }
 
-func (_c *MockExporter_doDownloadDirectly_Call) RunAndReturn(run func(string, string) (io.Reader, int64, error)) *MockExporter_doDownloadDirectly_Call {
+func (_c *MockExporter_doDownloadDirectly_Call) RunAndReturn(run func(context.Context, string, string) (io.Reader, int64, error)) *MockExporter_doDownloadDirectly_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// doDownloadExported provides a mock function with given fields: filePath, fileToken
-func (_m *MockExporter) doDownloadExported(filePath string, fileToken string) (io.Reader, error) {
-	ret := _m.Called(filePath, fileToken)
+// doDownloadExported provides a mock function with given fields: ctx, filePath, fileToken
+func (_m *MockExporter) doDownloadExported(ctx context.Context, filePath string, fileToken string) (io.Reader, error) {
+	ret := _m.Called(ctx, filePath, fileToken)
 
 	if len(ret) == 0 {
 		panic("no return value specified for doDownloadExported")
@@ -164,19 +234,19 @@ func (_m *MockExporter) doDownloadExported(filePath string, fileToken string) (i
 
 	var r0 io.Reader
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (io.Reader, error)); ok {
-		return rf(filePath, fileToken)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (io.Reader, error)); ok {
+		return rf(ctx, filePath, fileToken)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) io.Reader); ok {
-		r0 = rf(filePath, fileToken)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) io.Reader); ok {
+		r0 = rf(ctx, filePath, fileToken)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(io.Reader)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(filePath, fileToken)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, filePath, fileToken)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -190,15 +260,16 @@ type MockExporter_doDownloadExported_Call struct {
 }
 
 // doDownloadExported is a helper method to define mock.On call
+//   - ctx context.Context
 //   - filePath string
 //   - fileToken string
-func (_e *MockExporter_Expecter) doDownloadExported(filePath any, fileToken any) *MockExporter_doDownloadExported_Call {
-	return &MockExporter_doDownloadExported_Call{Call: _e.mock.On("doDownloadExported", filePath, fileToken)}
+func (_e *MockExporter_Expecter) doDownloadExported(ctx any, filePath any, fileToken any) *MockExporter_doDownloadExported_Call {
+	return &MockExporter_doDownloadExported_Call{Call: _e.mock.On("doDownloadExported", ctx, filePath, fileToken)}
 }
 
-func (_c *MockExporter_doDownloadExported_Call) Run(run func(filePath string, fileToken string)) *MockExporter_doDownloadExported_Call {
+func (_c *MockExporter_doDownloadExported_Call) Run(run func(ctx context.Context, filePath string, fileToken string)) *MockExporter_doDownloadExported_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
@@ -208,14 +279,14 @@ func (_c *MockExporter_doDownloadExported_Call) Return(_a0 io.Reader, _a1 error)
 	return _c
 }
 
-func (_c *MockExporter_doDownloadExported_Call) RunAndReturn(run func(string, string) (io.Reader, error)) *MockExporter_doDownloadExported_Call {
+func (_c *MockExporter_doDownloadExported_Call) RunAndReturn(run func(context.Context, string, string) (io.Reader, error)) *MockExporter_doDownloadExported_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// doExport provides a mock function with given fields: di
-func (_m *MockExporter) doExport(di *DocumentInfo) (string, error) {
-	ret := _m.Called(di)
+// doExport provides a mock function with given fields: ctx, di
+func (_m *MockExporter) doExport(ctx context.Context, di *DocumentInfo) (string, error) {
+	ret := _m.Called(ctx, di)
 
 	if len(ret) == 0 {
 		panic("no return value specified for doExport")
@@ -223,17 +294,17 @@ func (_m *MockExporter) doExport(di *DocumentInfo) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*DocumentInfo) (string, error)); ok {
-		return rf(di)
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo) (string, error)); ok {
+		return rf(ctx, di)
 	}
-	if rf, ok := ret.Get(0).(func(*DocumentInfo) string); ok {
-		r0 = rf(di)
+	if rf, ok := ret.Get(0).(func(context.Context, *DocumentInfo) string); ok {
+		r0 = rf(ctx, di)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(*DocumentInfo) error); ok {
-		r1 = rf(di)
+	if rf, ok := ret.Get(1).(func(context.Context, *DocumentInfo) error); ok {
+		r1 = rf(ctx, di)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -247,14 +318,15 @@ type MockExporter_doExport_Call struct {
 }
 
 // doExport is a helper method to define mock.On call
+//   - ctx context.Context
 //   - di *DocumentInfo
-func (_e *MockExporter_Expecter) doExport(di any) *MockExporter_doExport_Call {
-	return &MockExporter_doExport_Call{Call: _e.mock.On("doExport", di)}
+func (_e *MockExporter_Expecter) doExport(ctx any, di any) *MockExporter_doExport_Call {
+	return &MockExporter_doExport_Call{Call: _e.mock.On("doExport", ctx, di)}
 }
 
-func (_c *MockExporter_doExport_Call) Run(run func(di *DocumentInfo)) *MockExporter_doExport_Call {
+func (_c *MockExporter_doExport_Call) Run(run func(ctx context.Context, di *DocumentInfo)) *MockExporter_doExport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*DocumentInfo))
+		run(args[0].(context.Context), args[1].(*DocumentInfo))
 	})
 	return _c
 }
@@ -264,7 +336,7 @@ func (_c *MockExporter_doExport_Call) Return(_a0 string, _a1 error) *MockExporte
 	return _c
 }
 
-func (_c *MockExporter_doExport_Call) RunAndReturn(run func(*DocumentInfo) (string, error)) *MockExporter_doExport_Call {
+func (_c *MockExporter_doExport_Call) RunAndReturn(run func(context.Context, *DocumentInfo) (string, error)) *MockExporter_doExport_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -15,6 +15,7 @@
 package feishu
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -72,4 +73,5 @@ func cleanSleep() {
 	mu.Lock()
 	defer mu.Unlock()
 	app.Sleep = func(duration time.Duration) { /* 单测时不需要睡眠等待 */ }
+	app.SleepContext = func(ctx context.Context, duration time.Duration) error { return ctx.Err() }
 }
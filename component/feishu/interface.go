@@ -19,7 +19,10 @@ import (
 	"io"
 
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkbitable "github.com/larksuite/oapi-sdk-go/v3/service/bitable/v1"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+	larksheets "github.com/larksuite/oapi-sdk-go/v3/service/sheets/v3"
 	larkwiki "github.com/larksuite/oapi-sdk-go/v3/service/wiki/v2"
 
 	"github.com/acyumi/xdoc/component/cloud"
@@ -38,6 +41,8 @@ type Client interface {
 	DriveList(ctx context.Context, req *larkdrive.ListFileReq, options ...larkcore.RequestOptionFunc) (*larkdrive.ListFileResp, error)
 	// DriveDownload 【云盘】下载文件
 	DriveDownload(ctx context.Context, req *larkdrive.DownloadFileReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadFileResp, error)
+	// DriveDownloadMedia 【云盘】下载素材，如文档中的图片和附件
+	DriveDownloadMedia(ctx context.Context, req *larkdrive.DownloadMediaReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadMediaResp, error)
 
 	// WikiGetNode 【知识库】获取节点信息
 	WikiGetNode(ctx context.Context, req *larkwiki.GetNodeSpaceReq, options ...larkcore.RequestOptionFunc) (*larkwiki.GetNodeSpaceResp, error)
@@ -52,19 +57,34 @@ type Client interface {
 	ExportGet(ctx context.Context, req *larkdrive.GetExportTaskReq, options ...larkcore.RequestOptionFunc) (*larkdrive.GetExportTaskResp, error)
 	// ExportDownload 【导出】下载文件
 	ExportDownload(ctx context.Context, req *larkdrive.DownloadExportTaskReq, options ...larkcore.RequestOptionFunc) (*larkdrive.DownloadExportTaskResp, error)
+
+	// DocxBlockList 【文档】获取文档所有块
+	DocxBlockList(ctx context.Context, req *larkdocx.ListDocumentBlockReq, options ...larkcore.RequestOptionFunc) (*larkdocx.ListDocumentBlockResp, error)
+
+	// SheetsQuery 【电子表格】获取工作表
+	SheetsQuery(ctx context.Context, req *larksheets.QuerySpreadsheetSheetReq, options ...larkcore.RequestOptionFunc) (*larksheets.QuerySpreadsheetSheetResp, error)
+	// BitableTableList 【多维表格】列出数据表
+	BitableTableList(ctx context.Context, req *larkbitable.ListAppTableReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableResp, error)
+	// BitableFieldList 【多维表格】列出字段
+	BitableFieldList(ctx context.Context, req *larkbitable.ListAppTableFieldReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableFieldResp, error)
+	// BitableRecordList 【多维表格】列出记录
+	BitableRecordList(ctx context.Context, req *larkbitable.ListAppTableRecordReq, options ...larkcore.RequestOptionFunc) (*larkbitable.ListAppTableRecordResp, error)
 }
 
 type IExporter interface {
 
 	// doExport 创建导出任务。
-	doExport(di *DocumentInfo) (string, error)
+	doExport(ctx context.Context, di *DocumentInfo) (string, error)
 
 	// checkExport 查询导出任务结果。
-	checkExport(di *DocumentInfo, ticket string) (*exportResult, progress.Status, error)
+	checkExport(ctx context.Context, di *DocumentInfo, ticket string) (*exportResult, progress.Status, error)
 
 	// doDownloadExported 下载导出的文件。
-	doDownloadExported(filePath, fileToken string) (io.Reader, error)
+	doDownloadExported(ctx context.Context, filePath, fileToken string) (io.Reader, error)
 
 	// doDownloadDirectly 不需要经过导出操作，直接下载文件。
-	doDownloadDirectly(filePath, fileToken string) (io.Reader, int64, error)
+	doDownloadDirectly(ctx context.Context, filePath, fileToken string) (io.Reader, int64, error)
+
+	// doConvert 不需要经过导出操作，读取文档内容后在本地转换。
+	doConvert(ctx context.Context, di *DocumentInfo) (io.Reader, int64, error)
 }
@@ -0,0 +1,163 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/spf13/afero"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+const journalFile = "export-journal.jsonl" // 导出日志文件名，保存在 SaveDir 中，用于中断后恢复导出
+
+// journalEntry 导出日志中的一行记录。
+type journalEntry struct {
+	Time     int64           `json:"time"`             // 记录时间（Unix时间戳，毫秒）
+	FilePath string          `json:"filePath"`         // 文件保存路径，作为文档的唯一标识
+	Token    string          `json:"token"`            // 文档token
+	Status   progress.Status `json:"status"`           // 文档的导出下载状态
+	Ticket   string          `json:"ticket,omitempty"` // 导出任务ID，创建导出任务成功时记录
+	Msg      string          `json:"msg,omitempty"`    // 状态附带的消息，如失败原因
+}
+
+// journal 导出日志，每行一条JSON记录，按时间顺序记录各文档的状态变化。
+// 即使程序中途崩溃，已写入的记录也可以在下次执行时通过 --resume 恢复。
+type journal struct {
+	lock sync.Mutex
+	file afero.File
+}
+
+// openJournal 打开 saveDir 中的导出日志，resume=false 时清空上一次的记录。
+func openJournal(saveDir string, resume bool) (*journal, error) {
+	err := app.Fs.MkdirAll(saveDir, 0o755)
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flag |= os.O_TRUNC
+	}
+	file, err := app.Fs.OpenFile(filepath.Join(saveDir, journalFile), flag, 0o644)
+	if err != nil {
+		return nil, oops.Wrapf(err, "打开导出日志失败")
+	}
+	return &journal{file: file}, nil
+}
+
+// write 追加一条记录，写入失败不影响导出下载，所以忽略错误。
+func (j *journal) write(entry journalEntry) {
+	if j == nil {
+		return
+	}
+	entry.Time = time.Now().UnixMilli()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_, _ = j.file.Write(append(data, '\n'))
+}
+
+func (j *journal) close() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_ = j.file.Close()
+}
+
+// loadJournal 读取 saveDir 中的导出日志，返回每个文档（以文件保存路径为key）最后的状态，文件不存在时返回nil。
+// 最后一条记录没有导出任务ID时，沿用之前记录的导出任务ID。
+func loadJournal(saveDir string) (map[string]*journalEntry, error) {
+	filePath := filepath.Join(saveDir, journalFile)
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return nil, oops.Wrap(err)
+	}
+	return readJournal(filePath)
+}
+
+// readJournal 读取指定的导出日志文件，返回每个文档（以文件保存路径为key）最后的状态。
+func readJournal(filePath string) (map[string]*journalEntry, error) {
+	file, err := app.Fs.Open(filePath)
+	if err != nil {
+		return nil, oops.Wrapf(err, "读取导出日志失败")
+	}
+	defer file.Close()
+	states := map[string]*journalEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// 程序崩溃时最后一行可能只写了一半，跳过即可
+			continue
+		}
+		state, ok := states[entry.FilePath]
+		if ok && entry.Ticket == "" && state.Token == entry.Token {
+			entry.Ticket = state.Ticket
+		}
+		states[entry.FilePath] = &entry
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, oops.Wrapf(err, "读取导出日志失败")
+	}
+	return states, nil
+}
+
+// resume 根据上一次的导出日志恢复进度，返回上一次已完成的文档（以文件保存路径为key）。
+// 上一次已完成且本地文件仍存在的文档标记为已跳过；上一次已创建导出任务但未完成的文档，记录导出任务ID以便继续查询导出结果。
+func (t *TaskImpl) resume(canDownloadList []*DocumentInfo, saveDir string) (map[string]bool, error) {
+	states, err := loadJournal(saveDir)
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	finished := map[string]bool{}
+	t.tickets = map[string]string{}
+	for _, di := range canDownloadList {
+		state, ok := states[di.FilePath]
+		if !ok || di.Status == progress.StatusSkipped || state.Token != di.Token {
+			continue
+		}
+		switch state.Status {
+		case progress.StatusCompleted, progress.StatusSkipped:
+			yes, err := app.Fs.Exists(di.FilePath)
+			if err != nil {
+				return nil, oops.Wrap(err)
+			}
+			if yes {
+				di.Status = progress.StatusSkipped
+				finished[di.FilePath] = true
+			}
+		case progress.StatusFailed:
+			// 失败的文档重新导出
+		default:
+			if state.Ticket != "" {
+				t.tickets[di.FilePath] = state.Ticket
+			}
+		}
+	}
+	return finished, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+func TestJournal(t *testing.T) {
+	useMemMapFs()
+	// 文件不存在时返回nil
+	states, err := loadJournal("/tmp/docs")
+	require.NoError(t, err)
+	require.Nil(t, states)
+
+	j, err := openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/a.docx", Token: "a", Status: progress.StatusExporting, Ticket: "ticket_a"})
+	j.write(journalEntry{FilePath: "/tmp/docs/a.docx", Token: "a", Status: progress.StatusExporting})
+	j.write(journalEntry{FilePath: "/tmp/docs/b.docx", Token: "b", Status: progress.StatusCompleted})
+	j.close()
+
+	// resume=true 时追加记录
+	j, err = openJournal("/tmp/docs", true)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/c.docx", Token: "c", Status: progress.StatusFailed, Msg: "失败了"})
+	j.close()
+	// 模拟程序崩溃时只写了一半的记录
+	file, err := app.Fs.OpenFile("/tmp/docs/export-journal.jsonl", os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"time":1,"filePath":"/tmp/docs/d.do`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	states, err = loadJournal("/tmp/docs")
+	require.NoError(t, err)
+	require.Len(t, states, 3)
+	require.Equal(t, progress.StatusExporting, states["/tmp/docs/a.docx"].Status)
+	require.Equal(t, "ticket_a", states["/tmp/docs/a.docx"].Ticket, "沿用之前记录的导出任务ID")
+	require.Equal(t, progress.StatusCompleted, states["/tmp/docs/b.docx"].Status)
+	require.Equal(t, "失败了", states["/tmp/docs/c.docx"].Msg)
+	require.Positive(t, states["/tmp/docs/c.docx"].Time)
+
+	// resume=false 时清空上一次的记录
+	j, err = openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.close()
+	states, err = loadJournal("/tmp/docs")
+	require.NoError(t, err)
+	require.Empty(t, states)
+
+	// nil 时不做任何操作
+	var nilJournal *journal
+	nilJournal.write(journalEntry{})
+	nilJournal.close()
+
+	// 只读文件系统打开失败
+	useFs(&afero.Afero{Fs: afero.NewReadOnlyFs(afero.NewMemMapFs())})
+	_, err = openJournal("/tmp/docs", false)
+	require.Error(t, err)
+	useMemMapFs()
+}
+
+func TestTaskImpl_resume(t *testing.T) {
+	useMemMapFs()
+	j, err := openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/completed.docx", Token: "completed", Status: progress.StatusCompleted})
+	j.write(journalEntry{FilePath: "/tmp/docs/deleted.docx", Token: "deleted", Status: progress.StatusCompleted})
+	j.write(journalEntry{FilePath: "/tmp/docs/changed.docx", Token: "old", Status: progress.StatusCompleted})
+	j.write(journalEntry{FilePath: "/tmp/docs/exporting.docx", Token: "exporting", Status: progress.StatusExporting, Ticket: "ticket"})
+	j.write(journalEntry{FilePath: "/tmp/docs/exporting.docx", Token: "exporting", Status: progress.StatusDownloading})
+	j.write(journalEntry{FilePath: "/tmp/docs/failed.docx", Token: "failed", Status: progress.StatusExporting, Ticket: "ticket"})
+	j.write(journalEntry{FilePath: "/tmp/docs/failed.docx", Token: "failed", Status: progress.StatusFailed})
+	j.close()
+	for _, name := range []string{"completed", "changed"} {
+		err = app.Fs.WriteFile("/tmp/docs/"+name+".docx", []byte(name), 0o644)
+		require.NoError(t, err)
+	}
+
+	var list []*DocumentInfo
+	for _, token := range []string{"completed", "deleted", "new", "exporting", "failed"} {
+		list = append(list, &DocumentInfo{Token: token, FilePath: "/tmp/docs/" + token + ".docx", CanDownload: true})
+	}
+	list = append(list, &DocumentInfo{Token: "new", FilePath: "/tmp/docs/changed.docx", CanDownload: true})
+
+	task := &TaskImpl{}
+	finished, err := task.resume(list, "/tmp/docs")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"/tmp/docs/completed.docx": true}, finished)
+	require.Equal(t, map[string]string{"/tmp/docs/exporting.docx": "ticket"}, task.tickets)
+	statuses := make([]string, 0, len(list))
+	for _, di := range list {
+		statuses = append(statuses, string(di.Status))
+	}
+	require.Equal(t, "s,,,,,", strings.Join(statuses, ","))
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"archive/zip"
+	"bytes"
+	"html"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// feishuLinkPattern 匹配文档中指向飞书云文档的链接，第1个分组为文档token或知识库节点token。
+// 链接中的查询参数和锚点（如块ID）一并匹配，改写时一起替换掉。
+var feishuLinkPattern = regexp.MustCompile(
+	`https?://[A-Za-z0-9.-]+\.(?:feishu\.cn|larksuite\.com|larkoffice\.com)/` +
+		`(?:wiki|docx|docs|sheets|base|mindnotes|file|slides|drive/folder)/([A-Za-z0-9]+)` +
+		`(?:[?#][^\s"'<>()\[\]]*)?`)
+
+// docxLinkFiles docx文件中保存超链接的文件，超链接地址保存在关系文件中，文档中的域代码也可能包含链接。
+var docxLinkFiles = map[string]bool{
+	"word/document.xml":            true,
+	"word/_rels/document.xml.rels": true,
+}
+
+// externalLink 指向本次未导出的文档的链接。
+type externalLink struct {
+	FilePath string // 包含该链接的本地文件
+	URL      string // 链接地址
+}
+
+// linkRewriter 将文档中指向飞书云文档的链接改写为本地相对路径。
+type linkRewriter struct {
+	targets   map[string]string // 文档token和知识库节点token到文件保存路径的映射
+	external  []externalLink    // 未导出的文档的链接
+	rewritten int               // 已改写的链接数量
+}
+
+// newLinkRewriter 根据平铺的文档列表创建链接改写器，只有已经保存在本地的文档才作为链接的目标。
+func newLinkRewriter(infoList []*DocumentInfo) *linkRewriter {
+	targets := map[string]string{}
+	for _, di := range infoList {
+		// 按工作表拆分出的文件与表格本身的token相同，链接指向表格的目录
+		if di.FilePath == "" || di.SubID != "" || di.Excluded || !savedLocally(di) {
+			continue
+		}
+		if di.Token != "" {
+			targets[di.Token] = di.FilePath
+		}
+		if di.NodeToken != "" {
+			targets[di.NodeToken] = di.FilePath
+		}
+	}
+	return &linkRewriter{targets: targets}
+}
+
+// savedLocally 文档是否已经保存在本地：目录已经创建，或文档已下载完成、未变更跳过。
+// 导出失败、被中断或未导出的文档在本地没有文件，指向它们的链接保持不变。
+func savedLocally(di *DocumentInfo) bool {
+	if di.isDir() {
+		yes, err := app.Fs.DirExists(di.FilePath)
+		return err == nil && yes
+	}
+	if !di.CanDownload {
+		return false
+	}
+	statusLock.Lock()
+	status := di.Status
+	statusLock.Unlock()
+	return status == progress.StatusCompleted || status == progress.StatusSkipped
+}
+
+// rewriteLinks 下载完成后，将Markdown文件（以及可选的docx文件）中指向本次已导出文档的链接改写为本地相对路径，
+// 指向本次未导出文档的链接保持不变，并打印出来。
+func rewriteLinks(logWriter io.Writer, infoList []*DocumentInfo, withDocx bool) error {
+	rw := newLinkRewriter(infoList)
+	var count int
+	for _, di := range infoList {
+		var processed bool
+		var err error
+		switch {
+		case !di.CanDownload || di.Excluded:
+			continue
+		case di.FileExtension == constant.FileExtMarkdown:
+			processed, err = rw.rewriteFile(di.FilePath, rw.rewriteMarkdown)
+		case withDocx && di.FileExtension == constant.FileExtDocx:
+			processed, err = rw.rewriteFile(di.FilePath, rw.rewriteDocx)
+		default:
+			continue
+		}
+		if err != nil {
+			return oops.Wrapf(err, "改写文档链接失败: %s", di.FilePath)
+		}
+		if processed {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	// 同一个文件中多次出现的相同链接只打印一次
+	rw.external = lo.Uniq(rw.external)
+	app.Fprintf(logWriter, "已处理文件数量: %d, 改写为本地路径的链接数量: %d, 未导出的文档链接数量: %d\n", count, rw.rewritten, len(rw.external))
+	for _, link := range rw.external {
+		app.Fprintf(logWriter, "  %s -> %s\n", link.FilePath, link.URL)
+	}
+	return nil
+}
+
+// rewriteFile 改写本地文件，文件不存在（如下载失败）时跳过并返回false，内容没有变化时不重写文件。
+func (rw *linkRewriter) rewriteFile(filePath string, rewrite func(filePath string, data []byte) ([]byte, error)) (bool, error) {
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return false, oops.Wrap(err)
+	}
+	data, err := app.Fs.ReadFile(filePath)
+	if err != nil {
+		return false, oops.Wrap(err)
+	}
+	rewritten, err := rewrite(filePath, data)
+	if err != nil || bytes.Equal(data, rewritten) {
+		return true, oops.Wrap(err)
+	}
+	return true, oops.Wrap(app.Fs.WriteFile(filePath, rewritten, 0o644))
+}
+
+// rewriteMarkdown 改写Markdown中的链接。
+func (rw *linkRewriter) rewriteMarkdown(filePath string, data []byte) ([]byte, error) {
+	return rw.replace(filePath, data, func(link string) string { return link }), nil
+}
+
+// rewriteDocx 改写docx中的超链接，docx是zip压缩包，只改写保存超链接的文件，其他文件原样复制。
+func (rw *linkRewriter) rewriteDocx(filePath string, data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	var changed bool
+	for _, f := range zr.File {
+		if !docxLinkFiles[f.Name] {
+			if err = zw.Copy(f); err != nil {
+				return nil, oops.Wrap(err)
+			}
+			continue
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		// xml中的路径需要转义
+		rewritten := rw.replace(filePath, content, html.EscapeString)
+		changed = changed || !bytes.Equal(content, rewritten)
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: f.Method, Modified: f.Modified})
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		if _, err = w.Write(rewritten); err != nil {
+			return nil, oops.Wrap(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	if !changed {
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// replace 将指向已导出文档的链接替换为相对于当前文件的路径，escape 用于转义替换后的路径。
+func (rw *linkRewriter) replace(filePath string, data []byte, escape func(string) string) []byte {
+	dir := filepath.Dir(filePath)
+	return feishuLinkPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		token := string(feishuLinkPattern.FindSubmatch(match)[1])
+		target, ok := rw.targets[token]
+		if !ok {
+			rw.external = append(rw.external, externalLink{FilePath: filePath, URL: html.UnescapeString(string(match))})
+			return match
+		}
+		if target == filePath {
+			// 链接到自己时只保留文件名
+			target = filepath.Base(filePath)
+		} else if rel, err := filepath.Rel(dir, target); err == nil {
+			target = rel
+		}
+		rw.rewritten++
+		return []byte(escape((&url.URL{Path: filepath.ToSlash(target)}).EscapedPath()))
+	})
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return data, oops.Wrap(err)
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+func TestFeishuLinkPattern(t *testing.T) {
+	tests := []struct {
+		text  string
+		match string
+		token string
+	}{
+		{"见 https://sample.feishu.cn/wiki/wikToken1 。", "https://sample.feishu.cn/wiki/wikToken1", "wikToken1"},
+		{"[a](https://sample.feishu.cn/docx/docxToken?from=from_copylink#part)", "https://sample.feishu.cn/docx/docxToken?from=from_copylink#part", "docxToken"},
+		{`Target="https://sample.larksuite.com/sheets/shtToken?a=1&amp;b=2"`, "https://sample.larksuite.com/sheets/shtToken?a=1&amp;b=2", "shtToken"},
+		{"https://sample.feishu.cn/drive/folder/fldToken", "https://sample.feishu.cn/drive/folder/fldToken", "fldToken"},
+		{"https://example.com/wiki/xxx", "", ""},
+		{"https://sample.feishu.cn/drive/home/", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			match := feishuLinkPattern.FindStringSubmatch(tt.text)
+			if tt.match == "" {
+				require.Nil(t, match)
+				return
+			}
+			require.Equal(t, tt.match, match[0])
+			require.Equal(t, tt.token, match[1])
+		})
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	useMemMapFs()
+	infoList := []*DocumentInfo{
+		{Type: constant.DocTypeFolder, Token: "fldToken", FilePath: "/tmp/docs/目录"},
+		{Type: constant.DocTypeDocx, Token: "docx1", NodeToken: "wik1", CanDownload: true, FileExtension: constant.FileExtMarkdown, FilePath: "/tmp/docs/目录/文档 1.md",
+			Status: progress.StatusCompleted},
+		{Type: constant.DocTypeDocx, Token: "docx2", CanDownload: true, FileExtension: constant.FileExtMarkdown, FilePath: "/tmp/docs/文档2.md",
+			Status: progress.StatusSkipped},
+		{Type: constant.DocTypeDocx, Token: "docx3", CanDownload: true, FileExtension: constant.FileExtDocx, FilePath: "/tmp/docs/文档3.docx",
+			Status: progress.StatusCompleted},
+		{Type: constant.DocTypeDocx, Token: "docx4", CanDownload: true, FileExtension: constant.FileExtMarkdown, FilePath: "/tmp/docs/下载失败.md",
+			Status: progress.StatusFailed},
+		{Type: constant.DocTypeMindNote, Token: "mindnote", FilePath: "/tmp/docs/思维笔记"},
+		{Type: constant.DocTypeDocx, Token: "docx5", CanDownload: true, FileExtension: constant.FileExtMarkdown, FilePath: "/tmp/docs/已中断.md",
+			Status: progress.StatusInterrupted},
+		{Type: constant.DocTypeDocx, Token: "docx6", CanDownload: true, FileExtension: constant.FileExtMarkdown, FilePath: "/tmp/docs/已排除.md",
+			Status: progress.StatusCompleted, Excluded: true},
+		{Type: constant.DocTypeFolder, Token: "emptyFld", FilePath: "/tmp/docs/空目录"},
+	}
+	md1 := "[自己](https://sample.feishu.cn/docx/docx1#block)\n" +
+		"[文档2](https://sample.feishu.cn/docx/docx2?from=copylink)\n" +
+		"[外部](https://sample.feishu.cn/wiki/other)\n" +
+		"[外部](https://sample.feishu.cn/wiki/other)\n" +
+		"[思维笔记](https://sample.feishu.cn/mindnotes/mindnote)\n" +
+		"[失败](https://sample.feishu.cn/docx/docx4)\n" +
+		"[中断](https://sample.feishu.cn/docx/docx5)\n" +
+		"[排除](https://sample.feishu.cn/docx/docx6)\n" +
+		"[空目录](https://sample.feishu.cn/drive/folder/emptyFld)\n"
+	md2 := "[文档1](https://sample.feishu.cn/wiki/wik1)\n[目录](https://sample.feishu.cn/drive/folder/fldToken)\n"
+	require.NoError(t, app.Fs.WriteFile(infoList[1].FilePath, []byte(md1), 0o644))
+	require.NoError(t, app.Fs.WriteFile(infoList[2].FilePath, []byte(md2), 0o644))
+	docx := newTestDocx(t, map[string]string{
+		"word/document.xml":            `<w:document>https://sample.feishu.cn/docx/docx2</w:document>`,
+		"word/_rels/document.xml.rels": `<Relationship Target="https://sample.feishu.cn/wiki/wik1?a=1&amp;b=2" TargetMode="External"/>`,
+		"word/styles.xml":              `<w:styles>https://sample.feishu.cn/docx/docx2</w:styles>`,
+	})
+	require.NoError(t, app.Fs.WriteFile(infoList[3].FilePath, docx, 0o644))
+
+	// 不改写docx
+	var out strings.Builder
+	err := rewriteLinks(&out, infoList, false)
+	require.NoError(t, err)
+	require.Equal(t, "已处理文件数量: 2, 改写为本地路径的链接数量: 4, 未导出的文档链接数量: 6\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/wiki/other\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/mindnotes/mindnote\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/docx/docx4\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/docx/docx5\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/docx/docx6\n"+
+		"  /tmp/docs/目录/文档 1.md -> https://sample.feishu.cn/drive/folder/emptyFld\n", out.String())
+	data, err := app.Fs.ReadFile(infoList[1].FilePath)
+	require.NoError(t, err)
+	require.Equal(t, "[自己](%E6%96%87%E6%A1%A3%201.md)\n"+
+		"[文档2](../%E6%96%87%E6%A1%A32.md)\n"+
+		"[外部](https://sample.feishu.cn/wiki/other)\n"+
+		"[外部](https://sample.feishu.cn/wiki/other)\n"+
+		"[思维笔记](https://sample.feishu.cn/mindnotes/mindnote)\n"+
+		"[失败](https://sample.feishu.cn/docx/docx4)\n"+
+		"[中断](https://sample.feishu.cn/docx/docx5)\n"+
+		"[排除](https://sample.feishu.cn/docx/docx6)\n"+
+		"[空目录](https://sample.feishu.cn/drive/folder/emptyFld)\n", string(data))
+	data, err = app.Fs.ReadFile(infoList[2].FilePath)
+	require.NoError(t, err)
+	require.Equal(t, "[文档1](%E7%9B%AE%E5%BD%95/%E6%96%87%E6%A1%A3%201.md)\n[目录](%E7%9B%AE%E5%BD%95)\n", string(data))
+	data, err = app.Fs.ReadFile(infoList[3].FilePath)
+	require.NoError(t, err)
+	require.Equal(t, docx, data)
+
+	// 改写docx，只改写保存超链接的文件
+	out.Reset()
+	err = rewriteLinks(&out, infoList, true)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "已处理文件数量: 3, 改写为本地路径的链接数量: 2, 未导出的文档链接数量: 6\n")
+	data, err = app.Fs.ReadFile(infoList[3].FilePath)
+	require.NoError(t, err)
+	files := readTestDocx(t, data)
+	require.Equal(t, `<w:document>%E6%96%87%E6%A1%A32.md</w:document>`, files["word/document.xml"])
+	require.Equal(t, `<Relationship Target="%E7%9B%AE%E5%BD%95/%E6%96%87%E6%A1%A3%201.md" TargetMode="External"/>`, files["word/_rels/document.xml.rels"])
+	require.Equal(t, `<w:styles>https://sample.feishu.cn/docx/docx2</w:styles>`, files["word/styles.xml"])
+
+	// 没有需要处理的文件
+	out.Reset()
+	err = rewriteLinks(&out, infoList[4:], true)
+	require.NoError(t, err)
+	require.Empty(t, out.String())
+
+	// docx文件损坏
+	require.NoError(t, app.Fs.WriteFile(infoList[3].FilePath, []byte("not a zip"), 0o644))
+	err = rewriteLinks(&out, infoList, true)
+	require.ErrorContains(t, err, "改写文档链接失败: /tmp/docs/文档3.docx")
+}
+
+func newTestDocx(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"word/document.xml", "word/_rels/document.xml.rels", "word/styles.xml"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func readTestDocx(t *testing.T, data []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		content, err := readZipFile(f)
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+	return files
+}
@@ -0,0 +1,367 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+	"github.com/spf13/cast"
+)
+
+// codeLanguages 代码块语言，key为飞书文档代码块的语言编号。
+// https://open.feishu.cn/document/server-docs/docs/docs/docx-v1/document-block/block-data-structure
+var codeLanguages = map[int]string{
+	1: "", 2: "abap", 3: "ada", 4: "apache", 5: "apex", 6: "assembly", 7: "bash", 8: "csharp", 9: "cpp", 10: "c",
+	11: "cobol", 12: "css", 13: "coffeescript", 14: "d", 15: "dart", 16: "delphi", 17: "django", 18: "dockerfile", 19: "erlang", 20: "fortran",
+	21: "foxpro", 22: "go", 23: "groovy", 24: "html", 25: "htmlbars", 26: "http", 27: "haskell", 28: "json", 29: "java", 30: "javascript",
+	31: "julia", 32: "kotlin", 33: "latex", 34: "lisp", 35: "logo", 36: "lua", 37: "matlab", 38: "makefile", 39: "markdown", 40: "nginx",
+	41: "objectivec", 42: "openedgeabl", 43: "php", 44: "perl", 45: "postscript", 46: "powershell", 47: "prolog", 48: "protobuf", 49: "python", 50: "r",
+	51: "rpg", 52: "ruby", 53: "rust", 54: "sas", 55: "scss", 56: "sql", 57: "scala", 58: "scheme", 59: "scratch", 60: "shell",
+	61: "swift", 62: "thrift", 63: "typescript", 64: "vbscript", 65: "vbnet", 66: "xml", 67: "yaml", 68: "cmake", 69: "diff", 70: "gherkin",
+	71: "graphql", 72: "glsl", 73: "properties", 74: "solidity", 75: "toml",
+}
+
+// markdownEscaper 转义普通文本中的Markdown标记字符。
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// markdownRenderer 将文档块树渲染为GitHub风格的Markdown（GFM）。
+type markdownRenderer struct {
+	blocks map[string]*larkdocx.Block // 全部文档块，key为block_id
+	media  map[string]string          // 图片和附件的链接地址，key为素材token，没有时直接使用token
+}
+
+// renderMarkdown 将【获取文档所有块】API返回的文档块渲染为Markdown。
+// 第一个块为文档根块（page），其余块通过 children 挂在根块下。
+// media 为图片和附件的链接地址，key为素材token。
+func renderMarkdown(blocks []*larkdocx.Block, media map[string]string) []byte {
+	if len(blocks) == 0 {
+		return nil
+	}
+	r := &markdownRenderer{blocks: make(map[string]*larkdocx.Block, len(blocks)), media: media}
+	for _, b := range blocks {
+		r.blocks[larkcore.StringValue(b.BlockId)] = b
+	}
+	page := blocks[0]
+	var sb strings.Builder
+	if title := r.renderText(page.Page); title != "" {
+		sb.WriteString("# " + title + "\n\n")
+	}
+	content := r.renderChildren(page.Children)
+	if content != "" {
+		sb.WriteString(content + "\n")
+	}
+	return []byte(sb.String())
+}
+
+// renderChildren 渲染子块，列表项之间只换一行，其余块之间空一行。
+func (r *markdownRenderer) renderChildren(ids []string) string {
+	var sb strings.Builder
+	var prevIsListItem bool
+	var orderedIndex int
+	for _, id := range ids {
+		b, ok := r.blocks[id]
+		if !ok {
+			continue
+		}
+		if b.Ordered != nil {
+			orderedIndex++
+		} else {
+			orderedIndex = 0
+		}
+		content := r.renderBlock(b, orderedIndex)
+		if content == "" {
+			continue
+		}
+		isListItem := b.Bullet != nil || b.Ordered != nil || b.Todo != nil
+		if sb.Len() > 0 {
+			if isListItem && prevIsListItem {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(content)
+		prevIsListItem = isListItem
+	}
+	return sb.String()
+}
+
+// renderBlock 渲染单个块，orderedIndex 为有序列表项在连续的有序列表中的序号。
+func (r *markdownRenderer) renderBlock(b *larkdocx.Block, orderedIndex int) string {
+	switch {
+	case b.Text != nil:
+		return r.withChildren(r.renderText(b.Text), "", b.Children)
+	case b.Heading1 != nil:
+		return r.renderHeading(1, b.Heading1)
+	case b.Heading2 != nil:
+		return r.renderHeading(2, b.Heading2)
+	case b.Heading3 != nil:
+		return r.renderHeading(3, b.Heading3)
+	case b.Heading4 != nil:
+		return r.renderHeading(4, b.Heading4)
+	case b.Heading5 != nil:
+		return r.renderHeading(5, b.Heading5)
+	case b.Heading6 != nil:
+		return r.renderHeading(6, b.Heading6)
+	case b.Heading7 != nil:
+		return r.renderHeading(7, b.Heading7)
+	case b.Heading8 != nil:
+		return r.renderHeading(8, b.Heading8)
+	case b.Heading9 != nil:
+		return r.renderHeading(9, b.Heading9)
+	case b.Bullet != nil:
+		return r.withChildren("- "+r.renderText(b.Bullet), "  ", b.Children)
+	case b.Ordered != nil:
+		marker := fmt.Sprintf("%d. ", orderedIndex)
+		if b.Ordered.Style != nil && b.Ordered.Style.Sequence != nil && *b.Ordered.Style.Sequence != "auto" {
+			marker = *b.Ordered.Style.Sequence + ". "
+		}
+		return r.withChildren(marker+r.renderText(b.Ordered), strings.Repeat(" ", len(marker)), b.Children)
+	case b.Todo != nil:
+		marker := "- [ ] "
+		if b.Todo.Style != nil && larkcore.BoolValue(b.Todo.Style.Done) {
+			marker = "- [x] "
+		}
+		return r.withChildren(marker+r.renderText(b.Todo), "  ", b.Children)
+	case b.Code != nil:
+		return r.renderCode(b.Code)
+	case b.Quote != nil:
+		return quoteLines(r.withChildren(r.renderText(b.Quote), "", b.Children))
+	case b.QuoteContainer != nil, b.Callout != nil:
+		return quoteLines(r.renderChildren(b.Children))
+	case b.Equation != nil:
+		return "$$\n" + r.renderPlainText(b.Equation) + "\n$$"
+	case b.Divider != nil:
+		return "---"
+	case b.Table != nil:
+		return r.renderTable(b.Table)
+	case b.Image != nil:
+		return fmt.Sprintf("![](%s)", r.mediaLink(larkcore.StringValue(b.Image.Token)))
+	case b.File != nil:
+		return fmt.Sprintf("[%s](%s)", markdownEscaper.Replace(larkcore.StringValue(b.File.Name)), r.mediaLink(larkcore.StringValue(b.File.Token)))
+	case b.Grid != nil, b.GridColumn != nil, b.View != nil:
+		// 分栏和视图只是容器，按顺序渲染其中的内容
+		return r.renderChildren(b.Children)
+	default:
+		return fmt.Sprintf("<!-- 暂不支持转换为Markdown的文档块, block_type: %d -->", larkcore.IntValue(b.BlockType))
+	}
+}
+
+func (r *markdownRenderer) renderHeading(level int, text *larkdocx.Text) string {
+	// Markdown最多支持六级标题
+	return strings.Repeat("#", min(level, 6)) + " " + r.renderText(text)
+}
+
+func (r *markdownRenderer) renderCode(code *larkdocx.Text) string {
+	var language string
+	if code.Style != nil {
+		language = codeLanguages[larkcore.IntValue(code.Style.Language)]
+	}
+	content := r.renderPlainText(code)
+	// 代码中包含```时，加长围栏
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + strings.TrimSuffix(content, "\n") + "\n" + fence
+}
+
+// renderTable 渲染表格，GFM表格必须有标题行，所以第一行总是作为标题行。
+// 单元格中的多个块用<br>连接，合并的单元格按拆分后的单元格渲染。
+func (r *markdownRenderer) renderTable(table *larkdocx.Table) string {
+	if table.Property == nil {
+		return ""
+	}
+	columnSize := larkcore.IntValue(table.Property.ColumnSize)
+	if columnSize == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i := 0; i < len(table.Cells); i += columnSize {
+		row := make([]string, columnSize)
+		for j := 0; j < columnSize && i+j < len(table.Cells); j++ {
+			if cell, ok := r.blocks[table.Cells[i+j]]; ok {
+				content := r.renderChildren(cell.Children)
+				content = strings.ReplaceAll(content, "\n\n", "<br>")
+				row[j] = strings.ReplaceAll(content, "\n", "<br>")
+			}
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |")
+		if i == 0 {
+			sb.WriteString("\n|" + strings.Repeat(" --- |", columnSize))
+		}
+	}
+	return sb.String()
+}
+
+// withChildren 将子块缩进后追加到当前块的内容后面，如列表项中嵌套的列表。
+func (r *markdownRenderer) withChildren(content, indent string, children []string) string {
+	if len(children) == 0 {
+		return content
+	}
+	childContent := r.renderChildren(children)
+	if childContent == "" {
+		return content
+	}
+	if indent == "" {
+		return content + "\n\n" + childContent
+	}
+	return content + "\n" + indentLines(childContent, indent)
+}
+
+// renderText 渲染带样式的文本。
+func (r *markdownRenderer) renderText(text *larkdocx.Text) string {
+	if text == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, e := range text.Elements {
+		switch {
+		case e.TextRun != nil:
+			content := larkcore.StringValue(e.TextRun.Content)
+			style := e.TextRun.TextElementStyle
+			if style != nil && larkcore.BoolValue(style.InlineCode) {
+				sb.WriteString(wrapStyle(inlineCode(content), style, false))
+				continue
+			}
+			sb.WriteString(wrapStyle(markdownEscaper.Replace(content), style, true))
+		case e.MentionDoc != nil:
+			title := markdownEscaper.Replace(larkcore.StringValue(e.MentionDoc.Title))
+			link := fmt.Sprintf("[%s](%s)", title, unescapeURL(larkcore.StringValue(e.MentionDoc.Url)))
+			sb.WriteString(wrapStyle(link, e.MentionDoc.TextElementStyle, false))
+		case e.MentionUser != nil:
+			sb.WriteString("@" + larkcore.StringValue(e.MentionUser.UserId))
+		case e.Equation != nil:
+			sb.WriteString("$" + strings.TrimSuffix(larkcore.StringValue(e.Equation.Content), "\n") + "$")
+		case e.Reminder != nil:
+			expireTime := cast.ToInt64(larkcore.StringValue(e.Reminder.ExpireTime))
+			sb.WriteString(time.UnixMilli(expireTime).Format("2006-01-02 15:04"))
+		case e.File != nil:
+			sb.WriteString(fmt.Sprintf("[附件](%s)", r.mediaLink(larkcore.StringValue(e.File.FileToken))))
+		}
+	}
+	return sb.String()
+}
+
+// mediaLink 返回图片或附件的链接地址，路径中的空格等字符需要编码。
+func (r *markdownRenderer) mediaLink(token string) string {
+	link, ok := r.media[token]
+	if !ok {
+		return token
+	}
+	return (&url.URL{Path: link}).EscapedPath()
+}
+
+// renderPlainText 渲染不带样式的文本，用于代码块和公式。
+func (r *markdownRenderer) renderPlainText(text *larkdocx.Text) string {
+	var sb strings.Builder
+	for _, e := range text.Elements {
+		switch {
+		case e.TextRun != nil:
+			sb.WriteString(larkcore.StringValue(e.TextRun.Content))
+		case e.Equation != nil:
+			sb.WriteString(larkcore.StringValue(e.Equation.Content))
+		case e.MentionDoc != nil:
+			sb.WriteString(unescapeURL(larkcore.StringValue(e.MentionDoc.Url)))
+		}
+	}
+	return sb.String()
+}
+
+// wrapStyle 按文本局部样式包装内容，withLink=true 时处理超链接。
+// 样式标记不能包含首尾空白，否则不生效，所以把首尾空白移到标记外面。
+func wrapStyle(content string, style *larkdocx.TextElementStyle, withLink bool) string {
+	if style == nil || strings.TrimSpace(content) == "" {
+		return content
+	}
+	trimmed := strings.TrimSpace(content)
+	start := strings.Index(content, trimmed)
+	prefix, suffix := content[:start], content[start+len(trimmed):]
+	if withLink && style.Link != nil {
+		trimmed = fmt.Sprintf("[%s](%s)", trimmed, unescapeURL(larkcore.StringValue(style.Link.Url)))
+	}
+	if larkcore.BoolValue(style.Bold) {
+		trimmed = "**" + trimmed + "**"
+	}
+	if larkcore.BoolValue(style.Italic) {
+		trimmed = "*" + trimmed + "*"
+	}
+	if larkcore.BoolValue(style.Strikethrough) {
+		trimmed = "~~" + trimmed + "~~"
+	}
+	if larkcore.BoolValue(style.Underline) {
+		trimmed = "<u>" + trimmed + "</u>"
+	}
+	return prefix + trimmed + suffix
+}
+
+// inlineCode 渲染行内代码，内容中包含`时加长标记。
+func inlineCode(content string) string {
+	mark := "`"
+	for strings.Contains(content, mark) {
+		mark += "`"
+	}
+	if strings.HasPrefix(content, "`") || strings.HasSuffix(content, "`") {
+		return mark + " " + content + " " + mark
+	}
+	return mark + content + mark
+}
+
+// unescapeURL 飞书返回的链接是经过url编码的，解码失败时原样返回。
+// 只解码%XX，链接中原有的+不能当作空格。
+func unescapeURL(u string) string {
+	unescaped, err := url.PathUnescape(u)
+	if err != nil {
+		return u
+	}
+	return unescaped
+}
+
+// quoteLines 每一行都加上引用标记。
+func quoteLines(content string) string {
+	if content == "" {
+		return ""
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+			continue
+		}
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines 每一行（空行除外）都加上缩进。
+func indentLines(content, indent string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
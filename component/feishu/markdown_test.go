@@ -0,0 +1,123 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"encoding/json"
+	"testing"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// 文档块结构参考 https://open.feishu.cn/document/server-docs/docs/docs/docx-v1/document/list
+const markdownTestBlocks = `[
+  {"block_id": "page", "block_type": 1, "children": ["h1", "text", "h7", "b1", "b2", "o1", "o2", "t1", "t2", "code", "quote", "callout", "divider", "table", "eq", "img", "bitable"],
+   "page": {"elements": [{"text_run": {"content": "文档标题"}}]}},
+  {"block_id": "h1", "block_type": 3, "heading1": {"elements": [{"text_run": {"content": "一级标题"}}]}},
+  {"block_id": "text", "block_type": 2, "text": {"elements": [
+    {"text_run": {"content": "普通"}},
+    {"text_run": {"content": "加粗 ", "text_element_style": {"bold": true}}},
+    {"text_run": {"content": "斜体", "text_element_style": {"italic": true}}},
+    {"text_run": {"content": "删除", "text_element_style": {"strikethrough": true}}},
+    {"text_run": {"content": "a_b", "text_element_style": {"inline_code": true}}},
+    {"text_run": {"content": "链接", "text_element_style": {"link": {"url": "https%3A%2F%2Fexample.com%2Fa%3Fb%3Dc"}}}},
+    {"text_run": {"content": "*星号*"}},
+    {"mention_doc": {"title": "另一篇文档", "url": "https%3A%2F%2Fsample.feishu.cn%2Fdocx%2Fxxx", "token": "xxx", "obj_type": 22}},
+    {"mention_user": {"user_id": "ou_xxx"}},
+    {"equation": {"content": "E=mc^2\n"}}
+  ]}},
+  {"block_id": "h7", "block_type": 9, "heading7": {"elements": [{"text_run": {"content": "七级标题"}}]}},
+  {"block_id": "b1", "block_type": 12, "children": ["b1-1"], "bullet": {"elements": [{"text_run": {"content": "无序1"}}]}},
+  {"block_id": "b1-1", "block_type": 12, "bullet": {"elements": [{"text_run": {"content": "无序1-1"}}]}},
+  {"block_id": "b2", "block_type": 12, "bullet": {"elements": [{"text_run": {"content": "无序2"}}]}},
+  {"block_id": "o1", "block_type": 13, "ordered": {"style": {"sequence": "auto"}, "elements": [{"text_run": {"content": "有序1"}}]}},
+  {"block_id": "o2", "block_type": 13, "ordered": {"elements": [{"text_run": {"content": "有序2"}}]}},
+  {"block_id": "t1", "block_type": 17, "todo": {"style": {"done": true}, "elements": [{"text_run": {"content": "已完成"}}]}},
+  {"block_id": "t2", "block_type": 17, "todo": {"style": {"done": false}, "elements": [{"text_run": {"content": "未完成"}}]}},
+  {"block_id": "code", "block_type": 14, "code": {"style": {"language": 22}, "elements": [{"text_run": {"content": "fmt.Println(\"*_*\")\n"}}]}},
+  {"block_id": "quote", "block_type": 15, "quote": {"elements": [{"text_run": {"content": "引用"}}]}},
+  {"block_id": "callout", "block_type": 19, "children": ["callout-1", "callout-2"], "callout": {"emoji_id": "bulb"}},
+  {"block_id": "callout-1", "block_type": 2, "text": {"elements": [{"text_run": {"content": "高亮块1"}}]}},
+  {"block_id": "callout-2", "block_type": 2, "text": {"elements": [{"text_run": {"content": "高亮块2"}}]}},
+  {"block_id": "divider", "block_type": 22, "divider": {}},
+  {"block_id": "table", "block_type": 31, "children": ["c1", "c2", "c3", "c4"], "table": {"cells": ["c1", "c2", "c3", "c4"], "property": {"row_size": 2, "column_size": 2}}},
+  {"block_id": "c1", "block_type": 32, "children": ["c1-1"], "table_cell": {}},
+  {"block_id": "c1-1", "block_type": 2, "text": {"elements": [{"text_run": {"content": "列1"}}]}},
+  {"block_id": "c2", "block_type": 32, "children": ["c2-1"], "table_cell": {}},
+  {"block_id": "c2-1", "block_type": 2, "text": {"elements": [{"text_run": {"content": "列2"}}]}},
+  {"block_id": "c3", "block_type": 32, "children": ["c3-1", "c3-2"], "table_cell": {}},
+  {"block_id": "c3-1", "block_type": 2, "text": {"elements": [{"text_run": {"content": "a|b"}}]}},
+  {"block_id": "c3-2", "block_type": 2, "text": {"elements": [{"text_run": {"content": "第二行"}}]}},
+  {"block_id": "c4", "block_type": 32, "table_cell": {}},
+  {"block_id": "eq", "block_type": 16, "equation": {"elements": [{"equation": {"content": "a^2+b^2=c^2"}}]}},
+  {"block_id": "img", "block_type": 27, "image": {"token": "imgToken", "width": 100, "height": 100}},
+  {"block_id": "bitable", "block_type": 18, "bitable": {"token": "bitableToken"}}
+]`
+
+func TestRenderMarkdown(t *testing.T) {
+	var blocks []*larkdocx.Block
+	err := json.Unmarshal([]byte(markdownTestBlocks), &blocks)
+	require.NoError(t, err)
+	expected := "# 文档标题\n\n" +
+		"# 一级标题\n\n" +
+		"普通**加粗** *斜体*~~删除~~`a_b`[链接](https://example.com/a?b=c)\\*星号\\*[另一篇文档](https://sample.feishu.cn/docx/xxx)@ou_xxx$E=mc^2$\n\n" +
+		"###### 七级标题\n\n" +
+		"- 无序1\n" +
+		"  - 无序1-1\n" +
+		"- 无序2\n" +
+		"1. 有序1\n" +
+		"2. 有序2\n" +
+		"- [x] 已完成\n" +
+		"- [ ] 未完成\n\n" +
+		"```go\nfmt.Println(\"*_*\")\n```\n\n" +
+		"> 引用\n\n" +
+		"> 高亮块1\n>\n> 高亮块2\n\n" +
+		"---\n\n" +
+		"| 列1 | 列2 |\n| --- | --- |\n| a\\|b<br>第二行 |  |\n\n" +
+		"$$\na^2+b^2=c^2\n$$\n\n" +
+		"![](imgToken)\n\n" +
+		"<!-- 暂不支持转换为Markdown的文档块, block_type: 18 -->\n"
+	require.Equal(t, expected, string(renderMarkdown(blocks, nil)))
+
+	// 没有文档块
+	require.Nil(t, renderMarkdown(nil, nil))
+}
+
+func TestUnescapeURL(t *testing.T) {
+	require.Equal(t, "https://example.com/a?b=c", unescapeURL("https%3A%2F%2Fexample.com%2Fa%3Fb%3Dc"))
+	require.Equal(t, "https://example.com/c++?q=a+b", unescapeURL("https%3A%2F%2Fexample.com%2Fc%2B%2B%3Fq%3Da+b"), "+不能解码为空格")
+	require.Equal(t, "https://example.com/a%zz", unescapeURL("https://example.com/a%zz"), "解码失败时原样返回")
+}
+
+func TestInlineCode(t *testing.T) {
+	require.Equal(t, "`a`", inlineCode("a"))
+	require.Equal(t, "``a`b``", inlineCode("a`b"))
+	require.Equal(t, "`` `a ``", inlineCode("`a"))
+}
+
+func TestWrapStyle(t *testing.T) {
+	style := &larkdocx.TextElementStyle{Bold: larkcore.BoolPtr(true), Underline: larkcore.BoolPtr(true)}
+	require.Equal(t, " <u>**a**</u> ", wrapStyle(" a ", style, true))
+	require.Equal(t, "  ", wrapStyle("  ", style, true))
+	require.Equal(t, "a", wrapStyle("a", nil, true))
+}
+
+func TestRenderCode_fence(t *testing.T) {
+	r := &markdownRenderer{}
+	code := &larkdocx.Text{Elements: []*larkdocx.TextElement{{TextRun: &larkdocx.TextRun{Content: larkcore.StringPtr("```\ncode\n```")}}}}
+	require.Equal(t, "````\n```\ncode\n```\n````", r.renderCode(code))
+}
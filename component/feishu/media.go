@@ -0,0 +1,150 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+const assetsDir = "assets" // 图片和附件的保存目录名，与文档保存在同一级
+
+// mediaRef 文档中引用的素材（图片或附件）。
+type mediaRef struct {
+	token string // 素材token
+	name  string // 素材的文件名，图片没有文件名
+}
+
+// mediaFile 已下载（或正在下载）的素材文件。
+type mediaFile struct {
+	lock sync.Mutex // 同一个素材同时只有一个协程在下载，其他协程等待下载结果
+	path string     // 本地保存路径，下载成功后才设置
+}
+
+// mediaStore 整个导出过程中已下载的素材，按token去重，同一个素材下载成功后不再重复下载。
+// 下载失败（如限流、网络错误）不缓存，之后引用该素材的文档会重新下载。
+type mediaStore struct {
+	files sync.Map // key为素材token，value为 *mediaFile
+}
+
+// collectMedia 找出文档块中引用的所有素材，按出现的顺序去重。
+func collectMedia(blocks []*larkdocx.Block) []mediaRef {
+	var refs []mediaRef
+	seen := map[string]bool{}
+	add := func(token, name string) {
+		if token == "" || seen[token] {
+			return
+		}
+		seen[token] = true
+		refs = append(refs, mediaRef{token: token, name: name})
+	}
+	for _, b := range blocks {
+		switch {
+		case b.Image != nil:
+			add(larkcore.StringValue(b.Image.Token), "")
+		case b.File != nil:
+			add(larkcore.StringValue(b.File.Token), larkcore.StringValue(b.File.Name))
+		}
+		for _, text := range blockTexts(b) {
+			for _, e := range text.Elements {
+				if e.File != nil {
+					add(larkcore.StringValue(e.File.FileToken), "")
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// blockTexts 返回块中所有可能包含行内附件的文本。
+func blockTexts(b *larkdocx.Block) []*larkdocx.Text {
+	texts := []*larkdocx.Text{
+		b.Text, b.Heading1, b.Heading2, b.Heading3, b.Heading4, b.Heading5, b.Heading6, b.Heading7, b.Heading8, b.Heading9,
+		b.Bullet, b.Ordered, b.Todo, b.Quote,
+	}
+	result := texts[:0]
+	for _, text := range texts {
+		if text != nil {
+			result = append(result, text)
+		}
+	}
+	return result
+}
+
+// downloadMedia 下载文档中引用的素材，保存到文档所在目录的 assets 目录中。
+// 返回素材token到链接地址（相对于文档的路径）的映射。
+// 其他文档已下载过的素材不再重复下载，直接链接到已下载的文件。
+func (e *exporter) downloadMedia(ctx context.Context, di *DocumentInfo, refs []mediaRef) (map[string]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	docDir := filepath.Dir(di.FilePath)
+	links := make(map[string]string, len(refs))
+	for i, ref := range refs {
+		value, _ := e.media.files.LoadOrStore(ref.token, &mediaFile{})
+		mf := value.(*mediaFile)
+		mf.lock.Lock()
+		var err error
+		if mf.path == "" {
+			mf.path, err = e.saveMedia(ctx, di, ref, docDir, i+1, len(refs))
+		}
+		path := mf.path
+		mf.lock.Unlock()
+		if err != nil {
+			return nil, oops.Wrapf(err, "下载图片或附件失败: %s", ref.token)
+		}
+		rel, err := filepath.Rel(docDir, path)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		links[ref.token] = filepath.ToSlash(rel)
+	}
+	return links, nil
+}
+
+// saveMedia 下载单个素材，文件名为素材token加上原文件的扩展名。
+func (e *exporter) saveMedia(ctx context.Context, di *DocumentInfo, ref mediaRef, docDir string, index, total int) (string, error) {
+	req := larkdrive.NewDownloadMediaReqBuilder().FileToken(ref.token).Build()
+	resp, err := SendWithRetry(func(count int) (*larkdrive.DownloadMediaResp, error) {
+		e.program.Update(di.FilePath, 0.19, progress.StatusDownloading, "下载图片和附件%d/%d, 请求%d次", index, total, count)
+		return e.client.DriveDownloadMedia(ctx, req)
+	})
+	if err != nil {
+		if resp != nil && !resp.Success() {
+			return "", oops.New(toErrMsg(resp, "下载素材"))
+		}
+		return "", oops.Wrap(err)
+	}
+	ext := filepath.Ext(resp.FileName)
+	if ext == "" {
+		ext = filepath.Ext(ref.name)
+	}
+	filePath := filepath.Join(docDir, assetsDir, ref.token+ext)
+	if err = app.Fs.WriteReader(filePath, resp.File); err != nil {
+		// 删除不完整的文件
+		_ = app.Fs.Remove(filePath)
+		return "", oops.Wrap(err)
+	}
+	return filePath, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+func TestCollectMedia(t *testing.T) {
+	var blocks []*larkdocx.Block
+	err := json.Unmarshal([]byte(`[
+  {"block_id": "page", "block_type": 1, "children": ["img1", "file", "text", "img2"], "page": {"elements": []}},
+  {"block_id": "img1", "block_type": 27, "image": {"token": "imgToken"}},
+  {"block_id": "file", "block_type": 23, "file": {"token": "fileToken", "name": "附件.pdf"}},
+  {"block_id": "text", "block_type": 12, "bullet": {"elements": [{"file": {"file_token": "inlineToken"}}]}},
+  {"block_id": "img2", "block_type": 27, "image": {"token": "imgToken"}},
+  {"block_id": "empty", "block_type": 27, "image": {}}
+]`), &blocks)
+	require.NoError(t, err)
+	expected := []mediaRef{
+		{token: "imgToken"},
+		{token: "fileToken", name: "附件.pdf"},
+		{token: "inlineToken"},
+	}
+	require.Equal(t, expected, collectMedia(blocks))
+	require.Nil(t, collectMedia(nil))
+}
+
+func TestExporter_downloadMedia(t *testing.T) {
+	useMemMapFs()
+	mockClient := NewMockClient(t)
+	mockProgram := NewMockProgram(t)
+	e := &exporter{client: mockClient, program: mockProgram, media: &mediaStore{}}
+	mockProgram.EXPECT().Update(mock.Anything, 0.19, progress.StatusDownloading, "下载图片和附件%d/%d, 请求%d次", mock.Anything, mock.Anything, mock.Anything).Return()
+	newResp := func(fileName, content string) *larkdrive.DownloadMediaResp {
+		return &larkdrive.DownloadMediaResp{File: strings.NewReader(content), FileName: fileName}
+	}
+	newReq := func(token string) *larkdrive.DownloadMediaReq {
+		return larkdrive.NewDownloadMediaReqBuilder().FileToken(token).Build()
+	}
+	mockClient.EXPECT().DriveDownloadMedia(mock.Anything, newReq("imgToken")).Return(newResp("image.png", "png"), nil).Once()
+	mockClient.EXPECT().DriveDownloadMedia(mock.Anything, newReq("fileToken")).Return(newResp("", "pdf"), nil).Once()
+
+	// 第一个文档下载素材
+	di1 := &DocumentInfo{FilePath: "/tmp/docs/a/doc1.md"}
+	links, err := e.downloadMedia(context.Background(), di1, []mediaRef{{token: "imgToken"}, {token: "fileToken", name: "附件.pdf"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"imgToken": "assets/imgToken.png", "fileToken": "assets/fileToken.pdf"}, links)
+	data, err := app.Fs.ReadFile("/tmp/docs/a/assets/imgToken.png")
+	require.NoError(t, err)
+	require.Equal(t, "png", string(data))
+	data, err = app.Fs.ReadFile("/tmp/docs/a/assets/fileToken.pdf")
+	require.NoError(t, err)
+	require.Equal(t, "pdf", string(data))
+
+	// 第二个文档引用同一个素材，不再重复下载，链接到已下载的文件
+	di2 := &DocumentInfo{FilePath: "/tmp/docs/b/doc2.md"}
+	links, err = e.downloadMedia(context.Background(), di2, []mediaRef{{token: "imgToken"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"imgToken": "../a/assets/imgToken.png"}, links)
+
+	// 没有素材
+	links, err = e.downloadMedia(context.Background(), di2, nil)
+	require.NoError(t, err)
+	require.Nil(t, links)
+
+	// 下载失败
+	mockClient.EXPECT().DriveDownloadMedia(mock.Anything, mock.Anything).Return(nil, errors.New("API调用失败")).Once()
+	_, err = e.downloadMedia(context.Background(), di2, []mediaRef{{token: "badToken"}})
+	require.EqualError(t, err, "下载图片或附件失败: badToken: API调用失败")
+	// 下载失败不缓存，之后引用同一个素材的文档重新下载
+	mockClient.EXPECT().DriveDownloadMedia(mock.Anything, newReq("badToken")).Return(newResp("bad.png", "bad"), nil).Once()
+	links, err = e.downloadMedia(context.Background(), di1, []mediaRef{{token: "badToken"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"badToken": "assets/badToken.png"}, links)
+	// 下载成功后不再重复请求
+	links, err = e.downloadMedia(context.Background(), di2, []mediaRef{{token: "badToken"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"badToken": "../a/assets/badToken.png"}, links)
+
+	// API响应不成功
+	resp := &larkdrive.DownloadMediaResp{
+		ApiResp:   &larkcore.ApiResp{StatusCode: 404},
+		CodeError: larkcore.CodeError{Code: 1061004, Msg: "forbidden"},
+	}
+	mockClient.EXPECT().DriveDownloadMedia(mock.Anything, mock.Anything).Return(checkResp(resp, nil)).Once()
+	_, err = e.downloadMedia(context.Background(), di2, []mediaRef{{token: "forbiddenToken"}})
+	require.ErrorContains(t, err, "操作: 下载素材, 响应错误: msg:forbidden,code:1061004")
+}
+
+func TestMarkdownRenderer_mediaLink(t *testing.T) {
+	r := &markdownRenderer{media: map[string]string{"imgToken": "../my docs/assets/imgToken.png"}}
+	require.Equal(t, "../my%20docs/assets/imgToken.png", r.mediaLink("imgToken"))
+	require.Equal(t, "otherToken", r.mediaLink("otherToken"))
+}
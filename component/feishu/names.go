@@ -0,0 +1,210 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// 同级重名文档的文件名区分策略。
+const (
+	DuplicateNamesIndex = "index" // 追加序号，沿用上一次document-tree.json中的文件名，新增的文档按创建时间排序后编号
+	DuplicateNamesToken = "token" // 追加文档token，不依赖上一次的文档树
+)
+
+type (
+	// nameResolver 调整同级文档的文件名，保证同一个文档在多次导出之间保存在同一个路径。
+	nameResolver struct {
+		strategy string
+		previous map[string]string // 上一次文档树中各文档的文件名，key为 nameKey
+	}
+
+	// namedNode 待调整文件名的文档及其基础名称。
+	namedNode struct {
+		*DocumentNode
+		base    string // 基础名称，空文件名的为"未命名xxx"
+		unnamed bool   // 是否空文件名，空文件名的总是追加序号，从1开始
+	}
+)
+
+// resolveNames 递归调整文件名，空文件名调整为"未命名xxxn"格式，同级重名的文件名按 strategy 区分。
+// previous 为上一次的文档树，用于沿用重名文档上一次的文件名，可以为nil。
+// 需要在计算文件保存路径（documentNodesToInfoList）和打印目录结构（printTree）之前调用。
+func resolveNames(dns []*DocumentNode, strategy string, previous []*DocumentNode) {
+	r := &nameResolver{strategy: strategy, previous: map[string]string{}}
+	for _, dn := range previous {
+		for _, di := range documentNodeToInfoList(dn) {
+			if key := nameKey(di); key != "" {
+				r.previous[key] = di.Name
+			}
+		}
+	}
+	r.resolve(dns)
+}
+
+func (r *nameResolver) resolve(dns []*DocumentNode) {
+	nodes := make([]*namedNode, 0, len(dns))
+	counts := map[string]int{}
+	for _, dn := range dns {
+		node := &namedNode{DocumentNode: dn, base: dn.Name}
+		if dn.Name == "" {
+			node.base = unnamedBase(dn.Type)
+			node.unnamed = true
+		}
+		nodes = append(nodes, node)
+		counts[node.base]++
+	}
+	// 按创建时间排序，使编号与接口返回的顺序无关
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return lessCreated(&nodes[i].DocumentInfo, &nodes[j].DocumentInfo)
+	})
+	used := map[string]bool{}
+	if r.strategy == DuplicateNamesToken {
+		for _, node := range nodes {
+			name := node.base
+			if node.unnamed || counts[node.base] > 1 {
+				name = fmt.Sprintf("%s_%s", node.base, nameSuffix(&node.DocumentInfo))
+			}
+			node.Name = claimName(name, used)
+		}
+	} else {
+		r.resolveByIndex(nodes, counts, used)
+	}
+	for _, dn := range dns {
+		r.resolve(dn.Children)
+	}
+}
+
+// resolveByIndex 按序号区分重名的文档：
+//  1. 沿用上一次的文件名，前提是与基础名称一致且未被占用
+//  2. 不重名的文档使用基础名称
+//  3. 其余的按创建时间顺序使用未被占用的最小序号
+func (r *nameResolver) resolveByIndex(nodes []*namedNode, counts map[string]int, used map[string]bool) {
+	var pending []*namedNode
+	for _, node := range nodes {
+		prev, ok := r.previous[nameKey(&node.DocumentInfo)]
+		if ok && !used[prev] && node.matchName(prev) {
+			node.Name = prev
+			used[prev] = true
+			continue
+		}
+		pending = append(pending, node)
+	}
+	var rest []*namedNode
+	for _, node := range pending {
+		if !node.unnamed && counts[node.base] == 1 && !used[node.base] {
+			node.Name = node.base
+			used[node.base] = true
+			continue
+		}
+		rest = append(rest, node)
+	}
+	for _, node := range rest {
+		index := 0
+		if node.unnamed {
+			index = 1
+		}
+		for used[node.indexName(index)] {
+			index++
+		}
+		node.Name = node.indexName(index)
+		used[node.Name] = true
+	}
+}
+
+// indexName 基础名称追加序号，序号为0时即为基础名称。
+func (n *namedNode) indexName(index int) string {
+	if index == 0 {
+		return n.base
+	}
+	return fmt.Sprintf("%s%d", n.base, index)
+}
+
+// matchName 文件名是否为基础名称或基础名称追加序号。
+func (n *namedNode) matchName(name string) bool {
+	if name == n.base {
+		return !n.unnamed
+	}
+	suffix, ok := strings.CutPrefix(name, n.base)
+	if !ok || strings.HasPrefix(suffix, "0") {
+		return false
+	}
+	index, err := strconv.Atoi(suffix)
+	return err == nil && index > 0
+}
+
+// claimName 占用文件名，已被占用时追加序号。
+func claimName(name string, used map[string]bool) string {
+	candidate := name
+	for index := 1; used[candidate]; index++ {
+		candidate = fmt.Sprintf("%s%d", name, index)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// nameKey 用于在多次导出之间识别同一个文档，拆分导出的工作表共用表格的token，需要加上工作表ID。
+func nameKey(di *DocumentInfo) string {
+	if di.Token == "" {
+		return ""
+	}
+	return di.Token + "#" + di.SubID
+}
+
+// nameSuffix 重名时追加的文档标识。
+func nameSuffix(di *DocumentInfo) string {
+	if di.SubID != "" {
+		return di.SubID
+	}
+	return di.Token
+}
+
+// lessCreated 按创建时间排序，创建时间未知的排在后面，创建时间相同时按token排序。
+func lessCreated(a, b *DocumentInfo) bool {
+	ta, errA := strconv.ParseInt(a.CreatedTime, 10, 64)
+	tb, errB := strconv.ParseInt(b.CreatedTime, 10, 64)
+	switch {
+	case errA == nil && errB == nil && ta != tb:
+		return ta < tb
+	case (errA == nil) != (errB == nil):
+		return errA == nil
+	}
+	return nameSuffix(a) < nameSuffix(b)
+}
+
+// unnamedBase 空文件名的文档按类型使用的基础名称。
+func unnamedBase(typ constant.DocType) string {
+	switch typ {
+	case constant.DocTypeDocx:
+		return "未命名新版文档"
+	case constant.DocTypeDoc:
+		return "未命名旧版文档"
+	case constant.DocTypeSheet:
+		return "未命名电子表格"
+	case constant.DocTypeBitable:
+		return "未命名多维表格"
+	case constant.DocTypeMindNote:
+		return "未命名思维笔记"
+	case constant.DocTypeSlides:
+		return "未命名幻灯片"
+	default:
+		return "未命名飞书文档"
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+func TestResolveNames(t *testing.T) {
+	dns := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "test", Type: constant.DocTypeFolder},
+			Children: []*DocumentNode{
+				{DocumentInfo: DocumentInfo{Name: "", Type: constant.DocTypeDocx}},
+				{DocumentInfo: DocumentInfo{Name: "", Type: constant.DocTypeDocx}},
+				{DocumentInfo: DocumentInfo{Name: "test", Type: constant.DocTypeDocx}},
+			},
+		},
+		{DocumentInfo: DocumentInfo{Name: "test", Type: constant.DocTypeDocx}},
+		{DocumentInfo: DocumentInfo{Name: "test", Type: constant.DocTypeSheet}},
+	}
+	resolveNames(dns, DuplicateNamesIndex, nil)
+	require.Equal(t, "test", dns[0].Name)
+	require.Equal(t, "未命名新版文档1", dns[0].Children[0].Name)
+	require.Equal(t, "未命名新版文档2", dns[0].Children[1].Name)
+	require.Equal(t, "test", dns[0].Children[2].Name, "不同层级的同名文档不需要追加序号")
+	require.Equal(t, "test1", dns[1].Name)
+	require.Equal(t, "test2", dns[2].Name)
+}
+
+// newDuplicateNodes 构造同级重名的文档，按接口返回的顺序（最近编辑时间倒序）排列。
+func newDuplicateNodes(tokens ...string) []*DocumentNode {
+	createdTimes := map[string]string{"a": "100", "b": "200", "c": "300", "d": "400"}
+	var dns []*DocumentNode
+	for _, token := range tokens {
+		dns = append(dns, &DocumentNode{DocumentInfo: DocumentInfo{
+			Name: "周报", Type: constant.DocTypeDocx, Token: token, CreatedTime: createdTimes[token],
+		}})
+	}
+	return dns
+}
+
+func namesByToken(dns []*DocumentNode) map[string]string {
+	names := map[string]string{}
+	for _, dn := range dns {
+		names[dn.Token] = dn.Name
+	}
+	return names
+}
+
+func TestResolveNames_index(t *testing.T) {
+	// 按创建时间编号，与接口返回的顺序无关
+	dns := newDuplicateNodes("c", "a", "b")
+	resolveNames(dns, DuplicateNamesIndex, nil)
+	require.Equal(t, map[string]string{"a": "周报", "b": "周报1", "c": "周报2"}, namesByToken(dns))
+	dns = newDuplicateNodes("b", "c", "a")
+	resolveNames(dns, "", nil)
+	require.Equal(t, map[string]string{"a": "周报", "b": "周报1", "c": "周报2"}, namesByToken(dns), "默认按序号区分")
+
+	// 沿用上一次的文件名，a删除后b、c不变，新增的d使用最小的空闲序号
+	previous := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "目录", Type: constant.DocTypeFolder, Token: "folder"},
+			Children:     newDuplicateNodes("a", "b", "c"),
+		},
+	}
+	resolveNames(previous[0].Children, DuplicateNamesIndex, nil)
+	dns = newDuplicateNodes("d", "c", "b")
+	resolveNames(dns, DuplicateNamesIndex, previous)
+	require.Equal(t, map[string]string{"b": "周报1", "c": "周报2", "d": "周报"}, namesByToken(dns))
+
+	// 重命名后不再沿用上一次的文件名
+	dns = newDuplicateNodes("b", "c")
+	dns[0].Name = "月报"
+	resolveNames(dns, DuplicateNamesIndex, previous)
+	require.Equal(t, map[string]string{"b": "月报", "c": "周报2"}, namesByToken(dns))
+
+	// 不重名的文档使用原名，重名的文档跳过已被占用的文件名
+	dns = []*DocumentNode{
+		{DocumentInfo: DocumentInfo{Name: "周报", Type: constant.DocTypeDocx, Token: "a", CreatedTime: "100"}},
+		{DocumentInfo: DocumentInfo{Name: "周报", Type: constant.DocTypeDocx, Token: "b", CreatedTime: "200"}},
+		{DocumentInfo: DocumentInfo{Name: "周报1", Type: constant.DocTypeDocx, Token: "c", CreatedTime: "300"}},
+	}
+	resolveNames(dns, DuplicateNamesIndex, nil)
+	require.Equal(t, map[string]string{"a": "周报", "b": "周报2", "c": "周报1"}, namesByToken(dns))
+}
+
+func TestResolveNames_token(t *testing.T) {
+	dns := newDuplicateNodes("c", "a")
+	dns = append(dns,
+		&DocumentNode{DocumentInfo: DocumentInfo{Name: "月报", Type: constant.DocTypeDocx, Token: "m"}},
+		&DocumentNode{DocumentInfo: DocumentInfo{Name: "", Type: constant.DocTypeSheet, Token: "s"}},
+		&DocumentNode{
+			DocumentInfo: DocumentInfo{Name: "表格", Type: constant.DocTypeSheet, Token: "t", SplitBySheet: true},
+			Children: []*DocumentNode{
+				{DocumentInfo: DocumentInfo{Name: "Sheet", Type: constant.DocTypeSheet, Token: "t", SubID: "s1"}},
+				{DocumentInfo: DocumentInfo{Name: "Sheet", Type: constant.DocTypeSheet, Token: "t", SubID: "s2"}},
+			},
+		},
+	)
+	resolveNames(dns, DuplicateNamesToken, nil)
+	require.Equal(t, map[string]string{"a": "周报_a", "c": "周报_c", "m": "月报", "s": "未命名电子表格_s", "t": "表格"}, namesByToken(dns))
+	require.Equal(t, "Sheet_s1", dns[4].Children[0].Name, "拆分导出的工作表追加工作表ID")
+	require.Equal(t, "Sheet_s2", dns[4].Children[1].Name)
+}
+
+func TestNamedNode_matchName(t *testing.T) {
+	named := &namedNode{base: "周报"}
+	require.True(t, named.matchName("周报"))
+	require.True(t, named.matchName("周报12"))
+	require.False(t, named.matchName("周报0"))
+	require.False(t, named.matchName("周报01"))
+	require.False(t, named.matchName("周报-1"))
+	require.False(t, named.matchName("月报1"))
+	unnamed := &namedNode{base: "未命名新版文档", unnamed: true}
+	require.False(t, unnamed.matchName("未命名新版文档"), "空文件名的总是追加序号")
+	require.True(t, unnamed.matchName("未命名新版文档2"))
+}
+
+func TestClaimName(t *testing.T) {
+	used := map[string]bool{}
+	require.Equal(t, "a", claimName("a", used))
+	require.Equal(t, "a1", claimName("a", used))
+	require.Equal(t, "a2", claimName("a", used))
+	require.True(t, used["a2"])
+}
+
+func TestLessCreated(t *testing.T) {
+	require.True(t, lessCreated(&DocumentInfo{CreatedTime: "9"}, &DocumentInfo{CreatedTime: "10"}), "按数值比较")
+	require.True(t, lessCreated(&DocumentInfo{CreatedTime: "10"}, &DocumentInfo{}), "创建时间未知的排在后面")
+	require.False(t, lessCreated(&DocumentInfo{}, &DocumentInfo{CreatedTime: "10"}))
+	require.True(t, lessCreated(&DocumentInfo{CreatedTime: "10", Token: "a"}, &DocumentInfo{CreatedTime: "10", Token: "b"}))
+	require.True(t, lessCreated(&DocumentInfo{Token: "t", SubID: "s1"}, &DocumentInfo{Token: "t", SubID: "s2"}))
+	require.False(t, lessCreated(&DocumentInfo{}, &DocumentInfo{}))
+}
+
+func TestUnnamedBase(t *testing.T) {
+	tests := []struct {
+		typ      constant.DocType
+		expected string
+	}{
+		{typ: constant.DocTypeDocx, expected: "未命名新版文档"},
+		{typ: constant.DocTypeDoc, expected: "未命名旧版文档"},
+		{typ: constant.DocTypeSheet, expected: "未命名电子表格"},
+		{typ: constant.DocTypeBitable, expected: "未命名多维表格"},
+		{typ: constant.DocTypeMindNote, expected: "未命名思维笔记"},
+		{typ: constant.DocTypeSlides, expected: "未命名幻灯片"},
+		{typ: "unknown", expected: "未命名飞书文档"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, unnamedBase(tt.typ), tt.typ)
+	}
+}
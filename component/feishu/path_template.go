@@ -0,0 +1,189 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// 路径模板中的占位符。
+const (
+	placeholderTitle     = "title"      // 文档名
+	placeholderToken     = "token"      // 文档token
+	placeholderNodeToken = "node_token" // 知识节点ID，云空间的文档为空
+	placeholderType      = "type"       // 文档类型，如 docx
+	placeholderExt       = "ext"        // 文件扩展名，如 md
+	placeholderSpace     = "space"      // 顶层节点名，如知识库名称或云空间文件夹名称，顶层节点本身为空
+	placeholderPath      = "path"       // 顶层节点与文档之间的各级上级节点名，以/分隔
+	placeholderDate      = "date"       // 文档最近编辑日期，如 2025-01-02，未知时为空
+	placeholderDepth     = "depth"      // 文档在文档树中的层级，顶层节点为1
+)
+
+var (
+	pathPlaceholders   = []string{placeholderTitle, placeholderToken, placeholderNodeToken, placeholderType, placeholderExt, placeholderSpace, placeholderPath, placeholderDate, placeholderDepth}
+	pathPlaceholderReg = regexp.MustCompile(`\{([a-z_]+)}`)
+)
+
+// validatePathTemplate 校验 path-template，只能使用支持的占位符，且必须能区分不同的文档。
+func validatePathTemplate(value any) error {
+	tmpl, _ := value.(string)
+	if tmpl == "" {
+		return nil
+	}
+	if filepath.IsAbs(tmpl) || strings.HasPrefix(tmpl, "/") {
+		return oops.Errorf("path-template必须是相对于dir的路径: %s", tmpl)
+	}
+	if lo.Contains(strings.Split(filepath.ToSlash(tmpl), "/"), "..") {
+		return oops.Errorf("path-template不能包含..: %s", tmpl)
+	}
+	var names []string
+	for _, match := range pathPlaceholderReg.FindAllStringSubmatch(tmpl, -1) {
+		if !lo.Contains(pathPlaceholders, match[1]) {
+			return oops.Errorf("path-template不支持的占位符: %s", match[0])
+		}
+		names = append(names, match[1])
+	}
+	if !lo.ContainsBy(names, func(name string) bool {
+		return name == placeholderTitle || name == placeholderToken || name == placeholderNodeToken
+	}) {
+		return oops.Errorf("path-template必须包含{%s}、{%s}或{%s}: %s", placeholderTitle, placeholderToken, placeholderNodeToken, tmpl)
+	}
+	return nil
+}
+
+// applyPathTemplate 按路径模板重新计算文档的保存路径，模板为空时保持 documentNodesToInfoList 计算的路径。
+// 需要在按过滤条件标记文档（filterDocuments）之后调用，过滤条件中的路径始终与阶段1打印的目录结构一致。
+//   - 文件夹不再对应本地目录，保存路径置为空
+//   - 按工作表拆分导出的表格，模板路径去掉扩展名作为目录，拆分出的文件保存在其中
+//   - 不同上级节点下的文档可能得到相同的保存路径，此时都追加文档token区分，返回追加了token的文档数量
+func applyPathTemplate(dns []*DocumentNode, saveDir, tmpl string) int {
+	if tmpl == "" {
+		return 0
+	}
+	renderPathTemplate(dns, saveDir, tmpl, nil)
+	return dedupePaths(dns)
+}
+
+// renderPathTemplate 递归计算保存路径，ancestors 为从顶层节点到上级节点的节点名。
+func renderPathTemplate(dns []*DocumentNode, saveDir, tmpl string, ancestors []string) {
+	for _, dn := range dns {
+		switch {
+		case dn.Type == constant.DocTypeFolder:
+			dn.FilePath = ""
+		case dn.SplitBySheet:
+			dn.FilePath = strings.TrimSuffix(renderPath(dn, "", saveDir, tmpl, ancestors), ".")
+		default:
+			dn.FilePath = renderPath(dn, string(dn.FileExtension), saveDir, tmpl, ancestors)
+		}
+		renderPathTemplate(dn.setSheetPaths(), saveDir, tmpl, append(ancestors[:len(ancestors):len(ancestors)], dn.Name))
+	}
+}
+
+// setSheetPaths 按工作表拆分导出的表格，拆分出的文件保存在表格的目录中，返回其余的子节点。
+func (dn *DocumentNode) setSheetPaths() []*DocumentNode {
+	var children []*DocumentNode
+	for _, child := range dn.Children {
+		if dn.SplitBySheet && child.SubID != "" {
+			child.FilePath = filepath.Join(dn.FilePath, child.Name+"."+string(child.FileExtension))
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// dedupePaths 为保存路径相同的文档追加文档token，与 DuplicateNamesToken 一样按 {title}_{token} 区分，避免相互覆盖。
+func dedupePaths(dns []*DocumentNode) int {
+	groups := map[string][]*DocumentNode{}
+	var paths []string
+	var collect func(dns []*DocumentNode)
+	collect = func(dns []*DocumentNode) {
+		for _, dn := range dns {
+			if dn.FilePath != "" {
+				if _, ok := groups[dn.FilePath]; !ok {
+					paths = append(paths, dn.FilePath)
+				}
+				groups[dn.FilePath] = append(groups[dn.FilePath], dn)
+			}
+			collect(dn.setSheetPaths())
+		}
+	}
+	collect(dns)
+	used := lo.SliceToMap(paths, func(path string) (string, bool) { return path, true })
+	var count int
+	for _, path := range paths {
+		nodes := groups[path]
+		if len(nodes) < 2 {
+			continue
+		}
+		// 按创建时间排序，使结果与接口返回的顺序无关
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return lessCreated(&nodes[i].DocumentInfo, &nodes[j].DocumentInfo)
+		})
+		for _, dn := range nodes {
+			var ext string
+			if !dn.SplitBySheet {
+				ext = filepath.Ext(path)
+			}
+			base := fmt.Sprintf("%s_%s", strings.TrimSuffix(path, ext), nameSuffix(&dn.DocumentInfo))
+			dn.FilePath = claimName(base, used) + ext
+			_ = dn.setSheetPaths()
+			count++
+		}
+	}
+	return count
+}
+
+// renderPath 替换路径模板中的占位符，去掉替换后为空的各级目录。
+func renderPath(dn *DocumentNode, ext, saveDir, tmpl string, ancestors []string) string {
+	var space, path string
+	if len(ancestors) > 0 {
+		space = ancestors[0]
+		path = strings.Join(ancestors[1:], "/")
+	}
+	var date string
+	if sec, err := strconv.ParseInt(dn.ModifiedTime, 10, 64); err == nil {
+		date = time.Unix(sec, 0).Format(time.DateOnly)
+	}
+	values := map[string]string{
+		placeholderTitle:     dn.Name,
+		placeholderToken:     dn.Token,
+		placeholderNodeToken: dn.NodeToken,
+		placeholderType:      string(dn.Type),
+		placeholderExt:       ext,
+		placeholderSpace:     space,
+		placeholderPath:      path,
+		placeholderDate:      date,
+		placeholderDepth:     strconv.Itoa(len(ancestors) + 1),
+	}
+	rendered := pathPlaceholderReg.ReplaceAllStringFunc(tmpl, func(match string) string {
+		return values[match[1:len(match)-1]]
+	})
+	segments := lo.Filter(strings.Split(filepath.ToSlash(rendered), "/"), func(s string, _ int) bool {
+		return s != "" && s != "."
+	})
+	return filepath.Join(append([]string{saveDir}, segments...)...)
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+func TestValidatePathTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl      string
+		wantError string
+	}{
+		{tmpl: ""},
+		{tmpl: "{space}/{path}/{title}_{token}.{ext}"},
+		{tmpl: "{type}/{date}/{depth}-{node_token}.{ext}"},
+		{tmpl: "/{title}.{ext}", wantError: "path-template必须是相对于dir的路径: /{title}.{ext}"},
+		{tmpl: "../{title}.{ext}", wantError: "path-template不能包含..: ../{title}.{ext}"},
+		{tmpl: "{name}.{ext}", wantError: "path-template不支持的占位符: {name}"},
+		{tmpl: "{type}/{date}.{ext}", wantError: "path-template必须包含{title}、{token}或{node_token}: {type}/{date}.{ext}"},
+	}
+	for _, tt := range tests {
+		err := validatePathTemplate(tt.tmpl)
+		if tt.wantError == "" {
+			require.NoError(t, err, tt.tmpl)
+			continue
+		}
+		require.EqualError(t, err, tt.wantError, tt.tmpl)
+	}
+}
+
+// newPathTemplateTree 构造用于测试路径模板的文档树。
+//
+//	├─ 知识库
+//	│   ├─ A.docx
+//	│   ├─ A
+//	│   │   └─ B.docx
+//	│   └─ 表格             按工作表拆分
+//	│       ├─ 工作表1.csv
+//	│       └─ C.docx       表格的子文档
+//	└─ D.md
+func newPathTemplateTree() []*DocumentNode {
+	dns := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "知识库", Type: constant.DocTypeFolder, Token: "space"},
+			Children: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{Name: "A", Type: constant.DocTypeDocx, Token: "a", NodeToken: "na",
+						FileExtension: constant.FileExtDocx, CanDownload: true, ModifiedTime: "1735689600"},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "B", Type: constant.DocTypeDocx, Token: "b", NodeToken: "nb",
+							FileExtension: constant.FileExtDocx, CanDownload: true}},
+					},
+				},
+				{
+					DocumentInfo: DocumentInfo{Name: "表格", Type: constant.DocTypeSheet, Token: "s",
+						FileExtension: constant.FileExtCSV, SplitBySheet: true},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "工作表1", Type: constant.DocTypeSheet, Token: "s",
+							FileExtension: constant.FileExtCSV, CanDownload: true, SubID: "s1"}},
+						{DocumentInfo: DocumentInfo{Name: "C", Type: constant.DocTypeDocx, Token: "c",
+							FileExtension: constant.FileExtDocx, CanDownload: true}},
+					},
+				},
+			},
+		},
+		{DocumentInfo: DocumentInfo{Name: "D", Type: constant.DocTypeDocx, Token: "d", FileExtension: constant.FileExtMarkdown, CanDownload: true}},
+	}
+	_ = documentNodesToInfoList(dns, "/tmp/docs")
+	return dns
+}
+
+func TestApplyPathTemplate(t *testing.T) {
+	date := time.Unix(1735689600, 0).Format(time.DateOnly)
+	tests := []struct {
+		name      string
+		tmpl      string
+		wantPaths map[string]string // 文档名 -> 保存路径
+	}{
+		{
+			name: "模板为空时按文档层级保存",
+			tmpl: "",
+			wantPaths: map[string]string{
+				"知识库":  "/tmp/docs/知识库",
+				"A":    "/tmp/docs/知识库/A.docx",
+				"B":    "/tmp/docs/知识库/A/B.docx",
+				"表格":   "/tmp/docs/知识库/表格",
+				"工作表1": "/tmp/docs/知识库/表格/工作表1.csv",
+				"C":    "/tmp/docs/知识库/表格/C.docx",
+				"D":    "/tmp/docs/D.md",
+			},
+		},
+		{
+			name: "按层级保存并追加token",
+			tmpl: "{space}/{path}/{title}_{token}.{ext}",
+			wantPaths: map[string]string{
+				"知识库":  "",
+				"A":    "/tmp/docs/知识库/A_a.docx",
+				"B":    "/tmp/docs/知识库/A/B_b.docx",
+				"表格":   "/tmp/docs/知识库/表格_s",
+				"工作表1": "/tmp/docs/知识库/表格_s/工作表1.csv",
+				"C":    "/tmp/docs/知识库/表格/C_c.docx",
+				"D":    "/tmp/docs/D_d.md",
+			},
+		},
+		{
+			name: "扁平保存",
+			tmpl: "{type}/{date}/{depth}-{title}.{ext}",
+			wantPaths: map[string]string{
+				"知识库":  "",
+				"A":    "/tmp/docs/docx/" + date + "/2-A.docx",
+				"B":    "/tmp/docs/docx/3-B.docx",
+				"表格":   "/tmp/docs/sheet/2-表格",
+				"工作表1": "/tmp/docs/sheet/2-表格/工作表1.csv",
+				"C":    "/tmp/docs/docx/3-C.docx",
+				"D":    "/tmp/docs/docx/1-D.md",
+			},
+		},
+		{
+			name: "云空间的文档没有知识节点ID",
+			tmpl: "{node_token}/{title}.{ext}",
+			wantPaths: map[string]string{
+				"知识库":  "",
+				"A":    "/tmp/docs/na/A.docx",
+				"B":    "/tmp/docs/nb/B.docx",
+				"表格":   "/tmp/docs/表格",
+				"工作表1": "/tmp/docs/表格/工作表1.csv",
+				"C":    "/tmp/docs/C.docx",
+				"D":    "/tmp/docs/D.md",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dns := newPathTemplateTree()
+			applyPathTemplate(dns, "/tmp/docs", tt.tmpl)
+			paths := map[string]string{}
+			for _, dn := range dns {
+				for _, di := range documentNodeToInfoList(dn) {
+					paths[di.Name] = filepath.ToSlash(di.FilePath)
+				}
+			}
+			require.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func TestApplyPathTemplate_duplicatePaths(t *testing.T) {
+	// 不同上级节点下的同名文档按扁平的模板会得到相同的保存路径
+	dns := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "知识库", Type: constant.DocTypeFolder, Token: "space"},
+			Children: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{Name: "A", Type: constant.DocTypeDocx, Token: "a", FileExtension: constant.FileExtDocx},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "周报", Type: constant.DocTypeDocx, Token: "x", FileExtension: constant.FileExtDocx, CreatedTime: "2"}},
+					},
+				},
+				{
+					DocumentInfo: DocumentInfo{Name: "B", Type: constant.DocTypeDocx, Token: "b", FileExtension: constant.FileExtDocx},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "周报", Type: constant.DocTypeDocx, Token: "y", FileExtension: constant.FileExtDocx, CreatedTime: "1"}},
+					},
+				},
+				{DocumentInfo: DocumentInfo{Name: "C", Type: constant.DocTypeDocx, Token: "c", FileExtension: constant.FileExtDocx}},
+			},
+		},
+	}
+	_ = documentNodesToInfoList(dns, "/tmp/docs")
+	require.Equal(t, 2, applyPathTemplate(dns, "/tmp/docs", "{type}/{title}.{ext}"))
+	paths := map[string]string{}
+	for _, di := range flattenDocumentNodes(dns) {
+		paths[di.Token] = filepath.ToSlash(di.FilePath)
+	}
+	require.Equal(t, map[string]string{
+		"space": "",
+		"a":     "/tmp/docs/docx/A.docx",
+		"x":     "/tmp/docs/docx/周报_x.docx",
+		"b":     "/tmp/docs/docx/B.docx",
+		"y":     "/tmp/docs/docx/周报_y.docx",
+		"c":     "/tmp/docs/docx/C.docx",
+	}, paths)
+
+	// 按工作表拆分导出的表格重名时，拆分出的文件保存在追加了token的目录中
+	sheets := []*DocumentNode{
+		{DocumentInfo: DocumentInfo{Name: "表格", Type: constant.DocTypeSheet, Token: "s1", SplitBySheet: true},
+			Children: []*DocumentNode{
+				{DocumentInfo: DocumentInfo{Name: "工作表1", Type: constant.DocTypeSheet, Token: "s1", SubID: "sub", FileExtension: constant.FileExtCSV}},
+			}},
+		{DocumentInfo: DocumentInfo{Name: "表格", Type: constant.DocTypeSheet, Token: "s2", FileExtension: constant.FileExtXlsx}},
+	}
+	require.Equal(t, 0, applyPathTemplate(sheets, "/tmp/docs", "{title}.{ext}"), "保存路径不同")
+	sheets[1].SplitBySheet = true
+	require.Equal(t, 2, applyPathTemplate(sheets, "/tmp/docs", "{title}.{ext}"))
+	require.Equal(t, "/tmp/docs/表格_s1", filepath.ToSlash(sheets[0].FilePath))
+	require.Equal(t, "/tmp/docs/表格_s1/工作表1.csv", filepath.ToSlash(sheets[0].Children[0].FilePath))
+	require.Equal(t, "/tmp/docs/表格_s2", filepath.ToSlash(sheets[1].FilePath))
+}
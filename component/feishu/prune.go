@@ -0,0 +1,276 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// 清理模式，用于清理云文档已删除或已移动的本地文件。
+const (
+	PruneOff        = "off"        // 不清理
+	PruneDryRun     = "dry-run"    // 只列出将被清理的文件和目录，不导出也不清理
+	PruneDelete     = "delete"     // 删除
+	PruneQuarantine = "quarantine" // 移动到 SaveDir 中的 .xdoc-trash 目录隔离起来
+
+	trashDir = ".xdoc-trash" // 隔离目录名，保存在 SaveDir 中
+)
+
+// mediaLinkPattern 匹配Markdown文件中指向 assets 目录中图片和附件的链接，见 markdownRenderer.mediaLink。
+var mediaLinkPattern = regexp.MustCompile(`\]\((\S*?` + assetsDir + `/[^/\s()]+)\)`)
+
+// scopePrevious 返回上一次的文档树中属于本次文档源的部分，只在这个范围内找出云文档已删除或已移动的本地文件。
+// 上一次的文档树中与本次某个文档源的根节点相同的节点取其子树，上一次的根节点包含在本次的文档树中时取整棵树。
+// 缩小 --urls 的范围后，其他文档源的本地文件不会被当作已删除。
+func scopePrevious(dns, previous []*DocumentNode) []*DocumentNode {
+	same := func(a, b *DocumentInfo) bool {
+		return a.Token != "" && a.Type == b.Type && a.Token == b.Token
+	}
+	current := flattenDocumentNodes(dns)
+	var scoped []*DocumentNode
+	var walk func(dn *DocumentNode, root bool)
+	walk = func(dn *DocumentNode, root bool) {
+		if lo.ContainsBy(dns, func(cur *DocumentNode) bool { return same(&dn.DocumentInfo, &cur.DocumentInfo) }) ||
+			root && lo.ContainsBy(current, func(cur *DocumentInfo) bool { return same(&dn.DocumentInfo, cur) }) {
+			scoped = append(scoped, dn)
+			return
+		}
+		for _, child := range dn.Children {
+			walk(child, false)
+		}
+	}
+	for _, dn := range previous {
+		walk(dn, true)
+	}
+	return scoped
+}
+
+// findStaleFiles 对比上一次的文档树，找出本地仍存在但已不对应任何文档节点的文件和目录。
+// 只会找出上一次 document-tree.json 中记录过的路径（以及这些Markdown文件引用的图片和附件），不会动用户自己放到 saveDir 中的文件。
+// 返回的目录按从深到浅排序，方便先清理子目录。
+func findStaleFiles(infoList []*DocumentInfo, previous []*DocumentNode, saveDir string) (files, dirs []string, err error) {
+	// 当前文档树用到的文件和目录
+	currentFiles := map[string]bool{}
+	currentDirs := map[string]bool{}
+	for _, di := range infoList {
+		currentFiles[di.FilePath] = true
+		for dir := filepath.Dir(di.FilePath); isSubPath(saveDir, dir) && !currentDirs[dir]; dir = filepath.Dir(dir) {
+			currentDirs[dir] = true
+		}
+	}
+	staleDirs := map[string]bool{}
+	for _, dn := range previous {
+		for _, di := range documentNodeToInfoList(dn) {
+			if di.FilePath == "" || !isSubPath(saveDir, di.FilePath) {
+				continue
+			}
+			// 上一次的文件所在的各级目录，当前已不再使用的，清理完文件后如果为空也一并清理
+			for dir := filepath.Dir(di.FilePath); isSubPath(saveDir, dir) && !currentDirs[dir]; dir = filepath.Dir(dir) {
+				staleDirs[dir] = true
+			}
+			if di.isDir() {
+				if !currentDirs[di.FilePath] {
+					staleDirs[di.FilePath] = true
+				}
+				continue
+			}
+			if currentFiles[di.FilePath] || currentDirs[di.FilePath] {
+				continue
+			}
+			yes, err := app.Fs.Exists(di.FilePath)
+			if err != nil {
+				return nil, nil, oops.Wrap(err)
+			}
+			if yes {
+				files = append(files, di.FilePath)
+			}
+		}
+	}
+	// 清理的Markdown文件引用的图片和附件，没有被其他Markdown文件引用时一并清理，assets 目录为空时也清理
+	assets, err := findStaleAssets(files, infoList, saveDir)
+	if err != nil {
+		return nil, nil, oops.Wrap(err)
+	}
+	for _, asset := range assets {
+		files = append(files, asset)
+		staleDirs[filepath.Dir(asset)] = true
+	}
+	for dir := range staleDirs {
+		yes, err := app.Fs.DirExists(dir)
+		if err != nil {
+			return nil, nil, oops.Wrap(err)
+		}
+		if yes {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(files)
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := strings.Count(dirs[i], string(filepath.Separator)), strings.Count(dirs[j], string(filepath.Separator))
+		if di != dj {
+			return di > dj
+		}
+		return dirs[i] < dirs[j]
+	})
+	return files, dirs, nil
+}
+
+// findStaleAssets 找出只被将要清理的Markdown文件引用的图片和附件。
+// 同一目录的文档共用一个 assets 目录，同一个素材也可能被其他目录的文档引用，所以当前文档树中的Markdown文件仍引用的素材保留。
+func findStaleAssets(files []string, infoList []*DocumentInfo, saveDir string) ([]string, error) {
+	candidates := map[string]bool{}
+	for _, file := range files {
+		links, err := readMediaLinks(file)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		for _, link := range links {
+			if isSubPath(saveDir, link) {
+				candidates[link] = true
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	for _, di := range infoList {
+		links, err := readMediaLinks(di.FilePath)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		for _, link := range links {
+			delete(candidates, link)
+		}
+	}
+	var assets []string
+	for asset := range candidates {
+		yes, err := app.Fs.Exists(asset)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		if yes {
+			assets = append(assets, asset)
+		}
+	}
+	return assets, nil
+}
+
+// readMediaLinks 读取Markdown文件中引用的图片和附件的本地路径，不是Markdown文件或文件不存在时返回空。
+func readMediaLinks(filePath string) ([]string, error) {
+	if !strings.EqualFold(filepath.Ext(filePath), "."+string(constant.FileExtMarkdown)) {
+		return nil, nil
+	}
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return nil, oops.Wrap(err)
+	}
+	data, err := app.Fs.ReadFile(filePath)
+	if err != nil {
+		return nil, oops.Wrapf(err, "读取Markdown文件失败: %s", filePath)
+	}
+	var links []string
+	for _, match := range mediaLinkPattern.FindAllStringSubmatch(string(data), -1) {
+		link, err := url.PathUnescape(match[1])
+		if err != nil {
+			link = match[1]
+		}
+		links = append(links, filepath.Join(filepath.Dir(filePath), filepath.FromSlash(link)))
+	}
+	return links, nil
+}
+
+// pruneFiles 按清理模式删除或隔离文件，再删除清理后为空的目录，目录中还有其他文件时保留。
+func pruneFiles(logWriter io.Writer, mode string, files, dirs []string, saveDir string) error {
+	if mode != PruneDelete && mode != PruneQuarantine {
+		return nil
+	}
+	trash := filepath.Join(saveDir, trashDir, time.Now().Format("20060102150405"))
+	for _, file := range files {
+		var err error
+		if mode == PruneDelete {
+			err = app.Fs.Remove(file)
+		} else {
+			err = moveToTrash(file, saveDir, trash)
+		}
+		if err != nil {
+			return oops.Wrapf(err, "清理文件失败: %s", file)
+		}
+	}
+	var removedDirs int
+	for _, dir := range dirs {
+		empty, err := app.Fs.IsEmpty(dir)
+		if err != nil {
+			return oops.Wrapf(err, "清理目录失败: %s", dir)
+		}
+		if !empty {
+			continue
+		}
+		if err = app.Fs.Remove(dir); err != nil {
+			return oops.Wrapf(err, "清理目录失败: %s", dir)
+		}
+		removedDirs++
+	}
+	if mode == PruneDelete {
+		app.Fprintf(logWriter, "已删除文件数量: %d, 已删除空目录数量: %d\n", len(files), removedDirs)
+	} else {
+		app.Fprintf(logWriter, "已隔离文件数量: %d, 隔离目录: %s, 已删除空目录数量: %d\n", len(files), trash, removedDirs)
+	}
+	return nil
+}
+
+// printStaleFiles 打印将被清理的文件和目录。
+func printStaleFiles(logWriter io.Writer, mode string, files, dirs []string) {
+	app.Fprintf(logWriter, "云文档已删除或已移动, 将被清理(%s)的文件数量: %d, 目录数量(为空时才清理): %d\n", mode, len(files), len(dirs))
+	for _, file := range files {
+		app.Fprintf(logWriter, "  [文件] %s\n", file)
+	}
+	for _, dir := range dirs {
+		app.Fprintf(logWriter, "  [目录] %s\n", dir)
+	}
+}
+
+// moveToTrash 将文件按其在 saveDir 中的相对路径移动到隔离目录。
+func moveToTrash(file, saveDir, trash string) error {
+	rel, err := filepath.Rel(saveDir, file)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	target := filepath.Join(trash, rel)
+	if err = app.Fs.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return oops.Wrap(err)
+	}
+	return oops.Wrap(app.Fs.Rename(file, target))
+}
+
+// isSubPath 判断 path 是否是 dir 下的子路径（不包括 dir 本身）。
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
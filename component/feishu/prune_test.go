@@ -0,0 +1,275 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+// setupPruneFiles 构造上一次的文档树和本地文件。
+//
+//	/tmp/docs
+//	├─ 知识库
+//	│   ├─ a.docx       仍存在
+//	│   ├─ b.docx       已删除
+//	│   ├─ b            已删除（b的子文档目录）
+//	│   │   └─ c.docx   已删除
+//	│   └─ d.docx       已删除，但本地文件也已不存在
+//	├─ 云空间           已删除，但目录中有用户自己的文件
+//	│   ├─ e.pdf        已删除
+//	│   └─ user.txt     用户自己的文件
+//	└─ other.txt        用户自己的文件
+func setupPruneFiles(t *testing.T) (infoList []*DocumentInfo, previous []*DocumentNode) {
+	useMemMapFs()
+	for _, file := range []string{"知识库/a.docx", "知识库/b.docx", "知识库/b/c.docx", "云空间/e.pdf", "云空间/user.txt", "other.txt"} {
+		filePath := filepath.Join("/tmp/docs", file)
+		require.NoError(t, app.Fs.MkdirAll(filepath.Dir(filePath), 0o755))
+		require.NoError(t, app.Fs.WriteFile(filePath, []byte(file), 0o644))
+	}
+	newNode := func(filePath string, typ constant.DocType, children ...*DocumentNode) *DocumentNode {
+		return &DocumentNode{DocumentInfo: DocumentInfo{Type: typ, FilePath: filePath}, Children: children}
+	}
+	previous = []*DocumentNode{
+		newNode("/tmp/docs/知识库", constant.DocTypeFolder,
+			newNode("/tmp/docs/知识库/a.docx", constant.DocTypeDocx),
+			newNode("/tmp/docs/知识库/b.docx", constant.DocTypeDocx,
+				newNode("/tmp/docs/知识库/b/c.docx", constant.DocTypeDocx),
+			),
+			newNode("/tmp/docs/知识库/d.docx", constant.DocTypeDocx),
+		),
+		newNode("/tmp/docs/云空间", constant.DocTypeFolder,
+			newNode("/tmp/docs/云空间/e.pdf", constant.DocTypeFile),
+		),
+		// 不在saveDir中的文件不处理
+		newNode("/tmp/other/f.docx", constant.DocTypeDocx),
+	}
+	infoList = []*DocumentInfo{
+		{Type: constant.DocTypeFolder, FilePath: "/tmp/docs/知识库"},
+		{Type: constant.DocTypeDocx, FilePath: "/tmp/docs/知识库/a.docx"},
+	}
+	return infoList, previous
+}
+
+func TestFindStaleFiles(t *testing.T) {
+	infoList, previous := setupPruneFiles(t)
+	files, dirs, err := findStaleFiles(infoList, previous, "/tmp/docs")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/docs/云空间/e.pdf", "/tmp/docs/知识库/b.docx", "/tmp/docs/知识库/b/c.docx"}, files)
+	require.Equal(t, []string{"/tmp/docs/知识库/b", "/tmp/docs/云空间"}, dirs)
+
+	// 没有上一次的文档树
+	files, dirs, err = findStaleFiles(infoList, nil, "/tmp/docs")
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.Empty(t, dirs)
+}
+
+func TestFindStaleFiles_assets(t *testing.T) {
+	useMemMapFs()
+	files := map[string]string{
+		"/tmp/docs/知识库/a.md":             "![](assets/img1.png)\n[附件](../%E4%BA%91%E7%A9%BA%E9%97%B4/assets/file1.pdf)\n",
+		"/tmp/docs/知识库/b.md":             "![](assets/img1.png)\n![](assets/img2.png)\n[说明 文档.pdf](assets/file2.pdf)\n",
+		"/tmp/docs/知识库/assets/img1.png":  "img1",
+		"/tmp/docs/知识库/assets/img2.png":  "img2",
+		"/tmp/docs/知识库/assets/file2.pdf": "file2",
+		"/tmp/docs/云空间/c.md":             "[附件](assets/file1.pdf)\n",
+		"/tmp/docs/云空间/assets/file1.pdf": "file1",
+		"/tmp/docs/云空间/assets/user.txt":  "用户自己的文件",
+	}
+	for filePath, content := range files {
+		require.NoError(t, app.Fs.MkdirAll(filepath.Dir(filePath), 0o755))
+		require.NoError(t, app.Fs.WriteFile(filePath, []byte(content), 0o644))
+	}
+	newNode := func(filePath string, typ constant.DocType, children ...*DocumentNode) *DocumentNode {
+		return &DocumentNode{DocumentInfo: DocumentInfo{Type: typ, FilePath: filePath}, Children: children}
+	}
+	previous := []*DocumentNode{
+		newNode("/tmp/docs/知识库", constant.DocTypeFolder,
+			newNode("/tmp/docs/知识库/a.md", constant.DocTypeDocx),
+			newNode("/tmp/docs/知识库/b.md", constant.DocTypeDocx),
+		),
+		newNode("/tmp/docs/云空间", constant.DocTypeFolder,
+			newNode("/tmp/docs/云空间/c.md", constant.DocTypeDocx),
+		),
+	}
+	infoList := []*DocumentInfo{
+		{Type: constant.DocTypeFolder, FilePath: "/tmp/docs/知识库"},
+		{Type: constant.DocTypeDocx, FilePath: "/tmp/docs/知识库/a.md"},
+	}
+	stale, dirs, err := findStaleFiles(infoList, previous, "/tmp/docs")
+	require.NoError(t, err)
+	// a.md仍引用的img1.png和file1.pdf保留，只被已清理的文档引用的素材一并清理
+	require.Equal(t, []string{
+		"/tmp/docs/云空间/c.md",
+		"/tmp/docs/知识库/assets/file2.pdf",
+		"/tmp/docs/知识库/assets/img2.png",
+		"/tmp/docs/知识库/b.md",
+	}, stale)
+	require.Equal(t, []string{"/tmp/docs/知识库/assets", "/tmp/docs/云空间"}, dirs)
+
+	var output strings.Builder
+	require.NoError(t, pruneFiles(&output, PruneDelete, stale, dirs, "/tmp/docs"))
+	require.Equal(t, "已删除文件数量: 4, 已删除空目录数量: 0\n", output.String(), "目录中还有其他文件")
+	yes, err := app.Fs.Exists("/tmp/docs/知识库/assets/img1.png")
+	require.NoError(t, err)
+	require.True(t, yes)
+
+	// assets目录清空后一并删除
+	stale, dirs, err = findStaleFiles(nil, previous[:1], "/tmp/docs")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/docs/云空间/assets/file1.pdf", "/tmp/docs/知识库/a.md", "/tmp/docs/知识库/assets/img1.png"}, stale)
+	output.Reset()
+	require.NoError(t, pruneFiles(&output, PruneDelete, stale, dirs, "/tmp/docs"))
+	yes, err = app.Fs.Exists("/tmp/docs/知识库")
+	require.NoError(t, err)
+	require.False(t, yes)
+	yes, err = app.Fs.Exists("/tmp/docs/云空间/assets/user.txt")
+	require.NoError(t, err)
+	require.True(t, yes)
+}
+
+func TestScopePrevious(t *testing.T) {
+	newNode := func(typ constant.DocType, token string, children ...*DocumentNode) *DocumentNode {
+		return &DocumentNode{DocumentInfo: DocumentInfo{Type: typ, Token: token}, Children: children}
+	}
+	previous := []*DocumentNode{
+		newNode(constant.DocTypeFolder, "space",
+			newNode(constant.DocTypeDocx, "a",
+				newNode(constant.DocTypeDocx, "b"),
+			),
+			newNode(constant.DocTypeDocx, "c"),
+		),
+		newNode(constant.DocTypeFolder, "folder",
+			newNode(constant.DocTypeFile, "d"),
+		),
+		newNode(constant.DocTypeDocx, "e"),
+		newNode(constant.DocTypeFolder, ""),
+	}
+	tokens := func(dns []*DocumentNode) []string {
+		return lo.Map(dns, func(dn *DocumentNode, _ int) string { return dn.Token })
+	}
+
+	// 相同的文档源
+	require.Equal(t, []string{"space", "folder"}, tokens(scopePrevious([]*DocumentNode{
+		newNode(constant.DocTypeFolder, "space"),
+		newNode(constant.DocTypeFolder, "folder"),
+	}, previous)))
+	// 缩小文档源的范围，只对比文档源对应的子树
+	require.Equal(t, []string{"a"}, tokens(scopePrevious([]*DocumentNode{newNode(constant.DocTypeDocx, "a")}, previous)))
+	// 扩大文档源的范围，包含上一次的整棵树
+	require.Equal(t, []string{"e"}, tokens(scopePrevious([]*DocumentNode{
+		newNode(constant.DocTypeFolder, "root", newNode(constant.DocTypeDocx, "e")),
+	}, previous)))
+	// 其他文档源，类型不同的也不算相同
+	require.Empty(t, scopePrevious([]*DocumentNode{
+		newNode(constant.DocTypeFolder, "other"),
+		newNode(constant.DocTypeDocx, "folder"),
+		newNode(constant.DocTypeFolder, ""),
+	}, previous))
+}
+
+func TestPruneFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		wantExists map[string]bool
+		wantOutput string
+	}{
+		{
+			name: "dry-run",
+			mode: PruneDryRun,
+			wantExists: map[string]bool{
+				"/tmp/docs/知识库/b.docx":   true,
+				"/tmp/docs/知识库/b/c.docx": true,
+				"/tmp/docs/云空间/e.pdf":    true,
+			},
+			wantOutput: "",
+		},
+		{
+			name: "delete",
+			mode: PruneDelete,
+			wantExists: map[string]bool{
+				"/tmp/docs/知识库/a.docx":   true,
+				"/tmp/docs/知识库/b.docx":   false,
+				"/tmp/docs/知识库/b":        false,
+				"/tmp/docs/云空间/e.pdf":    false,
+				"/tmp/docs/云空间/user.txt": true,
+				"/tmp/docs/other.txt":    true,
+			},
+			wantOutput: "已删除文件数量: 3, 已删除空目录数量: 1\n",
+		},
+		{
+			name: "quarantine",
+			mode: PruneQuarantine,
+			wantExists: map[string]bool{
+				"/tmp/docs/知识库/a.docx":   true,
+				"/tmp/docs/知识库/b.docx":   false,
+				"/tmp/docs/知识库/b":        false,
+				"/tmp/docs/云空间/e.pdf":    false,
+				"/tmp/docs/云空间/user.txt": true,
+			},
+			wantOutput: "已隔离文件数量: 3, 隔离目录: /tmp/docs/.xdoc-trash/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infoList, previous := setupPruneFiles(t)
+			files, dirs, err := findStaleFiles(infoList, previous, "/tmp/docs")
+			require.NoError(t, err)
+			var output strings.Builder
+			err = pruneFiles(&output, tt.mode, files, dirs, "/tmp/docs")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(output.String(), tt.wantOutput), output.String())
+			for filePath, want := range tt.wantExists {
+				yes, err := app.Fs.Exists(filePath)
+				require.NoError(t, err)
+				require.Equal(t, want, yes, filePath)
+			}
+			if tt.mode == PruneQuarantine {
+				matches, err := afero.Glob(app.Fs, "/tmp/docs/.xdoc-trash/*/知识库/b/c.docx")
+				require.NoError(t, err)
+				require.Len(t, matches, 1)
+			}
+		})
+	}
+}
+
+func TestPrintStaleFiles(t *testing.T) {
+	var output strings.Builder
+	printStaleFiles(&output, PruneDelete, []string{"/tmp/a.docx"}, []string{"/tmp/b"})
+	require.Equal(t, `云文档已删除或已移动, 将被清理(delete)的文件数量: 1, 目录数量(为空时才清理): 1
+  [文件] /tmp/a.docx
+  [目录] /tmp/b
+`, output.String())
+}
+
+func TestIsSubPath(t *testing.T) {
+	require.True(t, isSubPath("/tmp", "/tmp/a"))
+	require.True(t, isSubPath("/tmp", "/tmp/a/b"))
+	require.True(t, isSubPath("/tmp", "/tmp/..a"))
+	require.False(t, isSubPath("/tmp", "/tmp"))
+	require.False(t, isSubPath("/tmp", "/"))
+	require.False(t, isSubPath("/tmp", "/tmp2/a"))
+	require.False(t, isSubPath("/tmp", "/tmp/../a"))
+}
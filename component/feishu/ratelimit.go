@@ -0,0 +1,143 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+)
+
+// 接口类别，同一类别的接口共用一个令牌桶。
+const (
+	RateLimitDrive    = "drive"    // 云空间文件元信息和文件清单
+	RateLimitDownload = "download" // 下载文件、素材和导出的文件
+	RateLimitWiki     = "wiki"     // 知识库
+	RateLimitExport   = "export"   // 创建和查询导出任务
+	RateLimitDocx     = "docx"     // 新版文档
+	RateLimitSheets   = "sheets"   // 电子表格
+	RateLimitBitable  = "bitable"  // 多维表格
+)
+
+// DefaultRateLimits 各类接口默认每分钟最多请求次数，参考飞书开放平台各接口文档中的频率限制。
+// https://open.feishu.cn/document/server-docs/api-call-guide/frequency-control
+var DefaultRateLimits = map[string]int{
+	RateLimitDrive:    1000,
+	RateLimitDownload: 300,
+	RateLimitWiki:     100,
+	RateLimitExport:   100,
+	RateLimitDocx:     300,
+	RateLimitSheets:   100,
+	RateLimitBitable:  1200,
+}
+
+// tokenBucket 令牌桶，按固定速率生成令牌，最多积攒1秒的令牌用于应对突发请求。
+type tokenBucket struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	interval time.Duration // 生成一个令牌的间隔
+	burst    float64       // 令牌桶容量
+	tokens   float64       // 当前令牌数量，为负数时表示已被预占
+	last     time.Time     // 上一次计算令牌数量的时间
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	burst := math.Max(1, math.Floor(float64(perMinute)/60))
+	return &tokenBucket{
+		now:      time.Now,
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    burst,
+		tokens:   burst,
+		last:     time.Now(),
+	}
+}
+
+// reserve 取走一个令牌，返回需要等待的时长，令牌不足时预占后续生成的令牌。
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+float64(elapsed)/float64(b.interval))
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.interval))
+}
+
+// cancel 归还 reserve 取走的令牌，用于等待期间被取消而没有发出的请求。
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// rateLimiter 客户端限流器，key为接口类别，没有令牌桶的类别不限流。
+type rateLimiter map[string]*tokenBucket
+
+// newRateLimiter 根据各类接口每分钟最多请求次数创建限流器，次数小于等于0时不限流。
+func newRateLimiter(limits map[string]int) rateLimiter {
+	rl := rateLimiter{}
+	for family, perMinute := range limits {
+		if perMinute > 0 {
+			rl[family] = newTokenBucket(perMinute)
+		}
+	}
+	return rl
+}
+
+// wait 等待直到可以请求该类接口，ctx已取消时直接返回错误，等待期间ctx被取消时归还令牌并返回错误。
+func (rl rateLimiter) wait(ctx context.Context, family string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b, ok := rl[family]
+	if !ok {
+		return nil
+	}
+	if d := b.reserve(); d > 0 {
+		if err := app.SleepContext(ctx, d); err != nil {
+			b.cancel()
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// validateRateLimits 校验接口类别和每分钟最多请求次数。
+func validateRateLimits(value any) error {
+	limits, _ := value.(map[string]int)
+	families := lo.Keys(limits)
+	sort.Strings(families)
+	for _, family := range families {
+		if _, ok := DefaultRateLimits[family]; !ok {
+			return oops.Errorf("rate-limits不支持的接口类别: %s", family)
+		}
+		if limits[family] < 0 {
+			return oops.Errorf("rate-limits的请求次数不能小于0: %s", family)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(120) // 每500毫秒生成一个令牌，容量为2
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	require.Equal(t, time.Duration(0), b.reserve())
+	require.Equal(t, time.Duration(0), b.reserve())
+	require.Equal(t, 500*time.Millisecond, b.reserve(), "令牌用完后预占下一个令牌")
+	require.Equal(t, time.Second, b.reserve(), "预占的令牌累加等待时长")
+
+	now = now.Add(1500 * time.Millisecond)
+	require.Equal(t, time.Duration(0), b.reserve(), "预占的令牌已经生成")
+
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), b.reserve())
+	require.Equal(t, time.Duration(0), b.reserve())
+	require.Equal(t, 500*time.Millisecond, b.reserve(), "最多只积攒容量大小的令牌")
+}
+
+func TestNewTokenBucket(t *testing.T) {
+	tests := []struct {
+		name      string
+		perMinute int
+		interval  time.Duration
+		burst     float64
+	}{
+		{"每分钟100次", 100, 600 * time.Millisecond, 1},
+		{"每分钟1000次", 1000, 60 * time.Millisecond, 16},
+		{"每分钟1次", 1, time.Minute, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTokenBucket(tt.perMinute)
+			require.Equal(t, tt.interval, b.interval)
+			require.InDelta(t, tt.burst, b.burst, 0)
+			require.InDelta(t, tt.burst, b.tokens, 0)
+		})
+	}
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	origin := app.SleepContext
+	defer func() {
+		app.SleepContext = origin
+	}()
+	var slept []time.Duration
+	app.SleepContext = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+
+	rl := newRateLimiter(map[string]int{RateLimitWiki: 60, RateLimitExport: 0})
+	require.Contains(t, rl, RateLimitWiki)
+	require.NotContains(t, rl, RateLimitExport, "请求次数为0时不限流")
+
+	now := time.Now()
+	rl[RateLimitWiki].now = func() time.Time { return now }
+	rl[RateLimitWiki].last = now
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, rl.wait(ctx, RateLimitWiki))
+	require.NoError(t, rl.wait(ctx, RateLimitWiki))
+	require.NoError(t, rl.wait(ctx, RateLimitExport))
+	require.NoError(t, rl.wait(ctx, RateLimitDrive))
+	require.Equal(t, []time.Duration{time.Second}, slept)
+
+	var nilLimiter rateLimiter
+	require.NoError(t, nilLimiter.wait(ctx, RateLimitWiki))
+	require.Len(t, slept, 1)
+
+	cancel()
+	require.ErrorIs(t, rl.wait(ctx, RateLimitDrive), context.Canceled, "ctx已取消时直接返回错误")
+	require.Len(t, slept, 1)
+}
+
+func TestRateLimiter_wait_canceled(t *testing.T) {
+	origin := app.SleepContext
+	defer func() {
+		app.SleepContext = origin
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 模拟等待期间收到中断信号
+	app.SleepContext = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	rl := newRateLimiter(map[string]int{RateLimitWiki: 60})
+	b := rl[RateLimitWiki]
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	b.last = now
+	require.NoError(t, rl.wait(ctx, RateLimitWiki))
+	require.InDelta(t, 0, b.tokens, 0)
+	require.ErrorIs(t, rl.wait(ctx, RateLimitWiki), context.Canceled, "等待期间ctx被取消时不再等待")
+	require.InDelta(t, 0, b.tokens, 0, "归还预占的令牌")
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  map[string]int
+		wantErr string
+	}{
+		{"为空", nil, ""},
+		{"默认值", DefaultRateLimits, ""},
+		{"不限流", map[string]int{RateLimitWiki: 0}, ""},
+		{"不支持的接口类别", map[string]int{"im": 10, "abc": 10}, "rate-limits不支持的接口类别: abc"},
+		{"请求次数小于0", map[string]int{RateLimitDocx: -1}, "rate-limits的请求次数不能小于0: docx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRateLimits(tt.limits)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
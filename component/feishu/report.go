@@ -0,0 +1,429 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 导出报告的格式，json格式总是会生成。
+const (
+	ReportJSON     = "json"
+	ReportHTML     = "html"
+	ReportMarkdown = "md"
+
+	reportFile = "export-report" // 导出报告文件名（不含扩展名），保存在 SaveDir 中
+)
+
+// logIDRegexp 匹配错误信息中的日志ID，见 getLogID。
+var logIDRegexp = regexp.MustCompile(`open\.feishu\.cn/search\?q=([0-9A-Za-z]+)`)
+
+type (
+	// report 导出报告，列出本次导出的每个文档的最终状态，用于归档审计。
+	report struct {
+		StartedAt  int64             `json:"startedAt"`  // 阶段2开始时间（Unix时间戳，毫秒）
+		FinishedAt int64             `json:"finishedAt"` // 阶段2结束时间（Unix时间戳，毫秒）
+		Duration   int64             `json:"duration"`   // 阶段2耗时（毫秒）
+		SaveDir    string            `json:"saveDir"`    // 文档存放目录
+		Summary    reportSummary     `json:"summary"`    // 统计信息，与下载UI程序的统计信息一致
+		Documents  []*reportDocument `json:"documents"`  // 文档列表，按文档树的顺序排列，不包括目录
+	}
+
+	// reportSummary 各状态的文档数量统计。
+	reportSummary struct {
+		Total       int `json:"total"`       // 文档总数量，包括不可下载的文档
+		CanDownload int `json:"canDownload"` // 可下载
+		Submitted   int `json:"submitted"`   // 已提交
+		Completed   int `json:"completed"`   // 已下载
+		Remaining   int `json:"remaining"`   // 未下载，已提交但未完成、未失败也未跳过的文档
+		Failed      int `json:"failed"`      // 已失败
+		Skipped     int `json:"skipped"`     // 已跳过
+		Interrupted int `json:"interrupted"` // 已中断
+	}
+
+	// reportDocument 导出报告中的一个文档。
+	reportDocument struct {
+		Name        string           `json:"name"`              // 文档名
+		Type        constant.DocType `json:"type"`              // 文档类型
+		URL         string           `json:"url"`               // 在浏览器中查看的链接
+		Token       string           `json:"token"`             // 文档token
+		FilePath    string           `json:"filePath"`          // 文件保存路径
+		CanDownload bool             `json:"canDownload"`       // 是否可下载
+		Status      string           `json:"status"`            // 最终状态，未提交的文档为空
+		Size        int64            `json:"size"`              // 本地文件大小（字节）
+		Duration    int64            `json:"duration"`          // 从提交到最终状态的耗时（毫秒）
+		Error       string           `json:"error,omitempty"`   // 失败原因
+		LogID       string           `json:"logId,omitempty"`   // 失败时飞书开放平台返回的日志ID，用于排查问题
+		Message     string           `json:"message,omitempty"` // 其他状态附带的消息，如跳过的原因
+	}
+
+	// reportRecorder 记录各文档提交和结束的时间，以及最终状态附带的消息。
+	reportRecorder struct {
+		lock    sync.Mutex
+		now     func() time.Time
+		records map[string]*reportRecord // key为文件保存路径
+	}
+
+	reportRecord struct {
+		startedAt  time.Time
+		finishedAt time.Time
+		msg        string
+	}
+)
+
+func newReportRecorder() *reportRecorder {
+	return &reportRecorder{now: time.Now, records: map[string]*reportRecord{}}
+}
+
+// add 记录文档提交到下载UI程序的时间。
+func (r *reportRecorder) add(filePath string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records[filePath] = &reportRecord{startedAt: r.now()}
+}
+
+// record 文档到达最终状态时记录结束时间和附带的消息。
+func (r *reportRecorder) record(filePath string, status progress.Status, msg string) {
+	if r == nil {
+		return
+	}
+	switch status {
+	case progress.StatusCompleted, progress.StatusFailed, progress.StatusSkipped, progress.StatusInterrupted:
+	default:
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	rec, ok := r.records[filePath]
+	if !ok {
+		rec = &reportRecord{startedAt: r.now()}
+		r.records[filePath] = rec
+	}
+	rec.finishedAt = r.now()
+	rec.msg = msg
+}
+
+// build 根据文档的最终状态生成导出报告。
+func (r *reportRecorder) build(infoList []*DocumentInfo, saveDir string, startedAt time.Time) *report {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	finishedAt := r.now()
+	rp := &report{
+		StartedAt:  startedAt.UnixMilli(),
+		FinishedAt: finishedAt.UnixMilli(),
+		Duration:   finishedAt.Sub(startedAt).Milliseconds(),
+		SaveDir:    saveDir,
+		Documents:  []*reportDocument{},
+	}
+	for _, di := range infoList {
+		// 已被过滤条件排除的文档不列入导出报告
+		if di.isDir() || di.Excluded {
+			continue
+		}
+		statusLock.Lock()
+		status := di.Status
+		statusLock.Unlock()
+		doc := &reportDocument{
+			Name:        di.Name,
+			Type:        di.Type,
+			URL:         di.URL,
+			Token:       di.Token,
+			FilePath:    di.FilePath,
+			CanDownload: di.CanDownload,
+		}
+		rp.Documents = append(rp.Documents, doc)
+		if !di.CanDownload {
+			continue
+		}
+		rec, ok := r.records[di.FilePath]
+		if !ok {
+			continue
+		}
+		doc.Status = progress.StatusName(status)
+		if !rec.finishedAt.IsZero() {
+			doc.Duration = rec.finishedAt.Sub(rec.startedAt).Milliseconds()
+		}
+		switch status {
+		case progress.StatusCompleted, progress.StatusSkipped:
+			info, err := app.Fs.Stat(di.FilePath)
+			if err == nil && !info.IsDir() {
+				doc.Size = info.Size()
+			}
+			doc.Message = rec.msg
+		case progress.StatusFailed:
+			doc.Error, doc.LogID = parseErrMsg(rec.msg)
+		case progress.StatusInterrupted:
+			doc.Message = rec.msg
+		}
+	}
+	rp.Summary = summarizeReport(rp.Documents)
+	return rp
+}
+
+// summarizeReport 按各文档的最终状态统计数量。
+func summarizeReport(docs []*reportDocument) (sm reportSummary) {
+	for _, doc := range docs {
+		sm.Total++
+		if !doc.CanDownload {
+			continue
+		}
+		sm.CanDownload++
+		if doc.Status == "" {
+			continue
+		}
+		sm.Submitted++
+		switch doc.Status {
+		case progress.StatusName(progress.StatusCompleted):
+			sm.Completed++
+		case progress.StatusName(progress.StatusSkipped):
+			sm.Skipped++
+		case progress.StatusName(progress.StatusFailed):
+			sm.Failed++
+		case progress.StatusName(progress.StatusInterrupted):
+			sm.Interrupted++
+		}
+	}
+	sm.Remaining = sm.Submitted - sm.Completed - sm.Failed - sm.Skipped
+	return sm
+}
+
+// mergeReport 将重试失败的文档生成的导出报告合并到 saveDir 中上一次的导出报告。
+// 重试过的文档替换上一次的记录，未重试的文档保留上一次的状态，再重新统计；上一次的导出报告不存在时直接返回 rp。
+func mergeReport(rp *report, saveDir string) (*report, error) {
+	filePath := filepath.Join(saveDir, reportFile+"."+ReportJSON)
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return rp, oops.Wrap(err)
+	}
+	previous, err := readReport(filePath)
+	if err != nil {
+		return rp, oops.Wrap(err)
+	}
+	// 只替换本次提交过的文档，路径上不下载的文档和未来得及提交的文档保留上一次的记录
+	retried := map[string]*reportDocument{}
+	for _, doc := range rp.Documents {
+		if doc.Status != "" {
+			retried[doc.FilePath] = doc
+		}
+	}
+	merged := &report{
+		StartedAt:  rp.StartedAt,
+		FinishedAt: rp.FinishedAt,
+		Duration:   rp.Duration,
+		SaveDir:    rp.SaveDir,
+		Documents:  make([]*reportDocument, 0, len(previous.Documents)),
+	}
+	for _, doc := range previous.Documents {
+		if d, ok := retried[doc.FilePath]; ok {
+			doc = d
+			delete(retried, doc.FilePath)
+		}
+		merged.Documents = append(merged.Documents, doc)
+	}
+	for _, doc := range rp.Documents {
+		if _, ok := retried[doc.FilePath]; ok {
+			merged.Documents = append(merged.Documents, doc)
+		}
+	}
+	merged.Summary = summarizeReport(merged.Documents)
+	return merged, nil
+}
+
+// readReport 读取json格式的导出报告。
+func readReport(filePath string) (*report, error) {
+	data, err := app.Fs.ReadFile(filePath)
+	if err != nil {
+		return nil, oops.Wrapf(err, "读取导出报告失败")
+	}
+	var rp report
+	if err = json.Unmarshal(data, &rp); err != nil {
+		return nil, oops.Wrapf(err, "解析导出报告失败: %s", filePath)
+	}
+	return &rp, nil
+}
+
+// parseErrMsg 去掉错误信息中的终端样式，并取出其中的日志ID。
+func parseErrMsg(msg string) (errMsg, logID string) {
+	errMsg = progress.StripStyle(msg)
+	if match := logIDRegexp.FindStringSubmatch(errMsg); match != nil {
+		logID = match[1]
+	}
+	return errMsg, logID
+}
+
+// validateReportFormats 校验额外生成的导出报告格式。
+func validateReportFormats(value any) error {
+	formats, _ := value.([]string)
+	for _, format := range formats {
+		switch format {
+		case ReportJSON, ReportHTML, ReportMarkdown:
+		default:
+			return oops.Errorf("report-formats只能是json、html或md: %s", format)
+		}
+	}
+	return nil
+}
+
+// saveReport 将导出报告保存到 saveDir 中，总是保存json格式，formats中指定的html、md格式也一并保存，返回保存的文件路径。
+func saveReport(rp *report, saveDir string, formats []string) (filePaths []string, err error) {
+	if err = app.Fs.MkdirAll(saveDir, 0o755); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	renders := []struct {
+		format string
+		render func(*report) ([]byte, error)
+	}{
+		{ReportJSON, renderReportJSON},
+		{ReportHTML, renderReportHTML},
+		{ReportMarkdown, renderReportMarkdown},
+	}
+	for _, r := range renders {
+		if r.format != ReportJSON && !lo.Contains(formats, r.format) {
+			continue
+		}
+		data, err := r.render(rp)
+		if err != nil {
+			return filePaths, oops.Wrap(err)
+		}
+		filePath := filepath.Join(saveDir, reportFile+"."+r.format)
+		if err = app.Fs.WriteFile(filePath, data, 0o644); err != nil {
+			return filePaths, oops.Wrapf(err, "写入导出报告失败")
+		}
+		filePaths = append(filePaths, filePath)
+	}
+	return filePaths, nil
+}
+
+func renderReportJSON(rp *report) ([]byte, error) {
+	data, err := app.MarshalIndent(rp, "", "  ")
+	return data, oops.Wrap(err)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"datetime": formatMilli,
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<title>飞书云文档导出报告</title>
+<style>
+body { font-family: sans-serif; margin: 24px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; font-size: 14px; }
+th { background: #f5f5f5; }
+.failed { color: #d93025; }
+.interrupted { color: #e37400; }
+</style>
+</head>
+<body>
+<h1>飞书云文档导出报告</h1>
+<p>开始时间: {{datetime .StartedAt}}, 结束时间: {{datetime .FinishedAt}}, 耗时: {{.Duration}}ms</p>
+<p>文档存放目录: {{.SaveDir}}</p>
+<p>{{.Summary}}</p>
+<table>
+<tr><th>文档名</th><th>类型</th><th>token</th><th>文件保存路径</th><th>状态</th><th>大小(字节)</th><th>耗时(ms)</th><th>错误信息</th><th>logId</th></tr>
+{{- range .Documents}}
+<tr class="{{.Status}}"><td>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{.Type}}</td><td>{{.Token}}</td><td>{{.FilePath}}</td><td>{{if .CanDownload}}{{.Status}}{{else}}不可下载{{end}}</td><td>{{.Size}}</td><td>{{.Duration}}</td><td>{{.Error}}{{.Message}}</td><td>{{.LogID}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+func renderReportHTML(rp *report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, rp); err != nil {
+		return nil, oops.Wrap(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderReportMarkdown(rp *report) ([]byte, error) {
+	var buf bytes.Buffer
+	app.Fprintf(&buf, "# 飞书云文档导出报告\n\n")
+	app.Fprintf(&buf, "- 开始时间: %s\n", formatMilli(rp.StartedAt))
+	app.Fprintf(&buf, "- 结束时间: %s\n", formatMilli(rp.FinishedAt))
+	app.Fprintf(&buf, "- 耗时: %dms\n", rp.Duration)
+	app.Fprintf(&buf, "- 文档存放目录: %s\n", rp.SaveDir)
+	app.Fprintf(&buf, "- %s\n\n", rp.Summary)
+	app.Fprintf(&buf, "| 文档名 | 类型 | token | 文件保存路径 | 状态 | 大小(字节) | 耗时(ms) | 错误信息 | logId |\n")
+	app.Fprintf(&buf, "| --- | --- | --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, doc := range rp.Documents {
+		name := escapeTableCell(doc.Name)
+		if doc.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", name, doc.URL)
+		}
+		status := doc.Status
+		if !doc.CanDownload {
+			status = "不可下载"
+		}
+		app.Fprintf(&buf, "| %s | %s | %s | %s | %s | %d | %d | %s | %s |\n",
+			name, doc.Type, doc.Token, escapeTableCell(doc.FilePath), status, doc.Size, doc.Duration,
+			escapeTableCell(doc.Error+doc.Message), doc.LogID)
+	}
+	return buf.Bytes(), nil
+}
+
+// String 各状态的文档数量，用于导出报告和日志。
+func (sm reportSummary) String() string {
+	return fmt.Sprintf("文档总数: %d, 可下载: %d, 已提交: %d, 已下载: %d, 未下载: %d, 已失败: %d, 已跳过: %d, 已中断: %d",
+		sm.Total, sm.CanDownload, sm.Submitted, sm.Completed, sm.Remaining, sm.Failed, sm.Skipped, sm.Interrupted)
+}
+
+// ReadReportSummary 读取 saveDir 中导出报告的统计信息，导出报告不存在或早于 since 时返回空字符串。
+// 用于定时任务等在导出结束后输出本次导出的摘要。
+func ReadReportSummary(saveDir string, since time.Time) (string, error) {
+	filePath := filepath.Join(saveDir, reportFile+"."+ReportJSON)
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil || !yes {
+		return "", oops.Wrap(err)
+	}
+	rp, err := readReport(filePath)
+	if err != nil {
+		return "", oops.Wrap(err)
+	}
+	if rp.FinishedAt < since.UnixMilli() {
+		return "", nil
+	}
+	return rp.Summary.String(), nil
+}
+
+// escapeTableCell 转义Markdown表格单元格中的竖线和换行。
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func formatMilli(ms int64) string {
+	return time.UnixMilli(ms).Format(time.DateTime)
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// setupReport 构造各种最终状态的文档并记录。
+func setupReport(t *testing.T) (*reportRecorder, []*DocumentInfo) {
+	useMemMapFs()
+	require.NoError(t, app.Fs.MkdirAll("/tmp/docs", 0o755))
+	require.NoError(t, app.Fs.WriteFile("/tmp/docs/a.docx", []byte("hello"), 0o644))
+	require.NoError(t, app.Fs.WriteFile("/tmp/docs/b.md", []byte("# b"), 0o644))
+	infoList := []*DocumentInfo{
+		{Name: "文件夹", Type: constant.DocTypeFolder, Token: "f", FilePath: "/tmp/docs/文件夹"},
+		{Name: "a", Type: constant.DocTypeDocx, Token: "a", URL: "https://sample.feishu.cn/wiki/a", CanDownload: true,
+			FilePath: "/tmp/docs/a.docx", Status: progress.StatusCompleted},
+		{Name: "b", Type: constant.DocTypeDocx, Token: "b", CanDownload: true, FilePath: "/tmp/docs/b.md", Status: progress.StatusSkipped},
+		{Name: "c|d", Type: constant.DocTypeSheet, Token: "c", CanDownload: true, FilePath: "/tmp/docs/c|d.xlsx", Status: progress.StatusFailed},
+		{Name: "e", Type: constant.DocTypeDocx, Token: "e", CanDownload: true, FilePath: "/tmp/docs/e.docx", Status: progress.StatusInterrupted},
+		{Name: "f", Type: constant.DocTypeDocx, Token: "f", CanDownload: true, FilePath: "/tmp/docs/f.docx", Status: progress.StatusDownloading},
+		{Name: "g", Type: constant.DocTypeDocx, Token: "g", CanDownload: true, FilePath: "/tmp/docs/g.docx"},
+		{Name: "h", Type: constant.DocTypeMindNote, Token: "h", FilePath: "/tmp/docs/h.mindnote"},
+	}
+	now := time.UnixMilli(1735801445000)
+	r := newReportRecorder()
+	r.now = func() time.Time {
+		return now
+	}
+	for _, di := range infoList[1:6] {
+		r.add(di.FilePath)
+	}
+	now = now.Add(1500 * time.Millisecond)
+	r.record("/tmp/docs/a.docx", progress.StatusDownloading, "不是最终状态不记录")
+	r.record("/tmp/docs/a.docx", progress.StatusCompleted, "")
+	r.record("/tmp/docs/b.md", progress.StatusSkipped, "未变更")
+	r.record("/tmp/docs/c|d.xlsx", progress.StatusFailed,
+		toErrMsg(&mockError{logID: "20250102150405ABCDEF", msg: "code: 1069902\nmsg: no permission"}, "创建导出任务"))
+	r.record("/tmp/docs/e.docx", progress.StatusInterrupted, "")
+	return r, infoList
+}
+
+func TestReportRecorder_build(t *testing.T) {
+	r, infoList := setupReport(t)
+	rp := r.build(infoList, "/tmp/docs", time.UnixMilli(1735801444000))
+	require.Equal(t, int64(1735801444000), rp.StartedAt)
+	require.Equal(t, int64(1735801446500), rp.FinishedAt)
+	require.Equal(t, int64(2500), rp.Duration)
+	require.Equal(t, reportSummary{
+		Total: 7, CanDownload: 6, Submitted: 5, Completed: 1, Remaining: 2, Failed: 1, Skipped: 1, Interrupted: 1,
+	}, rp.Summary)
+	// 与下载UI程序的统计信息一致
+	stats := calculateOverallProgress(rp.Summary.CanDownload)(rp.Summary.Submitted, rp.Summary.Completed, rp.Summary.Failed, rp.Summary.Skipped)
+	require.Contains(t, stats, "可下载: 6, 已提交: 5, 已下载: 1, 未下载: 2, 已失败: 1, 已跳过: 1")
+
+	require.Len(t, rp.Documents, 7, "不包括目录")
+	require.Equal(t, &reportDocument{
+		Name: "a", Type: constant.DocTypeDocx, URL: "https://sample.feishu.cn/wiki/a", Token: "a", FilePath: "/tmp/docs/a.docx",
+		CanDownload: true, Status: "completed", Size: 5, Duration: 1500,
+	}, rp.Documents[0])
+	require.Equal(t, &reportDocument{
+		Name: "b", Type: constant.DocTypeDocx, Token: "b", FilePath: "/tmp/docs/b.md",
+		CanDownload: true, Status: "skipped", Size: 3, Duration: 1500, Message: "未变更",
+	}, rp.Documents[1])
+	require.Equal(t, &reportDocument{
+		Name: "c|d", Type: constant.DocTypeSheet, Token: "c", FilePath: "/tmp/docs/c|d.xlsx", CanDownload: true, Status: "failed", Duration: 1500,
+		Error: "logId: https://open.feishu.cn/search?q=20250102150405ABCDEF, 操作: 创建导出任务, 响应错误: code: 1069902 msg: no permission",
+		LogID: "20250102150405ABCDEF",
+	}, rp.Documents[2])
+	require.Equal(t, "interrupted", rp.Documents[3].Status)
+	require.Equal(t, &reportDocument{
+		Name: "f", Type: constant.DocTypeDocx, Token: "f", FilePath: "/tmp/docs/f.docx", CanDownload: true, Status: "downloading",
+	}, rp.Documents[4], "已提交但未到达最终状态")
+	require.Equal(t, "", rp.Documents[5].Status, "未提交")
+	require.False(t, rp.Documents[6].CanDownload)
+}
+
+func TestSaveReport(t *testing.T) {
+	r, infoList := setupReport(t)
+	rp := r.build(infoList, "/tmp/docs", time.UnixMilli(1735801444000))
+
+	filePaths, err := saveReport(rp, "/tmp/docs", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/docs/export-report.json"}, filePaths)
+	data, err := app.Fs.ReadFile("/tmp/docs/export-report.json")
+	require.NoError(t, err)
+	var got report
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, rp, &got)
+
+	filePaths, err = saveReport(rp, "/tmp/docs", []string{ReportMarkdown, ReportHTML})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/docs/export-report.json", "/tmp/docs/export-report.html", "/tmp/docs/export-report.md"}, filePaths)
+
+	data, err = app.Fs.ReadFile("/tmp/docs/export-report.html")
+	require.NoError(t, err)
+	html := string(data)
+	require.Contains(t, html, "文档总数: 7, 可下载: 6, 已提交: 5, 已下载: 1, 未下载: 2, 已失败: 1, 已跳过: 1, 已中断: 1")
+	require.Contains(t, html, `<tr class="completed"><td><a href="https://sample.feishu.cn/wiki/a">a</a></td><td>docx</td><td>a</td><td>/tmp/docs/a.docx</td><td>completed</td><td>5</td><td>1500</td><td></td><td></td></tr>`)
+	require.Contains(t, html, "<td>20250102150405ABCDEF</td>")
+	require.Contains(t, html, "<td>h</td><td>mindnote</td><td>h</td><td>/tmp/docs/h.mindnote</td><td>不可下载</td>")
+
+	data, err = app.Fs.ReadFile("/tmp/docs/export-report.md")
+	require.NoError(t, err)
+	md := string(data)
+	require.Contains(t, md, "- 文档总数: 7, 可下载: 6, 已提交: 5, 已下载: 1, 未下载: 2, 已失败: 1, 已跳过: 1, 已中断: 1\n")
+	require.Contains(t, md, "| [a](https://sample.feishu.cn/wiki/a) | docx | a | /tmp/docs/a.docx | completed | 5 | 1500 |  |  |\n")
+	require.Contains(t, md, `| c\|d | sheet | c | /tmp/docs/c\|d.xlsx | failed | 0 | 1500 | logId: `)
+	require.Contains(t, md, "| b | docx | b | /tmp/docs/b.md | skipped | 3 | 1500 | 未变更 |  |\n")
+	require.Equal(t, 9, strings.Count(md, "\n|"), "表头、分隔行和7个文档")
+
+	// 写入失败
+	useFs(&afero.Afero{Fs: afero.NewReadOnlyFs(app.Fs.Fs)})
+	defer useMemMapFs()
+	_, err = saveReport(rp, "/tmp/docs", nil)
+	require.Error(t, err)
+}
+
+func TestReadReportSummary(t *testing.T) {
+	r, infoList := setupReport(t)
+	rp := r.build(infoList, "/tmp/docs", time.UnixMilli(1735801444000))
+
+	// 导出报告不存在
+	summary, err := ReadReportSummary("/tmp/docs", time.UnixMilli(1735801444000))
+	require.NoError(t, err)
+	require.Equal(t, "", summary)
+
+	_, err = saveReport(rp, "/tmp/docs", nil)
+	require.NoError(t, err)
+	summary, err = ReadReportSummary("/tmp/docs", time.UnixMilli(1735801444000))
+	require.NoError(t, err)
+	require.Equal(t, "文档总数: 7, 可下载: 6, 已提交: 5, 已下载: 1, 未下载: 2, 已失败: 1, 已跳过: 1, 已中断: 1", summary)
+
+	// 上一次导出的报告
+	summary, err = ReadReportSummary("/tmp/docs", time.UnixMilli(1735801447000))
+	require.NoError(t, err)
+	require.Equal(t, "", summary)
+
+	// 导出报告格式不正确
+	require.NoError(t, app.Fs.WriteFile("/tmp/docs/export-report.json", []byte("{"), 0o644))
+	_, err = ReadReportSummary("/tmp/docs", time.UnixMilli(1735801444000))
+	require.ErrorContains(t, err, "解析导出报告失败: /tmp/docs/export-report.json")
+}
+
+func TestParseErrMsg(t *testing.T) {
+	errMsg, logID := parseErrMsg(toErrMsg(&mockError{logID: "123abc", msg: "error"}, "下载导出文件"))
+	require.Equal(t, "logId: https://open.feishu.cn/search?q=123abc, 操作: 下载导出文件, 响应错误: error", errMsg)
+	require.Equal(t, "123abc", logID)
+
+	errMsg, logID = parseErrMsg("响应错误: timeout")
+	require.Equal(t, "响应错误: timeout", errMsg)
+	require.Equal(t, "", logID)
+}
+
+func TestValidateReportFormats(t *testing.T) {
+	require.NoError(t, validateReportFormats([]string{}))
+	require.NoError(t, validateReportFormats([]string{ReportJSON, ReportHTML, ReportMarkdown}))
+	require.EqualError(t, validateReportFormats([]string{"pdf"}), "report-formats只能是json、html或md: pdf")
+}
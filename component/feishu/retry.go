@@ -0,0 +1,148 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/xlab/treeprint"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// loadFailedDocuments 读取上一次的导出报告（.json）或导出日志（.jsonl），返回失败的文档（key为文件保存路径，value为文档token）。
+func loadFailedDocuments(filePath string) (map[string]string, error) {
+	yes, err := app.Fs.Exists(filePath)
+	if err != nil {
+		return nil, oops.Wrap(err)
+	}
+	if !yes {
+		return nil, oops.Code("InvalidArgument").Errorf("retry-failed指定的文件不存在: %s", filePath)
+	}
+	failed := map[string]string{}
+	switch filepath.Ext(filePath) {
+	case ".jsonl":
+		states, err := readJournal(filePath)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		for _, state := range states {
+			if state.Status == progress.StatusFailed {
+				failed[state.FilePath] = state.Token
+			}
+		}
+	case ".json":
+		rp, err := readReport(filePath)
+		if err != nil {
+			return nil, oops.Wrap(err)
+		}
+		failedName := progress.StatusName(progress.StatusFailed)
+		for _, doc := range rp.Documents {
+			if doc.Status == failedName {
+				failed[doc.FilePath] = doc.Token
+			}
+		}
+	default:
+		return nil, oops.Code("InvalidArgument").Errorf("retry-failed只支持导出报告(%s.json)或导出日志(%s): %s", reportFile, journalFile, filePath)
+	}
+	return failed, nil
+}
+
+// selectDocuments 从文档树中选出满足条件的可下载文档，只保留从根节点到这些文档的路径。
+// 返回的是新构造的文档树，路径上未选中的文档标记为不可下载，选中的文档清空上一次的状态。
+func selectDocuments(dns []*DocumentNode, selected func(di *DocumentInfo) bool) []*DocumentNode {
+	var result []*DocumentNode
+	for _, dn := range dns {
+		children := selectDocuments(dn.Children, selected)
+		node := &DocumentNode{DocumentInfo: dn.DocumentInfo, Children: children}
+		switch {
+		case dn.CanDownload && selected(&dn.DocumentInfo):
+			node.Status = ""
+		case len(children) > 0:
+			node.CanDownload = false
+		default:
+			continue
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// retryFailed 根据上一次的导出报告或导出日志，只重新导出失败的文档，不再重新读取文档树。
+// 文档信息取自 SaveDir 中上一次保存的 document-tree.json，重试完成后将最终状态合并回完整的文档树再保存。
+func (c *ClientImpl) retryFailed(ctx context.Context) error {
+	logOutput := c.Args.LogOutput(os.Stdout)
+	app.Fprintln(logOutput, "阶段1: 读取上一次失败的文档")
+	app.Fprintln(logOutput, "--------------------------")
+	failed, err := loadFailedDocuments(c.Args.RetryFailed)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	previous, err := loadDocumentTree(c.Args.SaveDir)
+	if err != nil {
+		return oops.Wrap(err)
+	}
+	if previous == nil {
+		return oops.Errorf("%s中没有上一次的文档树%s, 无法重试失败的文档", c.Args.SaveDir, documentTreeFile)
+	}
+	// 按当前的SaveDir和路径模板重新计算文件保存路径并截断超长的路径，与导出报告或导出日志中的路径对应
+	infoList := documentNodesToInfoList(previous, c.Args.SaveDir)
+	_ = applyPathTemplate(previous, c.Args.SaveDir, c.Args.PathTemplate)
+	_ = newPathSanitizer(c.Args).limitPaths(infoList, c.Args.SaveDir)
+	dns := selectDocuments(previous, func(di *DocumentInfo) bool {
+		token, ok := failed[di.FilePath]
+		return ok && token == di.Token
+	})
+
+	app.Fprintln(logOutput, "预计将重试的目录或文件如下:")
+	tree := treeprint.NewWithRoot(c.Args.SaveDir)
+	_, retryCount := printTree(logOutput, tree, dns, 0, 0)
+	app.Fprintf(logOutput, "\n上一次失败的文档数量: %d, 将重试的文档数量: %d\n", len(failed), retryCount)
+	app.Fprintln(logOutput, "--------------------------")
+	app.Fprintf(logOutput, "阶段1, 耗时: %s\n", time.Since(c.Args.StartTime).String())
+	app.Fprintln(logOutput, "----------------------------------------------")
+	if c.Args.ListOnly || retryCount == 0 {
+		return nil
+	}
+
+	err = c.runTask(ctx, dns)
+	// 将重试的最终状态合并回完整的文档树，保证下次增量导出和重试时仍能使用
+	retried := map[string]progress.Status{}
+	for _, di := range flattenDocumentNodes(dns) {
+		if di.CanDownload {
+			retried[di.FilePath] = di.Status
+		}
+	}
+	for _, di := range infoList {
+		if status, ok := retried[di.FilePath]; ok {
+			di.Status = status
+		}
+	}
+	if er := saveDocumentTree(previous, c.Args.SaveDir); er != nil && err == nil {
+		err = er
+	}
+	if err != nil && !isPartialFailure(err) {
+		return oops.Wrap(err)
+	}
+	if er := rewriteLinks(logOutput, infoList, c.Args.RewriteDocxLinks); er != nil {
+		return oops.Wrap(er)
+	}
+	return oops.Wrap(err)
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/app"
+	"github.com/acyumi/xdoc/component/argument"
+	"github.com/acyumi/xdoc/component/cloud"
+	"github.com/acyumi/xdoc/component/constant"
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+func TestLoadFailedDocuments(t *testing.T) {
+	useMemMapFs()
+	// 导出日志
+	j, err := openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/a.docx", Token: "a", Status: progress.StatusFailed})
+	j.write(journalEntry{FilePath: "/tmp/docs/a.docx", Token: "a", Status: progress.StatusCompleted})
+	j.write(journalEntry{FilePath: "/tmp/docs/b.docx", Token: "b", Status: progress.StatusFailed, Msg: "失败了"})
+	j.write(journalEntry{FilePath: "/tmp/docs/c.docx", Token: "c", Status: progress.StatusInterrupted})
+	j.close()
+	failed, err := loadFailedDocuments("/tmp/docs/export-journal.jsonl")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"/tmp/docs/b.docx": "b"}, failed, "只取最后的状态为失败的文档")
+
+	// 导出报告
+	_, err = saveReport(&report{Documents: []*reportDocument{
+		{FilePath: "/tmp/docs/a.docx", Token: "a", Status: "completed"},
+		{FilePath: "/tmp/docs/d.xlsx", Token: "d", Status: "failed"},
+		{FilePath: "/tmp/docs/e.docx", Token: "e"},
+	}}, "/tmp/docs", nil)
+	require.NoError(t, err)
+	failed, err = loadFailedDocuments("/tmp/docs/export-report.json")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"/tmp/docs/d.xlsx": "d"}, failed)
+
+	// 文件不存在
+	_, err = loadFailedDocuments("/tmp/docs/not-exists.json")
+	require.EqualError(t, err, "retry-failed指定的文件不存在: /tmp/docs/not-exists.json")
+	var oopsError oops.OopsError
+	require.True(t, errors.As(err, &oopsError))
+	require.Equal(t, "InvalidArgument", oopsError.Code())
+
+	// 不支持的文件
+	require.NoError(t, app.Fs.WriteFile("/tmp/docs/document-tree.yaml", []byte("[]"), 0o644))
+	_, err = loadFailedDocuments("/tmp/docs/document-tree.yaml")
+	require.EqualError(t, err, "retry-failed只支持导出报告(export-report.json)或导出日志(export-journal.jsonl): /tmp/docs/document-tree.yaml")
+
+	// 导出报告格式不正确
+	require.NoError(t, app.Fs.WriteFile("/tmp/docs/bad.json", []byte("{"), 0o644))
+	_, err = loadFailedDocuments("/tmp/docs/bad.json")
+	require.ErrorContains(t, err, "解析导出报告失败: /tmp/docs/bad.json")
+}
+
+// newRetryTree 构造上一次的文档树。
+//
+//	/tmp/docs
+//	└─ 知识库
+//	    ├─ a.docx      已完成
+//	    ├─ a
+//	    │   └─ b.docx  失败
+//	    └─ c.pdf       失败，但token已变化
+func newRetryTree() []*DocumentNode {
+	return []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: "知识库", Type: constant.DocTypeFolder, Token: "space"},
+			Children: []*DocumentNode{
+				{
+					DocumentInfo: DocumentInfo{Name: "a", Type: constant.DocTypeDocx, Token: "a", FileExtension: constant.FileExtDocx,
+						CanDownload: true, Status: progress.StatusCompleted},
+					Children: []*DocumentNode{
+						{DocumentInfo: DocumentInfo{Name: "b", Type: constant.DocTypeDocx, Token: "b", FileExtension: constant.FileExtDocx,
+							CanDownload: true, Status: progress.StatusFailed}},
+					},
+				},
+				{DocumentInfo: DocumentInfo{Name: "c", Type: constant.DocTypeFile, Token: "c2", FileExtension: constant.FileExtPDF,
+					CanDownload: true, DownloadDirectly: true, Status: progress.StatusFailed}},
+			},
+		},
+	}
+}
+
+func TestSelectDocuments(t *testing.T) {
+	dns := newRetryTree()
+	_ = documentNodesToInfoList(dns, "/tmp/docs")
+	selected := selectDocuments(dns, func(di *DocumentInfo) bool {
+		return di.Token == "b" || di.Token == "space"
+	})
+	require.Len(t, selected, 1)
+	require.Equal(t, "知识库", selected[0].Name)
+	require.Len(t, selected[0].Children, 1, "c.pdf未选中")
+	a := selected[0].Children[0]
+	require.Equal(t, "a", a.Name)
+	require.False(t, a.CanDownload, "路径上未选中的文档不下载")
+	require.Len(t, a.Children, 1)
+	b := a.Children[0]
+	require.True(t, b.CanDownload)
+	require.Equal(t, progress.Status(""), b.Status, "选中的文档清空上一次的状态")
+	// 不修改原来的文档树
+	require.True(t, dns[0].Children[0].CanDownload)
+	require.Equal(t, progress.StatusFailed, dns[0].Children[0].Children[0].Status)
+
+	require.Empty(t, selectDocuments(dns, func(*DocumentInfo) bool { return false }))
+}
+
+func TestClientImpl_retryFailed(t *testing.T) {
+	useMemMapFs()
+	cleanSleep()
+	require.NoError(t, saveDocumentTree(newRetryTree(), "/tmp/docs"))
+	j, err := openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/知识库/a/b.docx", Token: "b", Status: progress.StatusFailed})
+	j.write(journalEntry{FilePath: "/tmp/docs/知识库/c.pdf", Token: "c", Status: progress.StatusFailed})
+	j.close()
+
+	mockTask := NewMockTask(t)
+	var gotDocs []*DocumentNode
+	c := &ClientImpl{
+		Args: &Args{
+			Args:        &argument.Args{StartTime: time.Now(), Progress: progress.ModePlain},
+			SaveDir:     "/tmp/docs",
+			RetryFailed: "/tmp/docs/export-journal.jsonl",
+		},
+		TaskCreator: func(args *Args, docs []*DocumentNode) cloud.Task {
+			gotDocs = docs
+			return mockTask
+		},
+	}
+	mockTask.EXPECT().Validate().Return(nil).Once()
+	mockTask.EXPECT().Run(mock.Anything).RunAndReturn(func(ctx context.Context) error {
+		// 模拟任务执行，重试成功
+		infoList := flattenDocumentNodes(gotDocs)
+		for _, di := range infoList {
+			if di.CanDownload {
+				di.Status = progress.StatusCompleted
+			}
+		}
+		return nil
+	}).Once()
+	mockTask.EXPECT().Close().Return().Once()
+	err = c.DownloadDocuments(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, c.Args.QuitAutomatically)
+
+	// 只重试token未变化的失败文档
+	infoList := flattenDocumentNodes(gotDocs)
+	var retried []string
+	for _, di := range infoList {
+		if di.CanDownload {
+			retried = append(retried, di.FilePath)
+		}
+	}
+	require.Equal(t, []string{"/tmp/docs/知识库/a/b.docx"}, retried)
+
+	// 最终状态合并回完整的文档树
+	dns, err := loadDocumentTree("/tmp/docs")
+	require.NoError(t, err)
+	require.Len(t, dns[0].Children, 2, "保存的是完整的文档树")
+	require.Equal(t, progress.StatusCompleted, dns[0].Children[0].Status)
+	require.True(t, dns[0].Children[0].CanDownload)
+	require.Equal(t, progress.StatusCompleted, dns[0].Children[0].Children[0].Status)
+	require.Equal(t, progress.StatusFailed, dns[0].Children[1].Status)
+
+	// 没有需要重试的文档时不创建任务
+	_, err = saveReport(&report{Documents: []*reportDocument{}}, "/tmp/docs", nil)
+	require.NoError(t, err)
+	c.Args.RetryFailed = "/tmp/docs/export-report.json"
+	err = c.DownloadDocuments(context.Background(), nil)
+	require.NoError(t, err)
+
+	// 没有上一次的文档树
+	c.Args.SaveDir = "/tmp/other"
+	err = c.DownloadDocuments(context.Background(), nil)
+	require.EqualError(t, err, "/tmp/other中没有上一次的文档树document-tree.json, 无法重试失败的文档")
+}
+
+func TestClientImpl_retryFailed_pathTemplate(t *testing.T) {
+	useMemMapFs()
+	cleanSleep()
+	require.NoError(t, saveDocumentTree(newRetryTree(), "/tmp/docs"))
+	j, err := openJournal("/tmp/docs", false)
+	require.NoError(t, err)
+	j.write(journalEntry{FilePath: "/tmp/docs/docx/b_b.docx", Token: "b", Status: progress.StatusFailed})
+	j.close()
+
+	mockTask := NewMockTask(t)
+	var gotDocs []*DocumentNode
+	c := &ClientImpl{
+		Args: &Args{
+			Args:         &argument.Args{StartTime: time.Now(), Progress: progress.ModePlain},
+			SaveDir:      "/tmp/docs",
+			RetryFailed:  "/tmp/docs/export-journal.jsonl",
+			PathTemplate: "{type}/{title}_{token}.{ext}",
+		},
+		TaskCreator: func(args *Args, docs []*DocumentNode) cloud.Task {
+			gotDocs = docs
+			return mockTask
+		},
+	}
+	mockTask.EXPECT().Validate().Return(nil).Once()
+	mockTask.EXPECT().Run(mock.Anything).Return(nil).Once()
+	mockTask.EXPECT().Close().Return().Once()
+	require.NoError(t, c.DownloadDocuments(context.Background(), nil))
+
+	// 按路径模板计算的路径与导出日志中的路径对应
+	var retried []string
+	for _, di := range flattenDocumentNodes(gotDocs) {
+		if di.CanDownload {
+			retried = append(retried, di.FilePath)
+		}
+	}
+	require.Equal(t, []string{"/tmp/docs/docx/b_b.docx"}, retried)
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 文件名清理策略。
+const (
+	NamePolicyPortable = "portable" // 在Windows、macOS、Linux上都合法，拷贝到其他系统也能正常使用
+	NamePolicyPOSIX    = "posix"    // 只处理类Unix系统不允许的文件名
+)
+
+// 文件名的Unicode规范化形式。
+const (
+	UnicodeFormNFC  = "nfc"  // 组合形式，Windows和Linux上常用的形式
+	UnicodeFormNFD  = "nfd"  // 分解形式，macOS的HFS+使用的形式
+	UnicodeFormNone = "none" // 不规范化
+)
+
+const (
+	maxNameBytes       = 255  // 各级目录名或文件名的最大字节数，Linux等大多数文件系统的限制
+	windowsMaxPathSize = 259  // Windows的MAX_PATH为260，包括结尾的NUL
+	posixMaxPathSize   = 4095 // Linux的PATH_MAX为4096，包括结尾的NUL
+)
+
+// windowsReservedNames Windows的保留设备名，不区分大小写，带扩展名时也不能使用。
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// pathSanitizer 将文档名转为合法的文件名，并截断超长的保存路径。
+type pathSanitizer struct {
+	policy        string
+	form          string
+	maxPathLength int  // 保存路径（绝对路径）的最大长度
+	utf16         bool // 是否按UTF-16编码单元计算路径长度（Windows），否则按字节计算
+}
+
+func newPathSanitizer(args *Args) *pathSanitizer {
+	s := &pathSanitizer{policy: NamePolicyPortable, form: UnicodeFormNFC, utf16: runtime.GOOS == "windows"}
+	if args != nil {
+		if args.NamePolicy != "" {
+			s.policy = args.NamePolicy
+		}
+		if args.UnicodeForm != "" {
+			s.form = args.UnicodeForm
+		}
+		s.maxPathLength = args.MaxPathLength
+	}
+	if s.maxPathLength <= 0 {
+		s.maxPathLength = posixMaxPathSize
+		if s.utf16 {
+			s.maxPathLength = windowsMaxPathSize
+		}
+	}
+	return s
+}
+
+// name 将文档名转为合法的文件名，空文件名保持为空，由 resolveNames 调整。
+//   - 规范化Unicode形式，避免同一个名称在不同系统上对应不同的文件
+//   - 控制字符和/替换为下划线，portable策略下Windows不允许的字符 \:*?"<>| 也替换为下划线
+//   - portable策略下去掉末尾的点和空格，Windows的保留设备名（如CON、NUL）追加下划线
+//   - 只由点组成的名称（如..）替换为下划线，避免指向上级目录
+func (s *pathSanitizer) name(name string) string {
+	switch s.form {
+	case UnicodeFormNFC:
+		name = norm.NFC.String(name)
+	case UnicodeFormNFD:
+		name = norm.NFD.String(name)
+	}
+	portable := s.policy != NamePolicyPOSIX
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '/' || (portable && strings.ContainsRune(`\:*?"<>|`, r)) {
+			return '_'
+		}
+		return r
+	}, name)
+	if portable {
+		if trimmed := strings.TrimRight(name, ". "); trimmed != "" {
+			name = trimmed
+		}
+		stem, rest, hasExt := strings.Cut(name, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			name = stem + "_"
+			if hasExt {
+				name += "." + rest
+			}
+		}
+	}
+	if strings.Trim(name, ". ") == "" {
+		name = strings.Repeat("_", len(name))
+	}
+	return name
+}
+
+// limitPaths 截断超长的保存路径，返回截断的路径数量。
+// 各级目录名或文件名不超过255字节，整个路径不超过 maxPathLength，截断时保留扩展名，并追加原名称的哈希值以免重名。
+// 同一个目录总是截断为相同的名称，保证同一目录下的文档仍然保存在一起。
+func (s *pathSanitizer) limitPaths(infoList []*DocumentInfo, saveDir string) int {
+	// 按绝对路径计算长度
+	absDir, err := filepath.Abs(saveDir)
+	if err != nil {
+		absDir = saveDir
+	}
+	offset := s.length(absDir) - s.length(saveDir)
+	limited := map[string]string{} // 原路径 -> 截断后的路径
+	var count int
+	for _, di := range infoList {
+		if di.FilePath == "" {
+			continue
+		}
+		rel, err := filepath.Rel(saveDir, di.FilePath)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		origin, path := saveDir, saveDir
+		for i, part := range parts {
+			origin = filepath.Join(origin, part)
+			if cached, ok := limited[origin]; ok {
+				path = cached
+				continue
+			}
+			// 只有文档本身的文件名需要保留扩展名
+			keepExt := i == len(parts)-1 && !di.isDir()
+			path = filepath.Join(path, s.limitName(part, s.length(path)+offset, keepExt))
+			limited[origin] = path
+		}
+		if path != di.FilePath {
+			di.FilePath = path
+			count++
+		}
+	}
+	return count
+}
+
+// limitName 截断超长的目录名或文件名，parentLength 为上级目录的长度。
+func (s *pathSanitizer) limitName(name string, parentLength int, keepExt bool) string {
+	fits := func(n string) bool {
+		return len(n) <= maxNameBytes && parentLength+1+s.length(n) <= s.maxPathLength
+	}
+	if fits(name) {
+		return name
+	}
+	var ext string
+	if keepExt {
+		ext = filepath.Ext(name)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	suffix := fmt.Sprintf("~%08x%s", h.Sum32(), ext)
+	runes := []rune(strings.TrimSuffix(name, ext))
+	for n := len(runes) - 1; n > 0; n-- {
+		candidate := strings.TrimRight(string(runes[:n]), " ") + suffix
+		if fits(candidate) {
+			return candidate
+		}
+	}
+	// 上级目录已经太长时尽量缩短，保存时可能仍然失败
+	return suffix
+}
+
+// length 路径长度，Windows按UTF-16编码单元计算，其他系统按字节计算。
+func (s *pathSanitizer) length(path string) int {
+	if s.utf16 {
+		return len(utf16.Encode([]rune(path)))
+	}
+	return len(path)
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feishu
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acyumi/xdoc/component/constant"
+)
+
+func TestNewPathSanitizer(t *testing.T) {
+	s := newPathSanitizer(nil)
+	require.Equal(t, NamePolicyPortable, s.policy)
+	require.Equal(t, UnicodeFormNFC, s.form)
+	require.Equal(t, runtime.GOOS == "windows", s.utf16)
+	if s.utf16 {
+		require.Equal(t, windowsMaxPathSize, s.maxPathLength)
+	} else {
+		require.Equal(t, posixMaxPathSize, s.maxPathLength)
+	}
+
+	s = newPathSanitizer(&Args{NamePolicy: NamePolicyPOSIX, UnicodeForm: UnicodeFormNone, MaxPathLength: 200})
+	require.Equal(t, NamePolicyPOSIX, s.policy)
+	require.Equal(t, UnicodeFormNone, s.form)
+	require.Equal(t, 200, s.maxPathLength)
+}
+
+func TestPathSanitizer_name(t *testing.T) {
+	const (
+		nfc = "café"  // é为一个字符
+		nfd = "café" // e加组合重音符
+	)
+	tests := []struct {
+		name     string
+		policy   string
+		form     string
+		input    string
+		expected string
+	}{
+		{name: "普通名称", input: "周报 2025.01", expected: "周报 2025.01"},
+		{name: "空名称", input: "", expected: ""},
+		{name: "Windows不允许的字符", input: `a\b/c:d*e?f"g<h>i|j`, expected: "a_b_c_d_e_f_g_h_i_j"},
+		{name: "控制字符", input: "a\tb\nc\x00d\x7f", expected: "a_b_c_d_"},
+		{name: "末尾的点和空格", input: "周报. . ", expected: "周报"},
+		{name: "只有点", input: "..", expected: "__"},
+		{name: "只有空格", input: "  ", expected: "__"},
+		{name: "保留设备名", input: "CON", expected: "CON_"},
+		{name: "保留设备名不区分大小写", input: "com1", expected: "com1_"},
+		{name: "带扩展名的保留设备名", input: "nul.tar", expected: "nul_.tar"},
+		{name: "包含保留设备名", input: "CONSOLE", expected: "CONSOLE"},
+		{name: "规范化为NFC", input: nfd, expected: nfc},
+		{name: "规范化为NFD", form: UnicodeFormNFD, input: nfc, expected: nfd},
+		{name: "不规范化", form: UnicodeFormNone, input: nfd, expected: nfd},
+		{name: "posix保留Windows不允许的字符", policy: NamePolicyPOSIX, input: `a\b/c:d*e?f"g<h>i|j`, expected: `a\b_c:d*e?f"g<h>i|j`},
+		{name: "posix保留末尾的点和保留设备名", policy: NamePolicyPOSIX, input: "CON.", expected: "CON."},
+		{name: "posix替换上级目录", policy: NamePolicyPOSIX, input: "..", expected: "__"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newPathSanitizer(&Args{NamePolicy: tt.policy, UnicodeForm: tt.form})
+			require.Equal(t, tt.expected, s.name(tt.input))
+		})
+	}
+}
+
+func TestPathSanitizer_limitName(t *testing.T) {
+	s := &pathSanitizer{maxPathLength: posixMaxPathSize}
+	require.Equal(t, "短名称.docx", s.limitName("短名称.docx", 10, true))
+
+	// 超过255字节时截断，保留扩展名
+	long := strings.Repeat("文", 100) + ".docx"
+	limited := s.limitName(long, 10, true)
+	require.LessOrEqual(t, len(limited), maxNameBytes)
+	require.True(t, utf8.ValidString(limited))
+	require.True(t, strings.HasPrefix(limited, strings.Repeat("文", 50)))
+	require.Regexp(t, `^文+~[0-9a-f]{8}\.docx$`, limited)
+	require.Equal(t, limited, s.limitName(long, 10, true), "相同的名称总是截断为相同的结果")
+	other := s.limitName(strings.Repeat("文", 99)+"字.docx", 10, true)
+	require.NotEqual(t, limited, other, "前缀相同的名称截断后不重名")
+
+	// 目录名不保留扩展名
+	require.Regexp(t, `^文+~[0-9a-f]{8}$`, s.limitName(long, 10, false))
+
+	// 整个路径超过最大长度时截断
+	s = &pathSanitizer{maxPathLength: 50}
+	limited = s.limitName(strings.Repeat("a", 60)+".md", 20, true)
+	require.Equal(t, strings.Repeat("a", 17)+limited[17:], limited)
+	require.Len(t, limited, 29)
+
+	// 上级目录已经太长时只保留哈希值和扩展名
+	require.Regexp(t, `^~[0-9a-f]{8}\.md$`, s.limitName(strings.Repeat("a", 60)+".md", 49, true))
+
+	// Windows按UTF-16编码单元计算长度
+	s = &pathSanitizer{maxPathLength: 30, utf16: true}
+	require.Equal(t, strings.Repeat("文", 20), s.limitName(strings.Repeat("文", 20), 9, false))
+}
+
+func TestPathSanitizer_limitPaths(t *testing.T) {
+	saveDir := filepath.FromSlash("/tmp/docs")
+	longName := strings.Repeat("文", 100) // 300字节
+	dns := []*DocumentNode{
+		{
+			DocumentInfo: DocumentInfo{Name: longName, Type: constant.DocTypeDocx, FileExtension: constant.FileExtDocx, CanDownload: true},
+			Children: []*DocumentNode{
+				{DocumentInfo: DocumentInfo{Name: "a", Type: constant.DocTypeDocx, FileExtension: constant.FileExtDocx, CanDownload: true}},
+				{DocumentInfo: DocumentInfo{Name: "b", Type: constant.DocTypeDocx, FileExtension: constant.FileExtDocx, CanDownload: true}},
+			},
+		},
+		{DocumentInfo: DocumentInfo{Name: "folder", Type: constant.DocTypeFolder}},
+		{DocumentInfo: DocumentInfo{Name: strings.Repeat("c", 60), Type: constant.DocTypeDocx, FileExtension: constant.FileExtMarkdown, CanDownload: true}},
+	}
+	infoList := documentNodesToInfoList(dns, saveDir)
+	infoList = append(infoList, &DocumentInfo{Name: "模板", FilePath: ""})
+
+	s := &pathSanitizer{maxPathLength: posixMaxPathSize}
+	require.Equal(t, 3, s.limitPaths(infoList, saveDir))
+	require.Regexp(t, `^文+~[0-9a-f]{8}\.docx$`, filepath.Base(dns[0].FilePath), "超长的文件名截断后保留扩展名")
+	parent := filepath.Dir(dns[0].Children[0].FilePath)
+	require.Equal(t, saveDir, filepath.Dir(parent))
+	require.Regexp(t, `^文+~[0-9a-f]{8}$`, filepath.Base(parent), "超长的目录名被截断")
+	require.LessOrEqual(t, len(filepath.Base(parent)), maxNameBytes)
+	require.Equal(t, filepath.Join(parent, "a.docx"), dns[0].Children[0].FilePath)
+	require.Equal(t, filepath.Join(parent, "b.docx"), dns[0].Children[1].FilePath, "同一目录下的文档仍然保存在一起")
+	require.Equal(t, filepath.Join(saveDir, "folder"), dns[1].FilePath)
+	require.Equal(t, filepath.Join(saveDir, strings.Repeat("c", 60)+".md"), dns[2].FilePath)
+	require.Empty(t, infoList[len(infoList)-1].FilePath)
+
+	// 整个路径超过最大长度时截断，按绝对路径计算长度
+	s = &pathSanitizer{maxPathLength: 60}
+	require.Equal(t, 1, s.limitPaths([]*DocumentInfo{&dns[2].DocumentInfo}, saveDir))
+	require.Regexp(t, `^c+~[0-9a-f]{8}\.md$`, filepath.Base(dns[2].FilePath))
+	require.Len(t, dns[2].FilePath, 60)
+	require.Equal(t, 0, s.limitPaths([]*DocumentInfo{&dns[2].DocumentInfo}, saveDir), "截断后的路径不再变化")
+}
@@ -15,9 +15,11 @@
 package feishu
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"math/rand"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,6 +30,7 @@ import (
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	"github.com/samber/lo"
 	"github.com/samber/oops"
+	"github.com/spf13/cast"
 
 	"github.com/acyumi/xdoc/component/app"
 	"github.com/acyumi/xdoc/component/progress"
@@ -42,9 +45,13 @@ type TaskImpl struct {
 	program         progress.IProgram  //
 	countDown       *atomic.Int32      //
 	completed       *atomic.Bool       // 任务整体是否完成（导出+下载）
+	cancel          context.CancelFunc // 取消任务的上下文，中断进行中的请求和下载
 	queue           chan *exportResult //
 	wait            chan struct{}      //
 	exporter        IExporter          //
+	journal         *journal           // 导出日志，记录各文档的状态变化
+	tickets         map[string]string  // 恢复导出时上一次未完成的导出任务ID，key为文件保存路径
+	recorder        *reportRecorder    // 记录各文档的耗时和最终状态，用于生成导出报告
 }
 
 func (t TaskImpl) Validate() (err error) {
@@ -56,29 +63,49 @@ func (t TaskImpl) Validate() (err error) {
 		))
 }
 
-func (t *TaskImpl) Run() (err error) {
+func (t *TaskImpl) Run(ctx context.Context) (err error) {
 	startTime := time.Now()
-	fmt.Println("阶段2: 下载飞书云文档")
-	fmt.Println("--------------------------")
+	args := t.Client.GetArgs()
+	logOutput := args.LogOutput(os.Stdout)
+	app.Fprintln(logOutput, "阶段2: 下载飞书云文档")
+	app.Fprintln(logOutput, "--------------------------")
 	defer func() {
-		fmt.Println("--------------------------")
-		fmt.Printf("阶段2, 耗时: %s\n", time.Since(startTime).String())
+		app.Fprintln(logOutput, "--------------------------")
+		app.Fprintf(logOutput, "阶段2, 耗时: %s\n", time.Since(startTime).String())
 	}()
 
-	args := t.Client.GetArgs()
-	// 将树结构转为平铺的列表
-	infoList := documentNodesToInfoList(t.Docs, args.SaveDir)
+	// 将树结构转为平铺的列表，文件保存路径在创建任务前已经计算好（可能按路径模板调整过），这里不能重新计算
+	infoList := flattenDocumentNodes(t.Docs)
 
 	// 初始化必要参数备用
-	t.canDownloadList = lo.Filter(infoList, func(di *DocumentInfo, _ int) bool { return di.CanDownload })
-	canDownloadCount := len(t.canDownloadList)
-	t.program = t.ProgramConstructor(calculateOverallProgress(canDownloadCount))
+	canDownloadList := lo.Filter(infoList, func(di *DocumentInfo, _ int) bool { return di.shouldDownload() })
+	// 从上一次中断的位置恢复导出
+	var finished map[string]bool
+	if args.Resume {
+		if finished, err = t.resume(canDownloadList, args.SaveDir); err != nil {
+			return oops.Wrap(err)
+		}
+	}
+	// 重试失败的文档时追加到上一次的导出日志，保留未重试的文档的记录
+	if t.journal, err = openJournal(args.SaveDir, args.Resume || args.RetryFailed != ""); err != nil {
+		return oops.Wrap(err)
+	}
+	defer t.journal.close()
+	skippedList, downloadList := lo.FilterReject(canDownloadList, func(di *DocumentInfo, _ int) bool {
+		return di.Status == progress.StatusSkipped
+	})
+	t.canDownloadList = downloadList
+	t.recorder = newReportRecorder()
+	t.program = t.ProgramConstructor(calculateOverallProgress(len(canDownloadList)))
 	t.countDown = &atomic.Int32{}
-	t.countDown.Store(int32(canDownloadCount))
+	t.countDown.Store(int32(len(t.canDownloadList)))
 	t.completed = &atomic.Bool{}
-	t.queue = make(chan *exportResult, 20)
+	parent := ctx
+	ctx, t.cancel = context.WithCancel(ctx)
+	defer t.cancel()
+	t.queue = make(chan *exportResult, args.QueueSize)
 	t.wait = make(chan struct{})
-	t.exporter = &exporter{client: t.Client, program: t.program, completed: t.completed}
+	t.exporter = &exporter{client: t.Client, program: t.program, media: &mediaStore{}}
 
 	// 开启下载UI程序
 	go func() {
@@ -88,22 +115,88 @@ func (t *TaskImpl) Run() (err error) {
 		}()
 		// 启动 BubbleTea
 		if _, err = t.program.Run(); err != nil {
-			fmt.Println("下载UI程序运行出错:", err)
+			app.Fprintln(logOutput, "下载UI程序运行出错:", err)
 			return
 		}
-		fmt.Println("退出下载UI程序")
+		app.Fprintln(logOutput, "退出下载UI程序")
+	}()
+
+	// 增量导出时未变更的文档、恢复导出时上一次已完成的文档直接显示为已跳过
+	for _, di := range skippedList {
+		t.add(di)
+		if finished[di.FilePath] {
+			t.update(di, 1.0, progress.StatusSkipped, "上次已完成")
+			continue
+		}
+		t.update(di, 1.0, progress.StatusSkipped, "未变更")
+	}
+
+	// 收到中断信号时关掉下载UI程序
+	go func() {
+		<-ctx.Done()
+		if !t.completed.Load() {
+			t.Interrupt()
+		}
 	}()
 
-	// 开启5个协程同时创建导出任务
-	_ = t.exportDocuments()
+	// 开启多个协程同时创建导出任务
+	_ = t.exportDocuments(ctx, args.ExportWorkers)
 
-	// 开启3个协诚同时下载文件
-	_ = t.downloadDocuments()
+	// 开启多个协程同时下载文件
+	_ = t.downloadDocuments(ctx, args.DownloadWorkers)
 
 	// 等待中断触发或批量下载完成
 	<-t.wait
 
-	return err
+	// 保存各文档的最终状态，下次增量导出时用于判断是否可以跳过
+	if er := saveDocumentTree(t.Docs, args.SaveDir); er != nil && err == nil {
+		err = er
+	}
+	// 生成导出报告，列出各文档的最终状态
+	rp := t.recorder.build(infoList, args.SaveDir, startTime)
+	// 任务的结果只看本次提交的文档
+	outcome := taskOutcome(rp.Summary, parent.Err() != nil)
+	if args.RetryFailed != "" {
+		// 重试失败的文档时合并到上一次的导出报告，保留未重试的文档
+		var er error
+		if rp, er = mergeReport(rp, args.SaveDir); er != nil && err == nil {
+			err = er
+		}
+	}
+	filePaths, er := saveReport(rp, args.SaveDir, args.ReportFormats)
+	if er != nil && err == nil {
+		err = er
+	}
+	for _, filePath := range filePaths {
+		app.Fprintln(logOutput, "导出报告:", filePath)
+	}
+	if err != nil {
+		return err
+	}
+	return outcome
+}
+
+// taskOutcome 根据导出报告的统计信息判断任务的结果，用于决定程序的退出码。
+// 被中断或退出下载UI时仍有文档未完成，返回Interrupted错误；全部文档都已结束但有文档失败，返回PartialFailure错误。
+func taskOutcome(sm reportSummary, canceled bool) error {
+	unfinished := sm.CanDownload - sm.Completed - sm.Failed - sm.Skipped
+	if canceled || unfinished > 0 {
+		return oops.Code("Interrupted").
+			With("completed", sm.Completed, "failed", sm.Failed, "unfinished", unfinished).
+			Errorf("导出被中断, 已下载: %d, 已失败: %d, 未完成: %d", sm.Completed, sm.Failed, unfinished)
+	}
+	if sm.Failed > 0 {
+		return oops.Code("PartialFailure").
+			With("completed", sm.Completed, "failed", sm.Failed).
+			Errorf("部分文档导出失败, 已下载: %d, 已失败: %d, 已跳过: %d", sm.Completed, sm.Failed, sm.Skipped)
+	}
+	return nil
+}
+
+// isPartialFailure 是否是部分文档导出失败的错误，此时其他文档已经正常下载，仍需要继续后续处理。
+func isPartialFailure(err error) bool {
+	var oopsError oops.OopsError
+	return errors.As(err, &oopsError) && oopsError.Code() == "PartialFailure"
 }
 
 func (t *TaskImpl) Close() {
@@ -122,12 +215,76 @@ func (t *TaskImpl) Interrupt() {
 
 func (t *TaskImpl) Complete() {
 	t.completed.Store(true)
+	if t.cancel != nil {
+		t.cancel()
+	}
 	t.wait <- struct{}{}
 }
 
-// exportDocuments 批量创建和检查导出任务。
-func (t *TaskImpl) exportDocuments() (completed *atomic.Bool) {
+// add 将文档添加到下载UI程序，并记录提交的时间。
+func (t *TaskImpl) add(di *DocumentInfo) {
+	t.recorder.add(di.FilePath)
+	t.program.Add(di.FilePath, di.GetFileName())
+}
+
+// update 记录文档的状态并写入导出日志，再更新到下载UI程序。
+func (t *TaskImpl) update(di *DocumentInfo, pg float64, status progress.Status, msgFormat ...any) {
+	statusLock.Lock()
+	di.Status = status
+	statusLock.Unlock()
+	var msg string
+	if len(msgFormat) > 0 {
+		msg = fmt.Sprintf(cast.ToString(msgFormat[0]), msgFormat[1:]...)
+	}
+	t.journal.write(journalEntry{FilePath: di.FilePath, Token: di.Token, Status: status, Msg: msg})
+	t.recorder.record(di.FilePath, status, msg)
+	t.program.Update(di.FilePath, pg, status, msgFormat...)
+}
+
+// startWorkers 开启workers个协程执行work，所有协程都执行完后将返回的completed设置为true。
+func startWorkers(workers int, work func()) (completed *atomic.Bool) {
 	completed = &atomic.Bool{}
+	if workers <= 0 {
+		completed.Store(true)
+		return completed
+	}
+	doneCount := &atomic.Int32{}
+	for range workers {
+		go func() {
+			defer func() {
+				// 如果所有协程都执行完了，则发送完成信号
+				if doneCount.Add(1) == int32(workers) {
+					completed.Store(true)
+				}
+			}()
+			work()
+		}()
+	}
+	return completed
+}
+
+// fail 标记文档导出失败，任务被中断导致的失败标记为已中断，下次恢复导出时重新导出。
+func (t *TaskImpl) fail(ctx context.Context, di *DocumentInfo, pg float64, err error) {
+	t.countDown.Add(-1)
+	if ctx.Err() != nil {
+		t.update(di, pg, progress.StatusInterrupted)
+		return
+	}
+	t.update(di, pg, progress.StatusFailed, cleanEnter(err))
+}
+
+// enqueue 将导出结果放入等待下载的队列，任务被中断时返回false。
+func (t *TaskImpl) enqueue(ctx context.Context, er *exportResult) bool {
+	select {
+	case t.queue <- er:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// exportDocuments 开启workers个协程批量创建和检查导出任务。
+func (t *TaskImpl) exportDocuments(ctx context.Context, workers int) (completed *atomic.Bool) {
 	lock := &sync.Mutex{}
 	docIdx := -1
 	canDownloadCount := len(t.canDownloadList)
@@ -140,137 +297,114 @@ func (t *TaskImpl) exportDocuments() (completed *atomic.Bool) {
 		}
 		return t.canDownloadList[docIdx]
 	}
-	total := 5
-	doneCount := &atomic.Int32{}
-	for i := 0; i < total; i++ {
-		go func() {
-			defer func() {
-				// 如果所有协程都执行完了，则发送完成信号
-				if doneCount.Add(1) == int32(total) {
-					completed.Store(true)
-				}
-			}()
-			for {
-				if t.completed.Load() {
-					return
-				}
-				di := getNextDoc()
-				if di == nil {
-					return
-				}
-				// 发送新文件到program
-				fileName := di.GetFileName()
-				t.program.Add(di.FilePath, fileName)
-
-				if di.DownloadDirectly {
-					t.queue <- &exportResult{DocumentInfo: di, result: nil}
-					continue // 注意这里是continue而不是return
-				}
+	return startWorkers(workers, func() {
+		for {
+			if t.completed.Load() || ctx.Err() != nil {
+				return
+			}
+			di := getNextDoc()
+			if di == nil {
+				return
+			}
+			// 发送新文件到program
+			t.add(di)
 
-				// 创建导出任务
-				ticket, err := t.exporter.doExport(di)
-				if err != nil {
-					t.program.Update(di.FilePath, 0.05, progress.StatusFailed, cleanEnter(err))
-					t.countDown.Add(-1)
-					continue // 注意这里是continue而不是return
+			if di.DownloadDirectly || di.ConvertLocally {
+				if !t.enqueue(ctx, &exportResult{DocumentInfo: di, result: nil}) {
+					return
 				}
-				t.program.Update(di.FilePath, 0.05, progress.StatusExporting)
+				continue // 注意这里是continue而不是return
+			}
 
-				// 查询导出任务结果
-				exportResult, status, err := t.exporter.checkExport(di, ticket)
+			// 创建导出任务，恢复导出时沿用上一次未完成的导出任务
+			ticket, ok := t.tickets[di.FilePath]
+			if !ok {
+				var err error
+				ticket, err = t.exporter.doExport(ctx, di)
 				if err != nil {
-					t.program.Update(di.FilePath, 0.10, progress.StatusFailed, cleanEnter(err))
-					t.countDown.Add(-1)
+					t.fail(ctx, di, 0.05, err)
 					continue // 注意这里是continue而不是return
 				}
-				if status == progress.StatusInterrupted {
-					return
-				}
-				t.program.Update(di.FilePath, 0.15, status)
-
-				// 随机睡眠1到3秒
-				app.Sleep(time.Second * time.Duration(rand.Intn(2)+1))
+				t.journal.write(journalEntry{FilePath: di.FilePath, Token: di.Token, Status: progress.StatusExporting, Ticket: ticket})
+			}
+			t.update(di, 0.05, progress.StatusExporting)
 
-				t.program.Update(di.FilePath, 0.15, progress.StatusWaiting)
-				t.queue <- exportResult
+			// 查询导出任务结果
+			exportResult, status, err := t.exporter.checkExport(ctx, di, ticket)
+			if err != nil {
+				t.fail(ctx, di, 0.10, err)
+				continue // 注意这里是continue而不是return
 			}
-		}()
-	}
-	return completed
+			if status == progress.StatusInterrupted {
+				return
+			}
+			t.update(di, 0.15, status)
+			t.update(di, 0.15, progress.StatusWaiting)
+			if !t.enqueue(ctx, exportResult) {
+				return
+			}
+		}
+	})
 }
 
-// downloadDocuments 批量下载已导出的文件并显示下载进度。
-func (t *TaskImpl) downloadDocuments() (completed *atomic.Bool) {
-	completed = &atomic.Bool{}
-	total := 3
-	doneCount := atomic.Int32{}
-	for range total {
-		go func() {
-			defer func() {
-				// 如果所有协程都执行完了，则发送完成信号
-				if doneCount.Add(1) == int32(total) {
-					completed.Store(true)
-				}
-			}()
-			for {
-				if t.completed.Load() {
+// downloadDocuments 开启workers个协程批量下载已导出的文件并显示下载进度。
+func (t *TaskImpl) downloadDocuments(ctx context.Context, workers int) (completed *atomic.Bool) {
+	return startWorkers(workers, func() {
+		for {
+			if t.completed.Load() || ctx.Err() != nil {
+				return
+			}
+			select {
+			case value, ok := <-t.queue:
+				if !ok {
 					return
 				}
-				select {
-				case value, ok := <-t.queue:
-					if !ok {
-						return
-					}
 
-					// 开始下载文件，写入到saveDir目录中
-					var fileSize int64
-					var file io.Reader
-					var err error
-					if value.DownloadDirectly {
-						file, fileSize, err = t.exporter.doDownloadDirectly(value.FilePath, value.Token)
-					} else {
-						fileSize = int64(larkcore.IntValue(value.result.FileSize))
-						fileToken := larkcore.StringValue(value.result.FileToken)
-						file, err = t.exporter.doDownloadExported(value.FilePath, fileToken)
-					}
-					if err != nil {
-						t.program.Update(value.FilePath, 0.18, progress.StatusFailed, cleanEnter(err))
-						t.countDown.Add(-1)
-						continue // 注意这里是continue而不是return
-					}
-					t.program.Update(value.FilePath, 0.20, progress.StatusDownloading)
-
-					pw := &progress.Writer{
-						FileKey:  value.Token,
-						FilePath: value.FilePath,
-						Program:  t.program,
-						Total:    fileSize,
-						Walked:   0.2,
-					}
-					if err = pw.WriteFile(file); err != nil {
-						t.program.Update(value.FilePath, pw.Progress(), progress.StatusFailed, cleanEnter(err))
-						t.countDown.Add(-1)
-						continue // 注意这里是continue而不是return
-					}
-					t.program.Update(value.FilePath, pw.Progress(), progress.StatusCompleted)
-
-					// 随机睡眠1到3秒
-					app.Sleep(time.Second * time.Duration(rand.Intn(2)+1))
-					t.countDown.Add(-1)
+				// 开始下载文件，写入到saveDir目录中
+				var fileSize int64
+				var file io.Reader
+				var err error
+				switch {
+				case value.DownloadDirectly:
+					file, fileSize, err = t.exporter.doDownloadDirectly(ctx, value.FilePath, value.Token)
+				case value.ConvertLocally:
+					file, fileSize, err = t.exporter.doConvert(ctx, value.DocumentInfo)
 				default:
-					if t.countDown.Load() <= 0 {
-						if t.Client.GetArgs().QuitAutomatically {
-							// 随机睡眠1到3秒
-							app.Sleep(time.Second * time.Duration(rand.Intn(2)+1))
-							t.Interrupt()
-						}
-						return
+					fileSize = int64(larkcore.IntValue(value.result.FileSize))
+					fileToken := larkcore.StringValue(value.result.FileToken)
+					file, err = t.exporter.doDownloadExported(ctx, value.FilePath, fileToken)
+				}
+				if err != nil {
+					t.fail(ctx, value.DocumentInfo, 0.18, err)
+					continue // 注意这里是continue而不是return
+				}
+				t.update(value.DocumentInfo, 0.20, progress.StatusDownloading)
+
+				pw := &progress.Writer{
+					FileKey:  value.Token,
+					FilePath: value.FilePath,
+					Program:  t.program,
+					Total:    fileSize,
+					Walked:   0.2,
+				}
+				if err = pw.WriteFile(ctx, file); err != nil {
+					t.fail(ctx, value.DocumentInfo, pw.Progress(), err)
+					continue // 注意这里是continue而不是return
+				}
+				t.update(value.DocumentInfo, pw.Progress(), progress.StatusCompleted)
+				t.countDown.Add(-1)
+			default:
+				if t.countDown.Load() <= 0 {
+					if t.Client.GetArgs().QuitAutomatically {
+						// 等待下载UI程序显示最终状态后再退出
+						app.Sleep(time.Second)
+						t.Interrupt()
 					}
+					return
 				}
 			}
-		}()
-	}
-	return completed
+		}
+	})
 }
 
 // calculateOverallProgress 计算整体进度。
@@ -280,10 +414,13 @@ func calculateOverallProgress(canDownloadCount int) progress.Stats {
 		teaProgress.WithDefaultGradient(), // 使用默认渐变颜色
 		teaProgress.WithWidth(60),         // 设置进度条宽度
 	) // 整体进度
-	return func(total, downloaded, failed int) string {
-		remaining := total - downloaded - failed
+	return func(total, downloaded, failed, skipped int) string {
+		remaining := total - downloaded - failed - skipped
 		statsInfo := fmt.Sprintf("可下载: %d, 已提交: %d, 已下载: %d, 未下载: %d, 已失败: %d", canDownloadCount, total, downloaded, remaining, failed)
-		tp := totalProgress.ViewAs(float64(downloaded+failed) / float64(canDownloadCount))
+		if skipped > 0 {
+			statsInfo += fmt.Sprintf(", 已跳过: %d", skipped)
+		}
+		tp := totalProgress.ViewAs(float64(downloaded+failed+skipped) / float64(canDownloadCount))
 		return progress.TipsStyle.Render(statsInfo) + "\n" + tp
 	}
 }
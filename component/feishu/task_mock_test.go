@@ -2,7 +2,11 @@
 
 package feishu
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockTask is an autogenerated mock type for the Task type
 type MockTask struct {
@@ -113,17 +117,17 @@ func (_c *MockTask_Interrupt_Call) RunAndReturn(run func()) *MockTask_Interrupt_
 	return _c
 }
 
-// Run provides a mock function with no fields
-func (_m *MockTask) Run() error {
-	ret := _m.Called()
+// Run provides a mock function with given fields: ctx
+func (_m *MockTask) Run(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Run")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -137,13 +141,14 @@ type MockTask_Run_Call struct {
 }
 
 // Run is a helper method to define mock.On call
-func (_e *MockTask_Expecter) Run() *MockTask_Run_Call {
-	return &MockTask_Run_Call{Call: _e.mock.On("Run")}
+//   - ctx context.Context
+func (_e *MockTask_Expecter) Run(ctx any) *MockTask_Run_Call {
+	return &MockTask_Run_Call{Call: _e.mock.On("Run", ctx)}
 }
 
-func (_c *MockTask_Run_Call) Run(run func()) *MockTask_Run_Call {
+func (_c *MockTask_Run_Call) Run(run func(ctx context.Context)) *MockTask_Run_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(context.Context))
 	})
 	return _c
 }
@@ -153,7 +158,7 @@ func (_c *MockTask_Run_Call) Return(_a0 error) *MockTask_Run_Call {
 	return _c
 }
 
-func (_c *MockTask_Run_Call) RunAndReturn(run func() error) *MockTask_Run_Call {
+func (_c *MockTask_Run_Call) RunAndReturn(run func(context.Context) error) *MockTask_Run_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -15,6 +15,7 @@
 package feishu
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -30,6 +31,7 @@ import (
 	"github.com/samber/oops"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/acyumi/xdoc/component/app"
@@ -168,8 +170,11 @@ func (s *TaskImplTestSuite) TestTaskImpl_Run() {
 					},
 				}
 				args.mockClient.EXPECT().GetArgs().Return(&Args{
-					SaveDir:  "/tmp",
-					ListOnly: false,
+					SaveDir:         "/tmp",
+					ListOnly:        false,
+					ExportWorkers:   DefaultExportWorkers,
+					DownloadWorkers: DefaultDownloadWorkers,
+					QueueSize:       DefaultQueueSize,
 					Args: &argument.Args{
 						QuitAutomatically: true,
 					},
@@ -179,6 +184,78 @@ func (s *TaskImplTestSuite) TestTaskImpl_Run() {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "增量导出跳过未变更文档",
+			setupMock: func(args *mockRunArgs) {
+				args.task.Docs = []*DocumentNode{
+					{
+						DocumentInfo: DocumentInfo{
+							Name:          "doc1",
+							Token:         "doc1_token",
+							Type:          constant.DocTypeDocx,
+							FileExtension: constant.FileExtDocx,
+							CanDownload:   true,
+							FilePath:      "/tmp/doc1.docx",
+							Status:        progress.StatusSkipped,
+						},
+					},
+				}
+				args.mockClient.EXPECT().GetArgs().Return(&Args{
+					SaveDir:         "/tmp",
+					ListOnly:        false,
+					ExportWorkers:   DefaultExportWorkers,
+					DownloadWorkers: DefaultDownloadWorkers,
+					QueueSize:       DefaultQueueSize,
+					Args: &argument.Args{
+						QuitAutomatically: true,
+					},
+				}).Maybe()
+				args.mockProgram.EXPECT().Run().Return(nil, nil).Once()
+				args.mockProgram.EXPECT().Add("/tmp/doc1.docx", "doc1.docx").Once()
+				args.mockProgram.EXPECT().Update("/tmp/doc1.docx", 1.0, progress.StatusSkipped, "未变更").Once()
+				args.mockProgram.EXPECT().Quit().Maybe()
+			},
+			expectedError: nil,
+		},
+		{
+			name: "恢复导出跳过上一次已完成的文档",
+			setupMock: func(args *mockRunArgs) {
+				args.task.Docs = []*DocumentNode{
+					{
+						DocumentInfo: DocumentInfo{
+							Name:          "doc1",
+							Token:         "doc1_token",
+							Type:          constant.DocTypeDocx,
+							FileExtension: constant.FileExtDocx,
+							CanDownload:   true,
+							FilePath:      "/tmp/doc1.docx",
+						},
+					},
+				}
+				j, err := openJournal("/tmp", false)
+				s.Require().NoError(err)
+				j.write(journalEntry{FilePath: "/tmp/doc1.docx", Token: "doc1_token", Status: progress.StatusCompleted})
+				j.close()
+				err = app.Fs.WriteFile("/tmp/doc1.docx", []byte("doc1"), 0o644)
+				s.Require().NoError(err)
+				args.mockClient.EXPECT().GetArgs().Return(&Args{
+					SaveDir:         "/tmp",
+					ListOnly:        false,
+					ExportWorkers:   DefaultExportWorkers,
+					DownloadWorkers: DefaultDownloadWorkers,
+					QueueSize:       DefaultQueueSize,
+					Resume:          true,
+					Args: &argument.Args{
+						QuitAutomatically: true,
+					},
+				}).Maybe()
+				args.mockProgram.EXPECT().Run().Return(nil, nil).Once()
+				args.mockProgram.EXPECT().Add("/tmp/doc1.docx", "doc1.docx").Once()
+				args.mockProgram.EXPECT().Update("/tmp/doc1.docx", 1.0, progress.StatusSkipped, "上次已完成").Once()
+				args.mockProgram.EXPECT().Quit().Maybe()
+			},
+			expectedError: nil,
+		},
 		{
 			name: "下载UI程序报错",
 			setupMock: func(args *mockRunArgs) {
@@ -220,8 +297,11 @@ func (s *TaskImplTestSuite) TestTaskImpl_Run() {
 					},
 				}
 				args.mockClient.EXPECT().GetArgs().Return(&Args{
-					SaveDir:  "/tmp",
-					ListOnly: false,
+					SaveDir:         "/tmp",
+					ListOnly:        false,
+					ExportWorkers:   DefaultExportWorkers,
+					DownloadWorkers: DefaultDownloadWorkers,
+					QueueSize:       DefaultQueueSize,
 					Args: &argument.Args{
 						QuitAutomatically: true,
 					},
@@ -239,14 +319,16 @@ func (s *TaskImplTestSuite) TestTaskImpl_Run() {
 			// 设置mock
 			tt.setupMock(args)
 			// 执行测试
-			err := args.task.Run()
+			err := args.task.Run(context.Background())
 			defer func() {
-				treeFile := filepath.Join("/tmp", "document-tree.json")
-				yes, err := app.Fs.Exists(treeFile)
-				s.Require().NoError(err, tt.name)
-				if yes {
-					err = app.Fs.Remove(treeFile)
+				for _, file := range []string{"document-tree.json", "export-journal.jsonl", "export-report.json", "doc1.docx"} {
+					filePath := filepath.Join("/tmp", file)
+					yes, err := app.Fs.Exists(filePath)
 					s.Require().NoError(err, tt.name)
+					if yes {
+						err = app.Fs.Remove(filePath)
+						s.Require().NoError(err, tt.name)
+					}
 				}
 			}()
 			// 验证结果
@@ -261,6 +343,9 @@ func (s *TaskImplTestSuite) TestTaskImpl_Run() {
 			} else {
 				s.Require().NoError(err, tt.name)
 			}
+			yes, err := app.Fs.Exists("/tmp/export-report.json")
+			s.Require().NoError(err, tt.name)
+			s.True(yes, "总是生成导出报告")
 		})
 	}
 }
@@ -280,10 +365,43 @@ func (s *TaskImplTestSuite) TestTaskImpl_Interrupt() {
 func (s *TaskImplTestSuite) TestTaskImpl_Complete() {
 	s.task.queue = make(chan *exportResult, 1)
 	s.task.wait = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.task.cancel = cancel
 	go func() {
 		<-s.task.wait
 	}()
 	s.task.Complete()
+	s.Require().ErrorIs(ctx.Err(), context.Canceled, "完成任务时取消上下文")
+}
+
+func (s *TaskImplTestSuite) TestTaskImpl_fail() {
+	s.task.countDown = &atomic.Int32{}
+	s.task.countDown.Store(2)
+	di := &DocumentInfo{FilePath: "/tmp/doc1.docx"}
+	s.mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusFailed, "下载失败").Once()
+	s.task.fail(context.Background(), di, 0.18, oops.New("下载失败"))
+	s.Equal(progress.StatusFailed, di.Status)
+
+	// 被中断导致的失败标记为已中断
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.mockProgram.EXPECT().Update(di.FilePath, 0.18, progress.StatusInterrupted).Once()
+	s.task.fail(ctx, di, 0.18, context.Canceled)
+	s.Equal(progress.StatusInterrupted, di.Status)
+	s.Equal(int32(0), s.task.countDown.Load())
+}
+
+func (s *TaskImplTestSuite) TestTaskImpl_enqueue() {
+	s.task.queue = make(chan *exportResult, 1)
+	er := &exportResult{DocumentInfo: &DocumentInfo{}}
+	s.True(s.task.enqueue(context.Background(), er))
+	s.Same(er, <-s.task.queue)
+
+	// 队列已满时被中断则不再等待
+	s.task.queue = make(chan *exportResult)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.False(s.task.enqueue(ctx, er))
 }
 
 func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
@@ -423,16 +541,16 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 
 				s.mockProgram.EXPECT().Add(di1.FilePath, "doc1.docx").Once()
-				s.mockExporter.EXPECT().doExport(&di1.DocumentInfo).Return("ticket1", nil).Once()
+				s.mockExporter.EXPECT().doExport(mock.Anything, &di1.DocumentInfo).Return("ticket1", nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.05, progress.StatusExporting).Once()
-				s.mockExporter.EXPECT().checkExport(&di1.DocumentInfo, "ticket1").Return(exportResults[0], progress.StatusExported, nil).Once()
+				s.mockExporter.EXPECT().checkExport(mock.Anything, &di1.DocumentInfo, "ticket1").Return(exportResults[0], progress.StatusExported, nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.15, progress.StatusExported).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.15, progress.StatusWaiting).Once()
 
 				s.mockProgram.EXPECT().Add(di2.FilePath, "doc2.pdf").Once()
-				s.mockExporter.EXPECT().doExport(&di2.DocumentInfo).Return("ticket2", nil).Once()
+				s.mockExporter.EXPECT().doExport(mock.Anything, &di2.DocumentInfo).Return("ticket2", nil).Once()
 				s.mockProgram.EXPECT().Update(di2.FilePath, 0.05, progress.StatusExporting).Once()
-				s.mockExporter.EXPECT().checkExport(&di2.DocumentInfo, "ticket2").Return(exportResults[1], progress.StatusExported, nil).Once()
+				s.mockExporter.EXPECT().checkExport(mock.Anything, &di2.DocumentInfo, "ticket2").Return(exportResults[1], progress.StatusExported, nil).Once()
 				s.mockProgram.EXPECT().Update(di2.FilePath, 0.15, progress.StatusExported).Once()
 				s.mockProgram.EXPECT().Update(di2.FilePath, 0.15, progress.StatusWaiting).Once()
 				return []any{exportResults[0], exportResults[1]}
@@ -443,6 +561,52 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 				s.Subset([]*exportResult{args[0].(*exportResult), args[1].(*exportResult)}, got, name)
 			},
 		},
+		{
+			name: "正常执行[恢复上一次的导出任务]",
+			setupMock: func(name string) (args []any) {
+				di1 := &DocumentNode{
+					DocumentInfo: DocumentInfo{
+						Name:             "doc1",
+						Token:            "doc1_token",
+						Type:             constant.DocTypeDocx,
+						DownloadDirectly: false,
+						FileExtension:    constant.FileExtDocx,
+						CanDownload:      true,
+						FilePath:         "doc1_path.docx",
+					},
+				}
+				s.task.Docs = []*DocumentNode{di1}
+				infoList := documentNodesToInfoList(s.task.Docs, "/tmp")
+				er := &exportResult{
+					DocumentInfo: infoList[0],
+					result: &larkdrive.ExportTask{
+						FileToken:     larkcore.StringPtr(di1.Token),
+						FileExtension: larkcore.StringPtr(string(di1.FileExtension)),
+					},
+				}
+				// 初始化必要参数备用
+				s.task.canDownloadList = infoList
+				s.task.countDown = &atomic.Int32{}
+				s.task.countDown.Store(int32(len(s.task.canDownloadList)))
+				s.task.completed.Store(false)
+				s.task.queue = make(chan *exportResult, 1)
+				s.task.tickets = map[string]string{di1.FilePath: "ticket1"}
+
+				// 不再调用 doExport 创建导出任务
+				s.mockProgram.EXPECT().Add(di1.FilePath, "doc1.docx").Once()
+				s.mockProgram.EXPECT().Update(di1.FilePath, 0.05, progress.StatusExporting).Once()
+				s.mockExporter.EXPECT().checkExport(mock.Anything, &di1.DocumentInfo, "ticket1").Return(er, progress.StatusExported, nil).Once()
+				s.mockProgram.EXPECT().Update(di1.FilePath, 0.15, progress.StatusExported).Once()
+				s.mockProgram.EXPECT().Update(di1.FilePath, 0.15, progress.StatusWaiting).Once()
+				return []any{er}
+			},
+			want: func(name string, completed *atomic.Bool, args []any) {
+				got := s.receiveFromQueue(1)
+				s.waitToContinue(completed)
+				s.task.tickets = nil
+				s.Equal([]*exportResult{args[0].(*exportResult)}, got, name)
+			},
+		},
 		{
 			name: "创建导出任务失败",
 			setupMock: func(name string) (args []any) {
@@ -489,7 +653,7 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 
 				s.mockProgram.EXPECT().Add(di1.FilePath, "doc1.docx").Once()
-				s.mockExporter.EXPECT().doExport(&di1.DocumentInfo).Return("", oops.New("创建导出任务失败")).Once()
+				s.mockExporter.EXPECT().doExport(mock.Anything, &di1.DocumentInfo).Return("", oops.New("创建导出任务失败")).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.05, progress.StatusFailed, "创建导出任务失败").Once()
 				return []any{exportResults[0]}
 			},
@@ -545,9 +709,9 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 
 				s.mockProgram.EXPECT().Add(di1.FilePath, "doc1.docx").Once()
-				s.mockExporter.EXPECT().doExport(&di1.DocumentInfo).Return("ticket1", nil).Once()
+				s.mockExporter.EXPECT().doExport(mock.Anything, &di1.DocumentInfo).Return("ticket1", nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.05, progress.StatusExporting).Once()
-				s.mockExporter.EXPECT().checkExport(&di1.DocumentInfo, "ticket1").Return(nil, progress.StatusFailed, oops.New("查询导出任务结果失败")).Once()
+				s.mockExporter.EXPECT().checkExport(mock.Anything, &di1.DocumentInfo, "ticket1").Return(nil, progress.StatusFailed, oops.New("查询导出任务结果失败")).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.10, progress.StatusFailed, "查询导出任务结果失败").Once()
 				return []any{exportResults[0]}
 			},
@@ -601,9 +765,9 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 
 				s.mockProgram.EXPECT().Add(di1.FilePath, "doc1.docx").Once()
-				s.mockExporter.EXPECT().doExport(&di1.DocumentInfo).Return("ticket1", nil).Once()
+				s.mockExporter.EXPECT().doExport(mock.Anything, &di1.DocumentInfo).Return("ticket1", nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.05, progress.StatusExporting).Once()
-				s.mockExporter.EXPECT().checkExport(&di1.DocumentInfo, "ticket1").Return(nil, progress.StatusInterrupted, nil).Once()
+				s.mockExporter.EXPECT().checkExport(mock.Anything, &di1.DocumentInfo, "ticket1").Return(nil, progress.StatusInterrupted, nil).Once()
 				return []any{exportResults[0]}
 			},
 			want: func(name string, completed *atomic.Bool, args []any) {
@@ -616,7 +780,7 @@ func (s *TaskImplTestSuite) TestTaskImpl_exportDocuments() {
 			// 设置mock
 			args := tt.setupMock(tt.name)
 			// 执行测试
-			completed := s.task.exportDocuments()
+			completed := s.task.exportDocuments(context.Background(), DefaultExportWorkers)
 			// 验证结果
 			tt.want(tt.name, completed, args)
 		})
@@ -769,9 +933,9 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 				directlyContent := "mock直接下载的文件内容"
 				directlyFileSize := int64(len(directlyContent))
-				s.mockExporter.EXPECT().doDownloadDirectly(di1.FilePath, di1.Token).
+				s.mockExporter.EXPECT().doDownloadDirectly(mock.Anything, di1.FilePath, di1.Token).
 					Return(strings.NewReader(directlyContent), directlyFileSize, nil).Once()
-				s.mockExporter.EXPECT().doDownloadExported(
+				s.mockExporter.EXPECT().doDownloadExported(mock.Anything,
 					di2.FilePath, larkcore.StringValue(exportResults[1].result.FileToken),
 				).Return(strings.NewReader(exportedContent), nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.20, progress.StatusDownloading).Once()
@@ -809,6 +973,48 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 				}
 			},
 		},
+		{
+			name: "正常下载[本地转换]",
+			setupMock: func(name string) (args []any) {
+				di1 := &DocumentNode{
+					DocumentInfo: DocumentInfo{
+						Name:           "doc1",
+						Token:          "doc1_token",
+						Type:           constant.DocTypeDocx,
+						ConvertLocally: true,
+						FileExtension:  constant.FileExtMarkdown,
+						CanDownload:    true,
+					},
+				}
+				s.task.Docs = []*DocumentNode{di1}
+				infoList := documentNodesToInfoList(s.task.Docs, "/tmp")
+				// 初始化必要参数备用
+				s.task.canDownloadList = infoList
+				s.task.countDown = &atomic.Int32{}
+				s.task.countDown.Store(int32(len(s.task.canDownloadList)))
+				s.task.completed.Store(false)
+				s.task.queue = make(chan *exportResult, 1)
+				content := "# doc1\n"
+				s.mockExporter.EXPECT().doConvert(mock.Anything, &di1.DocumentInfo).
+					Return(strings.NewReader(content), int64(len(content)), nil).Once()
+				s.mockProgram.EXPECT().Update(di1.FilePath, 0.20, progress.StatusDownloading).Once()
+				s.mockProgram.EXPECT().Update(di1.FilePath, mock.Anything, progress.StatusDownloading,
+					"total: %d, wrote: %d", int64(len(content)), mock.Anything).Maybe()
+				s.mockProgram.EXPECT().Update(di1.FilePath, 1.00, progress.StatusCompleted).Once()
+				s.mockClient.EXPECT().GetArgs().Return(&Args{Args: &argument.Args{QuitAutomatically: true}}).Maybe()
+				s.mockProgram.EXPECT().Quit().Maybe()
+				return []any{&exportResult{DocumentInfo: infoList[0]}, content}
+			},
+			want: func(name string, completed *atomic.Bool, args []any) {
+				er := args[0].(*exportResult)
+				s.task.queue <- er
+				s.waitToContinue(completed)
+				data, err := app.Fs.ReadFile(er.FilePath)
+				s.Require().NoError(err, name)
+				s.Equal(args[1], string(data), name)
+				s.Require().NoError(app.Fs.Remove(er.FilePath), name)
+			},
+		},
 		{
 			name: "下载失败",
 			setupMock: func(name string) (args []any) {
@@ -839,7 +1045,7 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 				s.task.countDown.Store(int32(len(s.task.canDownloadList)))
 				s.task.completed.Store(false)
 				s.task.queue = make(chan *exportResult, 2)
-				s.mockExporter.EXPECT().doDownloadExported(di1.FilePath, di1.Token).Return(nil, oops.New("下载失败")).Once()
+				s.mockExporter.EXPECT().doDownloadExported(mock.Anything, di1.FilePath, di1.Token).Return(nil, oops.New("下载失败")).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.18, progress.StatusFailed, "下载失败").Once()
 				s.mockClient.EXPECT().GetArgs().Return(&Args{Args: &argument.Args{QuitAutomatically: true}}).Maybe()
 				s.mockProgram.EXPECT().Quit().Maybe()
@@ -916,9 +1122,9 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 				s.task.queue = make(chan *exportResult, 2)
 				directlyContent := "mock直接下载的文件内容2"
 				directlyFileSize := int64(len(directlyContent))
-				s.mockExporter.EXPECT().doDownloadDirectly(di1.FilePath, di1.Token).
+				s.mockExporter.EXPECT().doDownloadDirectly(mock.Anything, di1.FilePath, di1.Token).
 					Return(strings.NewReader(directlyContent), directlyFileSize, nil).Once()
-				s.mockExporter.EXPECT().doDownloadExported(
+				s.mockExporter.EXPECT().doDownloadExported(mock.Anything,
 					di2.FilePath, larkcore.StringValue(exportResults[1].result.FileToken),
 				).Return(strings.NewReader(exportedContent), nil).Once()
 				s.mockProgram.EXPECT().Update(di1.FilePath, 0.20, progress.StatusDownloading).Once()
@@ -953,7 +1159,7 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 			// 设置mock
 			args := tt.setupMock(tt.name)
 			// 执行测试
-			completed := s.task.downloadDocuments()
+			completed := s.task.downloadDocuments(context.Background(), DefaultDownloadWorkers)
 			// 验证结果
 			tt.want(tt.name, completed, args)
 		})
@@ -962,10 +1168,10 @@ func (s *TaskImplTestSuite) TestTaskImpl_downloadDocuments() {
 
 func (s *TaskImplTestSuite) Test_calculateOverallProgress() {
 	tests := []struct {
-		name                      string
-		canDownloadCount          int
-		total, downloaded, failed int
-		expected                  string
+		name                               string
+		canDownloadCount                   int
+		total, downloaded, failed, skipped int
+		expected                           string
 	}{
 		{
 			name:             "部分完成有失败1",
@@ -999,17 +1205,79 @@ func (s *TaskImplTestSuite) Test_calculateOverallProgress() {
 			failed:           0,
 			expected:         "可下载: 10, 已提交: 7, 已下载: 3, 未下载: 4, 已失败: 0\n█████████████████░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░  30%",
 		},
+		{
+			name:             "部分完成有跳过",
+			canDownloadCount: 10,
+			total:            7,
+			downloaded:       3,
+			failed:           0,
+			skipped:          2,
+			expected:         "可下载: 10, 已提交: 7, 已下载: 3, 未下载: 2, 已失败: 0, 已跳过: 2\n████████████████████████████░░░░░░░░░░░░░░░░░░░░░░░░░░░  50%",
+		},
 	}
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			stats := calculateOverallProgress(tt.canDownloadCount)
-			actual := stats(tt.total, tt.downloaded, tt.failed)
+			actual := stats(tt.total, tt.downloaded, tt.failed, tt.skipped)
 			s.Require().Equal(tt.expected, actual, tt.name)
 		})
 	}
 }
 
+func (s *TaskImplTestSuite) Test_taskOutcome() {
+	tests := []struct {
+		name      string
+		summary   reportSummary
+		canceled  bool
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:    "全部完成",
+			summary: reportSummary{CanDownload: 3, Submitted: 3, Completed: 2, Skipped: 1},
+		},
+		{
+			name:    "没有可下载的文档",
+			summary: reportSummary{},
+		},
+		{
+			name:      "部分失败",
+			summary:   reportSummary{CanDownload: 3, Submitted: 3, Completed: 1, Failed: 1, Skipped: 1},
+			wantError: "部分文档导出失败, 已下载: 1, 已失败: 1, 已跳过: 1",
+			wantCode:  "PartialFailure",
+		},
+		{
+			name:      "提前退出下载UI",
+			summary:   reportSummary{CanDownload: 3, Submitted: 2, Completed: 1, Failed: 1},
+			wantError: "导出被中断, 已下载: 1, 已失败: 1, 未完成: 1",
+			wantCode:  "Interrupted",
+		},
+		{
+			name:      "收到中断信号",
+			summary:   reportSummary{CanDownload: 3, Submitted: 3, Completed: 3},
+			canceled:  true,
+			wantError: "导出被中断, 已下载: 3, 已失败: 0, 未完成: 0",
+			wantCode:  "Interrupted",
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			err := taskOutcome(tt.summary, tt.canceled)
+			if tt.wantError == "" {
+				s.Require().NoError(err, tt.name)
+				return
+			}
+			s.Require().EqualError(err, tt.wantError, tt.name)
+			var oopsError oops.OopsError
+			s.Require().True(errors.As(err, &oopsError), tt.name)
+			s.Equal(tt.wantCode, oopsError.Code(), tt.name)
+			s.Equal(tt.wantCode == "PartialFailure", isPartialFailure(oops.Wrap(err)), tt.name)
+		})
+	}
+	s.False(isPartialFailure(errors.New("部分文档导出失败")))
+}
+
 // mockError Mock types for testing。
 type mockError struct {
 	logID string
@@ -1087,3 +1355,25 @@ func (s *TaskImplTestSuite) Test_cleanEnter() {
 		})
 	}
 }
+
+func TestStartWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		want    int32
+	}{
+		{"多个协程", 4, 4},
+		{"一个协程", 1, 1},
+		{"协程数量为0", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := &atomic.Int32{}
+			completed := startWorkers(tt.workers, func() {
+				count.Add(1)
+			})
+			require.Eventually(t, completed.Load, time.Second, time.Millisecond)
+			require.Equal(t, tt.want, count.Load())
+		})
+	}
+}
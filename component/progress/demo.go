@@ -15,6 +15,7 @@
 package progress
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
@@ -48,10 +49,13 @@ func (c *TestClient) Validate() error {
 	return nil
 }
 
-func (c *TestClient) DownloadDocuments(dss []*cloud.DocumentSource) error {
+func (c *TestClient) DownloadDocuments(ctx context.Context, dss []*cloud.DocumentSource) error {
 	if len(dss) == 0 {
 		return oops.New("文档源为空")
 	}
+	// 收到中断信号时关掉UI程序
+	stop := context.AfterFunc(ctx, c.p.Quit)
+	defer stop()
 	return testProgramAddUpdate(c.p)
 }
 
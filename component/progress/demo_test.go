@@ -15,6 +15,7 @@
 package progress
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -62,9 +63,9 @@ func (s *DemoTestSuite) TestDemo() {
 		return nil, errors.New("demo error")
 	})
 
-	err = tc.DownloadDocuments(nil)
+	err = tc.DownloadDocuments(context.Background(), nil)
 	s.Require().EqualError(err, "文档源为空")
 
-	err = tc.DownloadDocuments([]*cloud.DocumentSource{nil})
+	err = tc.DownloadDocuments(context.Background(), []*cloud.DocumentSource{nil})
 	s.Require().EqualError(err, "demo error")
 }
@@ -0,0 +1,152 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// JSON事件类型。
+const (
+	EventAdd     = "add"     // 添加文件
+	EventUpdate  = "update"  // 文件状态或进度变化
+	EventSummary = "summary" // 结束时的统计信息
+)
+
+// jsonProgressStep 同一状态下进度每前进这么多才输出一次，避免下载时每写一块数据就输出一行。
+const jsonProgressStep = 0.1
+
+type (
+	// jsonProgram JSON进度输出，每行一个JSON事件（NDJSON）。
+	jsonProgram struct {
+		*fileTracker
+		out       io.Writer
+		startedAt time.Time
+		emitted   map[string]float64 // 各文件最近一次输出的进度
+		done      chan struct{}
+		quitOnce  sync.Once
+	}
+
+	// Event JSON进度事件。
+	Event struct {
+		Type     string   `json:"type"`               // 事件类型: add/update/summary
+		Time     int64    `json:"time"`               // 事件时间（Unix时间戳，毫秒）
+		Key      string   `json:"key,omitempty"`      // 文件唯一标识，即文件保存路径
+		FileName string   `json:"fileName,omitempty"` // 文件名
+		Status   string   `json:"status,omitempty"`   // 状态: added/exporting/exported/waiting/downloading/completed/failed/interrupted/skipped
+		Progress float64  `json:"progress"`           // 进度 0.0->1.0
+		Message  string   `json:"message,omitempty"`  // 状态附带的消息，如失败原因
+		AddedAt  int64    `json:"addedAt,omitempty"`  // 文件添加时间（Unix时间戳，毫秒）
+		Elapsed  int64    `json:"elapsed"`            // 文件添加以来的耗时（毫秒），summary事件中为总耗时
+		Summary  *Summary `json:"summary,omitempty"`  // 统计信息，只在summary事件中输出
+	}
+
+	// Summary 各状态的文件数量统计。
+	Summary struct {
+		Total       int `json:"total"`       // 总数量
+		Completed   int `json:"completed"`   // 已下载
+		Failed      int `json:"failed"`      // 已失败
+		Skipped     int `json:"skipped"`     // 已跳过
+		Interrupted int `json:"interrupted"` // 已中断
+		Remaining   int `json:"remaining"`   // 未下载，包括已中断
+	}
+)
+
+func newJSONProgram(out io.Writer, _ Stats) *jsonProgram {
+	tracker := newFileTracker()
+	return &jsonProgram{
+		fileTracker: tracker,
+		out:         out,
+		startedAt:   tracker.now(),
+		emitted:     make(map[string]float64),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run 阻塞直到调用Quit，结束时输出summary事件。
+func (p *jsonProgram) Run() (tea.Model, error) {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.emit(Event{
+		Type:    EventSummary,
+		Time:    now.UnixMilli(),
+		Elapsed: now.Sub(p.startedAt).Milliseconds(),
+		Summary: p.summary(),
+	})
+	return nil, nil
+}
+
+// Quit 结束Run，可重复调用。
+func (p *jsonProgram) Quit() {
+	p.quitOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// Add 添加新的待下载文件。
+func (p *jsonProgram) Add(key, fileName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.add(key, fileName)
+	p.emitted[key] = 0
+	p.emitFile(EventAdd, f, "")
+}
+
+// Update 更新文件下载进度，状态变化或进度前进超过jsonProgressStep时才输出。
+func (p *jsonProgram) Update(key string, progress float64, status Status, msgFormat ...any) {
+	status, msg := parseUpdate(progress, status, msgFormat...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.get(key)
+	changed := f.status != status
+	f.status = status
+	f.progress = progress
+	if !changed && progress-p.emitted[key] < jsonProgressStep {
+		return
+	}
+	p.emitted[key] = progress
+	p.emitFile(EventUpdate, f, msg)
+}
+
+func (p *jsonProgram) emitFile(typ string, f *fileState, msg string) {
+	now := p.now()
+	p.emit(Event{
+		Type:     typ,
+		Time:     now.UnixMilli(),
+		Key:      f.key,
+		FileName: f.fileName,
+		Status:   StatusName(f.status),
+		Progress: f.progress,
+		Message:  StripStyle(msg),
+		AddedAt:  f.addedAt.UnixMilli(),
+		Elapsed:  now.Sub(f.addedAt).Milliseconds(),
+	})
+}
+
+// emit 输出一行JSON事件，写入失败不影响导出下载，所以忽略错误。
+func (p *jsonProgram) emit(event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = p.out.Write(append(data, '\n'))
+}
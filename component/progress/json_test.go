@@ -0,0 +1,117 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONProgram(t *testing.T) {
+	var out bytes.Buffer
+	now := time.UnixMilli(1735801445000)
+	p := newJSONProgram(&out, nil)
+	p.now = func() time.Time {
+		return now
+	}
+	p.startedAt = now
+
+	p.Add("/tmp/a.docx", "a.docx")
+	p.Add("/tmp/b.pdf", "b.pdf")
+	now = now.Add(time.Second)
+	p.Update("/tmp/a.docx", 0.2, StatusExporting)
+	p.Update("/tmp/a.docx", 0.25, StatusDownloading, "total: %d, wrote: %d", 100, 0)
+	p.Update("/tmp/a.docx", 0.3, StatusDownloading, "进度前进不足，不输出")
+	p.Update("/tmp/a.docx", 0.4, StatusDownloading, "total: %d, wrote: %d", 100, 20)
+	p.Update("/tmp/a.docx", 1.0, StatusDownloading, "total: %d, wrote: %d", 100, 100)
+	p.Update("/tmp/b.pdf", 0.2, StatusFailed, "响应错误: %s", "500")
+	p.Update("/tmp/c.md", 1.0, StatusSkipped, "未变更")
+
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		model, err := p.Run()
+		require.NoError(t, err)
+		require.Nil(t, model)
+	}()
+	p.Quit()
+	p.Quit()
+	<-ran
+
+	var events []Event
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), scanner.Text())
+		events = append(events, event)
+	}
+	added := int64(1735801445000)
+	updated := int64(1735801446000)
+	require.Equal(t, []Event{
+		{Type: EventAdd, Time: added, Key: "/tmp/a.docx", FileName: "a.docx", Status: "added", AddedAt: added},
+		{Type: EventAdd, Time: added, Key: "/tmp/b.pdf", FileName: "b.pdf", Status: "added", AddedAt: added},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/a.docx", FileName: "a.docx", Status: "exporting", Progress: 0.2, AddedAt: added, Elapsed: 1000},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/a.docx", FileName: "a.docx", Status: "downloading", Progress: 0.25,
+			Message: "total: 100, wrote: 0", AddedAt: added, Elapsed: 1000},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/a.docx", FileName: "a.docx", Status: "downloading", Progress: 0.4,
+			Message: "total: 100, wrote: 20", AddedAt: added, Elapsed: 1000},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/a.docx", FileName: "a.docx", Status: "completed", Progress: 1.0,
+			Message: "total: 100, wrote: 100", AddedAt: added, Elapsed: 1000},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/b.pdf", FileName: "b.pdf", Status: "failed", Progress: 0.2,
+			Message: "响应错误: 500", AddedAt: added, Elapsed: 1000},
+		{Type: EventUpdate, Time: updated, Key: "/tmp/c.md", FileName: "/tmp/c.md", Status: "skipped", Progress: 1.0,
+			Message: "未变更", AddedAt: updated},
+		{Type: EventSummary, Time: updated, Elapsed: 1000, Summary: &Summary{
+			Total: 3, Completed: 1, Failed: 1, Skipped: 1, Interrupted: 0, Remaining: 0,
+		}},
+	}, events)
+}
+
+func TestJSONProgram_interrupted(t *testing.T) {
+	var out bytes.Buffer
+	p := newJSONProgram(&out, nil)
+	p.Add("a", "a")
+	p.Add("b", "b")
+	p.Update("a", 0.2, StatusInterrupted, "context canceled")
+	p.Quit()
+	_, err := p.Run()
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	var summary Event
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &summary))
+	require.Equal(t, &Summary{Total: 2, Interrupted: 1, Remaining: 2}, summary.Summary)
+}
+
+func TestJSONProgram_stripStyle(t *testing.T) {
+	var out bytes.Buffer
+	p := newJSONProgram(&out, nil)
+	p.Add("a", "a")
+	// 错误信息中带有终端样式和链接，JSON事件中只保留文字和URL
+	p.Update("a", 0.2, StatusFailed, "logId: %s, %s", URLStyleRender("https://open.feishu.cn/search?q=xyz"), GreenStyle.Render("响应错误: 500"))
+	p.Quit()
+	_, err := p.Run()
+	require.NoError(t, err)
+	require.NotContains(t, out.String(), "\x1b")
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	var failed Event
+	require.NoError(t, json.Unmarshal(lines[1], &failed))
+	require.Equal(t, "failed", failed.Status)
+	require.Equal(t, "logId: https://open.feishu.cn/search?q=xyz, 响应错误: 500", failed.Message)
+}
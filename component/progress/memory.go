@@ -0,0 +1,106 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type (
+	// MemoryProgram 只在内存中记录各文件的进度，不输出任何内容，用于通过接口查询导出进度。
+	MemoryProgram struct {
+		*fileTracker
+		done     chan struct{}
+		quitOnce sync.Once
+	}
+
+	// FileProgress 文件进度的快照。
+	FileProgress struct {
+		Key       string  `json:"key"`               // 文件唯一标识，即文件保存路径
+		FileName  string  `json:"fileName"`          // 文件名
+		Status    string  `json:"status"`            // 状态: added/exporting/exported/waiting/downloading/completed/failed/interrupted/skipped
+		Progress  float64 `json:"progress"`          // 进度 0.0->1.0
+		Message   string  `json:"message,omitempty"` // 最近一次状态附带的消息，如失败原因
+		AddedAt   int64   `json:"addedAt"`           // 文件添加时间（Unix时间戳，毫秒）
+		UpdatedAt int64   `json:"updatedAt"`         // 最近一次更新时间（Unix时间戳，毫秒）
+	}
+)
+
+func NewMemoryProgram() *MemoryProgram {
+	return &MemoryProgram{
+		fileTracker: newFileTracker(),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run 阻塞直到调用Quit。
+func (p *MemoryProgram) Run() (tea.Model, error) {
+	<-p.done
+	return nil, nil
+}
+
+// Quit 结束Run，可重复调用。
+func (p *MemoryProgram) Quit() {
+	p.quitOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// Add 添加新的待下载文件。
+func (p *MemoryProgram) Add(key, fileName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.add(key, fileName)
+}
+
+// Update 更新文件下载进度，消息去掉终端样式后记录。
+func (p *MemoryProgram) Update(key string, progress float64, status Status, msgFormat ...any) {
+	status, msg := parseUpdate(progress, status, msgFormat...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.get(key)
+	f.status = status
+	f.progress = progress
+	f.message = StripStyle(msg)
+	f.updatedAt = p.now()
+}
+
+// Files 按添加顺序返回各文件进度的快照。
+func (p *MemoryProgram) Files() []*FileProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	files := make([]*FileProgress, 0, len(p.order))
+	for _, f := range p.order {
+		files = append(files, &FileProgress{
+			Key:       f.key,
+			FileName:  f.fileName,
+			Status:    StatusName(f.status),
+			Progress:  f.progress,
+			Message:   f.message,
+			AddedAt:   f.addedAt.UnixMilli(),
+			UpdatedAt: f.updatedAt.UnixMilli(),
+		})
+	}
+	return files
+}
+
+// Summary 各状态的文件数量统计。
+func (p *MemoryProgram) Summary() *Summary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.summary()
+}
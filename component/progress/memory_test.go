@@ -0,0 +1,63 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryProgram(t *testing.T) {
+	p := NewMemoryProgram()
+	now := time.UnixMilli(1735801445000)
+	p.now = func() time.Time {
+		return now
+	}
+
+	p.Add("/tmp/a.docx", "a.docx")
+	p.Add("/tmp/b.pdf", "b.pdf")
+	p.Add("/tmp/c.xlsx", "c.xlsx")
+	now = now.Add(time.Second)
+	p.Update("/tmp/a.docx", 0.5, StatusDownloading, "total: %d, wrote: %d", 10, 5)
+	p.Update("/tmp/b.pdf", 0.2, StatusFailed, "logId: %s, 响应错误: %s", URLStyleRender("https://open.feishu.cn/search?q=abc"), "500")
+	p.Update("/tmp/c.xlsx", 1.0, StatusSkipped, "未变更")
+	require.Equal(t, []*FileProgress{
+		{Key: "/tmp/a.docx", FileName: "a.docx", Status: "downloading", Progress: 0.5, Message: "total: 10, wrote: 5",
+			AddedAt: 1735801445000, UpdatedAt: 1735801446000},
+		{Key: "/tmp/b.pdf", FileName: "b.pdf", Status: "failed", Progress: 0.2,
+			Message: "logId: https://open.feishu.cn/search?q=abc, 响应错误: 500", AddedAt: 1735801445000, UpdatedAt: 1735801446000},
+		{Key: "/tmp/c.xlsx", FileName: "c.xlsx", Status: "skipped", Progress: 1.0, Message: "未变更",
+			AddedAt: 1735801445000, UpdatedAt: 1735801446000},
+	}, p.Files())
+	require.Equal(t, &Summary{Total: 3, Failed: 1, Skipped: 1, Remaining: 1}, p.Summary())
+
+	p.Update("/tmp/a.docx", 1.0, StatusDownloading)
+	require.Equal(t, "completed", p.Files()[0].Status)
+	require.Equal(t, "", p.Files()[0].Message)
+	require.Equal(t, &Summary{Total: 3, Completed: 1, Failed: 1, Skipped: 1}, p.Summary())
+
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		model, err := p.Run()
+		require.NoError(t, err)
+		require.Nil(t, model)
+	}()
+	p.Quit()
+	p.Quit()
+	<-ran
+}
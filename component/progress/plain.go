@@ -0,0 +1,261 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cast"
+
+	"github.com/acyumi/xdoc/component/app"
+)
+
+// 进度展示方式。
+const (
+	ModeAuto  = "auto"  // 标准输出是终端时使用tui，否则使用plain
+	ModeTUI   = "tui"   // 全屏的下载UI程序
+	ModePlain = "plain" // 逐行输出带时间戳的状态变化，适用于CI、定时任务和nohup
+	ModeJSON  = "json"  // 逐行输出JSON事件，便于其他工具解析
+)
+
+// statusNames 状态在纯文本输出中的名称。
+var statusNames = map[Status]string{
+	StatusAdded:       "added",
+	StatusExporting:   "exporting",
+	StatusExported:    "exported",
+	StatusWaiting:     "waiting",
+	StatusDownloading: "downloading",
+	StatusCompleted:   "completed",
+	StatusFailed:      "failed",
+	StatusInterrupted: "interrupted",
+	StatusSkipped:     "skipped",
+}
+
+// isTerminal 判断标准输出是否是终端。
+var isTerminal = func() bool {
+	return term.IsTerminal(os.Stdout.Fd())
+}
+
+type (
+	// plainProgram 纯文本进度输出，只在文件状态变化时输出一行，不需要终端支持。
+	plainProgram struct {
+		*fileTracker
+		out      io.Writer
+		done     chan struct{}
+		quitOnce sync.Once
+	}
+
+	// fileTracker 记录各文件的状态，用于非终端的进度输出。
+	fileTracker struct {
+		mu    sync.Mutex
+		now   func() time.Time
+		files map[string]*fileState // 通过key取出指定的文件
+		order []*fileState          // 按添加顺序排列的文件，用于统计
+	}
+
+	// fileState 文件的状态。
+	fileState struct {
+		key       string
+		fileName  string
+		status    Status
+		progress  float64
+		message   string // 最近一次状态附带的消息，只有内存进度记录
+		addedAt   time.Time
+		updatedAt time.Time
+	}
+)
+
+func newFileTracker() *fileTracker {
+	return &fileTracker{
+		now:   time.Now,
+		files: make(map[string]*fileState),
+	}
+}
+
+// add 添加文件，调用方需要持有锁。
+func (t *fileTracker) add(key, fileName string) *fileState {
+	now := t.now()
+	f := &fileState{key: key, fileName: fileName, status: StatusAdded, addedAt: now, updatedAt: now}
+	t.files[key] = f
+	t.order = append(t.order, f)
+	return f
+}
+
+// get 取出文件，未添加过的文件以key作为文件名添加，调用方需要持有锁。
+func (t *fileTracker) get(key string) *fileState {
+	f, ok := t.files[key]
+	if !ok {
+		f = t.add(key, key)
+		f.status = ""
+	}
+	return f
+}
+
+// count 统计各状态的文件数量，调用方需要持有锁。
+func (t *fileTracker) count() (total, downloaded, failed, skipped, interrupted int) {
+	total = len(t.order)
+	for _, f := range t.order {
+		switch f.status {
+		case StatusCompleted:
+			downloaded++
+		case StatusFailed:
+			failed++
+		case StatusSkipped:
+			skipped++
+		case StatusInterrupted:
+			interrupted++
+		}
+	}
+	return total, downloaded, failed, skipped, interrupted
+}
+
+// summary 各状态的文件数量统计，调用方需要持有锁。
+func (t *fileTracker) summary() *Summary {
+	total, downloaded, failed, skipped, interrupted := t.count()
+	return &Summary{
+		Total:       total,
+		Completed:   downloaded,
+		Failed:      failed,
+		Skipped:     skipped,
+		Interrupted: interrupted,
+		Remaining:   total - downloaded - failed - skipped,
+	}
+}
+
+// parseUpdate 格式化消息，进度到达1.0时除跳过外都视为已完成。
+func parseUpdate(progress float64, status Status, msgFormat ...any) (Status, string) {
+	var msg string
+	if len(msgFormat) > 0 {
+		msg = fmt.Sprintf(cast.ToString(msgFormat[0]), msgFormat[1:]...)
+	}
+	if progress >= 1.0 && status != StatusSkipped {
+		status = StatusCompleted
+	}
+	return status, msg
+}
+
+// StatusName 状态在非终端输出和导出报告中的名称。
+func StatusName(status Status) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+	return string(status)
+}
+
+// ResolveMode 解析进度展示方式，auto时根据标准输出是否是终端选择tui或plain。
+func ResolveMode(mode string) string {
+	if mode == "" || mode == ModeAuto {
+		if isTerminal() {
+			return ModeTUI
+		}
+		return ModePlain
+	}
+	return mode
+}
+
+// NewProgramConstructor 根据进度展示方式返回对应的IProgram构造函数，plain和json输出到out。
+func NewProgramConstructor(mode string, out io.Writer) func(Stats) IProgram {
+	switch ResolveMode(mode) {
+	case ModePlain:
+		// 纯文本输出只在结束时输出一行统计数量，不使用下载UI的进度条
+		return func(Stats) IProgram {
+			return newPlainProgram(out)
+		}
+	case ModeJSON:
+		return func(stats Stats) IProgram {
+			return newJSONProgram(out, stats)
+		}
+	default:
+		return NewProgram
+	}
+}
+
+func newPlainProgram(out io.Writer) *plainProgram {
+	return &plainProgram{
+		fileTracker: newFileTracker(),
+		out:         out,
+		done:        make(chan struct{}),
+	}
+}
+
+// Run 阻塞直到调用Quit，结束时输出统计信息。
+func (p *plainProgram) Run() (tea.Model, error) {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.println(p.renderStats())
+	return nil, nil
+}
+
+// Quit 结束Run，可重复调用。
+func (p *plainProgram) Quit() {
+	p.quitOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// Add 添加新的待下载文件。
+func (p *plainProgram) Add(key, fileName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.printStatus(p.add(key, fileName), "")
+}
+
+// Update 更新文件下载进度，只有状态变化时才输出。
+func (p *plainProgram) Update(key string, progress float64, status Status, msgFormat ...any) {
+	status, msg := parseUpdate(progress, status, msgFormat...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.get(key)
+	f.progress = progress
+	if f.status == status {
+		return
+	}
+	f.status = status
+	p.printStatus(f, msg)
+}
+
+func (p *plainProgram) printStatus(f *fileState, msg string) {
+	line := fmt.Sprintf("[%s] %s", StatusName(f.status), f.fileName)
+	if msg != "" {
+		// 错误信息中可能带有终端样式和链接，逐行输出时只保留文字
+		line += fmt.Sprintf(" (%s)", StripStyle(msg))
+	}
+	p.println(line)
+}
+
+func (p *plainProgram) println(line string) {
+	app.Fprintf(p.out, "%s %s\n", p.now().Format(time.DateTime), line)
+}
+
+// renderStats 渲染统计信息，只有各状态的数量，没有进度条和颜色。
+func (p *plainProgram) renderStats() string {
+	total, downloaded, failed, skipped, interrupted := p.count()
+	remaining := total - downloaded - failed - skipped
+	statsInfo := fmt.Sprintf("总数量: %d, 已下载: %d, 未下载: %d, 已失败: %d", total, downloaded, remaining, failed)
+	if skipped > 0 {
+		statsInfo += fmt.Sprintf(", 已跳过: %d", skipped)
+	}
+	if interrupted > 0 {
+		statsInfo += fmt.Sprintf(", 已中断: %d", interrupted)
+	}
+	return statsInfo
+}
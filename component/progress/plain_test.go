@@ -0,0 +1,114 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlainProgram(t *testing.T) {
+	var out bytes.Buffer
+	p := newPlainProgram(&out)
+	p.now = func() time.Time {
+		return time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	}
+
+	p.Add("/tmp/a.docx", "a.docx")
+	p.Add("/tmp/b.pdf", "b.pdf")
+	p.Add("/tmp/c.xlsx", "c.xlsx")
+	p.Update("/tmp/a.docx", 0.2, StatusExporting)
+	p.Update("/tmp/a.docx", 0.3, StatusExporting, "重复的状态不输出")
+	p.Update("/tmp/a.docx", 0.5, StatusDownloading, "%d%%", 50)
+	p.Update("/tmp/a.docx", 1.0, StatusDownloading)
+	p.Update("/tmp/b.pdf", 0.2, StatusFailed, "logId: %s, %s", URLStyleRender("https://open.feishu.cn/search?q=xyz"), GreenStyle.Render("响应错误: 500"))
+	p.Update("/tmp/c.xlsx", 1.0, StatusSkipped, "未变更")
+	p.Update("/tmp/d.md", 0.2, StatusExporting)
+
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		model, err := p.Run()
+		require.NoError(t, err)
+		require.Nil(t, model)
+	}()
+	p.Quit()
+	p.Quit()
+	<-ran
+
+	require.Equal(t, `2025-01-02 15:04:05 [added] a.docx
+2025-01-02 15:04:05 [added] b.pdf
+2025-01-02 15:04:05 [added] c.xlsx
+2025-01-02 15:04:05 [exporting] a.docx
+2025-01-02 15:04:05 [downloading] a.docx (50%)
+2025-01-02 15:04:05 [completed] a.docx
+2025-01-02 15:04:05 [failed] b.pdf (logId: https://open.feishu.cn/search?q=xyz, 响应错误: 500)
+2025-01-02 15:04:05 [skipped] c.xlsx (未变更)
+2025-01-02 15:04:05 [exporting] /tmp/d.md
+2025-01-02 15:04:05 总数量: 4, 已下载: 1, 未下载: 1, 已失败: 1, 已跳过: 1
+`, out.String())
+	require.NotContains(t, out.String(), "\x1b", "逐行输出中没有终端样式")
+}
+
+func TestPlainProgram_renderStats(t *testing.T) {
+	p := newPlainProgram(&bytes.Buffer{})
+	p.Add("a", "a")
+	p.Add("b", "b")
+	p.Update("a", 1.0, StatusSkipped)
+	require.Equal(t, "总数量: 2, 已下载: 0, 未下载: 1, 已失败: 0, 已跳过: 1", p.renderStats())
+	p.Update("a", 1.0, StatusDownloading)
+	require.Equal(t, "总数量: 2, 已下载: 1, 未下载: 1, 已失败: 0", p.renderStats())
+	p.Update("b", 0.5, StatusInterrupted)
+	require.Equal(t, "总数量: 2, 已下载: 1, 未下载: 1, 已失败: 0, 已中断: 1", p.renderStats())
+}
+
+func TestResolveMode(t *testing.T) {
+	defer func(origin func() bool) {
+		isTerminal = origin
+	}(isTerminal)
+	tests := []struct {
+		name     string
+		mode     string
+		terminal bool
+		want     string
+	}{
+		{"auto终端", ModeAuto, true, ModeTUI},
+		{"auto非终端", ModeAuto, false, ModePlain},
+		{"空值非终端", "", false, ModePlain},
+		{"指定tui", ModeTUI, false, ModeTUI},
+		{"指定plain", ModePlain, true, ModePlain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isTerminal = func() bool { return tt.terminal }
+			require.Equal(t, tt.want, ResolveMode(tt.mode))
+		})
+	}
+}
+
+func TestNewProgramConstructor(t *testing.T) {
+	var out bytes.Buffer
+	plain, ok := NewProgramConstructor(ModePlain, &out)(nil).(*plainProgram)
+	require.True(t, ok)
+	require.Same(t, &out, plain.out)
+	jp, ok := NewProgramConstructor(ModeJSON, &out)(nil).(*jsonProgram)
+	require.True(t, ok)
+	require.Same(t, &out, jp.out)
+	_, ok = NewProgramConstructor(ModeTUI, &out)(nil).(*program)
+	require.True(t, ok)
+}
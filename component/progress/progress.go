@@ -16,6 +16,7 @@ package progress
 
 import (
 	"fmt"
+	"regexp"
 	"runtime"
 	"sync/atomic"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/spf13/cast"
 
 	"github.com/acyumi/xdoc/component/constant"
@@ -41,6 +43,7 @@ const (
 	StatusCompleted   Status = "c"
 	StatusFailed      Status = "f"
 	StatusInterrupted Status = "i"
+	StatusSkipped     Status = "s"
 )
 
 var (
@@ -56,6 +59,17 @@ var (
 	}
 )
 
+// osc8Regexp 匹配 URLStyleRender 渲染的链接，取出其中的URL。
+var osc8Regexp = regexp.MustCompile(`\x1b\]8;;(.*?)\x1b\\`)
+
+// StripStyle 去掉终端样式，URLStyleRender 渲染的链接只保留URL，用于写入文件或通过接口返回。
+func StripStyle(s string) string {
+	s = osc8Regexp.ReplaceAllStringFunc(s, func(link string) string {
+		return ansi.Strip(osc8Regexp.FindStringSubmatch(link)[1])
+	})
+	return ansi.Strip(s)
+}
+
 type (
 	program struct {
 		*tea.Program
@@ -68,6 +82,7 @@ type (
 		progresses []*progressStatus          // 进度列表，用于在滚动视图中按顺序渲染进度条
 		viewport   viewport.Model             // 滚动视图
 		stats      Stats                      // 获取统计信息函数，用于在顶部显示
+		pending    map[string]updateMsg       // 先于addMsg到达的updateMsg，在添加文件时再应用
 	}
 
 	// Status 文件导出和下载状态。
@@ -82,7 +97,7 @@ type (
 	}
 
 	// Stats 文件数量统计函数。
-	Stats func(total, downloaded, failed int) string
+	Stats func(total, downloaded, failed, skipped int) string
 
 	// addMsg 自定义消息类型，用于动态增加文件。
 	addMsg struct {
@@ -98,6 +113,7 @@ type (
 		// 正常下载：a added -> x exporting -> p exported -> w waiting -> d downloading -> c completed
 		// 下载失败：a added -> x exporting -> p exported -> w waiting -> d downloading -> f failed
 		// 导出失败：a added -> x exporting -> f failed
+		// 未变更跳过：a added -> s skipped
 		status Status
 		msg    string // 自定义消息，用于每一行下载记录的最右侧
 	}
@@ -133,6 +149,7 @@ func newModel(stats Stats) *model {
 		progresses: make([]*progressStatus, 0),
 		viewport:   viewport.New(viewportWith, viewportHeight), // 初始化滚动视图
 		stats:      stats,
+		pending:    make(map[string]updateMsg),
 	}
 	return m
 }
@@ -155,15 +172,11 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// 更新指定文件的下载进度
 		mp, ok := m.pm[msg.key]
 		if !ok {
+			// Add和Update都是异步发送的，updateMsg可能先于addMsg到达，先暂存起来
+			m.pending[msg.key] = msg
 			return m, nil
 		}
-		mp.status = msg.status
-		mp.msg = msg.msg
-		mp.progress.SetPercent(msg.progress)
-		if msg.progress >= 1.0 {
-			mp.status = StatusCompleted
-			mp.progress.PercentageStyle = GreenStyle
-		}
+		m.applyUpdateMsg(mp, msg)
 		// 更新视图内容
 		m.viewport.SetContent(m.renderContent())
 		return m, nil
@@ -184,6 +197,10 @@ func (m *model) handleAddMsg(msg addMsg) (tea.Model, tea.Cmd) {
 		progress.WithWidth(progressWidth),
 	)
 	p := &progressStatus{fileName: msg.fileName, status: StatusAdded, progress: &pm}
+	if um, ok := m.pending[msg.key]; ok {
+		delete(m.pending, msg.key)
+		m.applyUpdateMsg(p, um)
+	}
 	m.pm[msg.key] = p
 	m.progresses = append(m.progresses, p)
 	// 更新视图内容
@@ -196,6 +213,19 @@ func (m *model) handleAddMsg(msg addMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// applyUpdateMsg 将进度及状态更新到指定文件。
+func (m *model) applyUpdateMsg(mp *progressStatus, msg updateMsg) {
+	mp.status = msg.status
+	mp.msg = msg.msg
+	mp.progress.SetPercent(msg.progress)
+	if msg.progress >= 1.0 {
+		if msg.status != StatusSkipped {
+			mp.status = StatusCompleted
+		}
+		mp.progress.PercentageStyle = GreenStyle
+	}
+}
+
 // View 渲染界面。
 func (m *model) View() string {
 	defer m.executed.Store(true)
@@ -210,18 +240,25 @@ func (m *model) renderStats() string {
 	total := len(m.progresses)
 	downloaded := 0
 	failed := 0
+	skipped := 0
 	for _, p := range m.progresses {
-		if p.status == StatusCompleted {
+		switch p.status {
+		case StatusCompleted:
 			downloaded++
-		} else if p.status == StatusFailed {
+		case StatusFailed:
 			failed++
+		case StatusSkipped:
+			skipped++
 		}
 	}
 	if m.stats != nil {
-		return m.stats(total, downloaded, failed)
+		return m.stats(total, downloaded, failed, skipped)
 	}
-	remaining := total - downloaded - failed
+	remaining := total - downloaded - failed - skipped
 	statsInfo := fmt.Sprintf("总数量: %d, 已下载: %d, 未下载: %d, 已失败: %d", total, downloaded, remaining, failed)
+	if skipped > 0 {
+		statsInfo += fmt.Sprintf(", 已跳过: %d", skipped)
+	}
 	return TipsStyle.Render(statsInfo)
 }
 
@@ -251,7 +288,7 @@ func (m *model) renderContent() (view string) {
 	total := len(m.progresses)
 	completed := 0
 	for _, p := range m.progresses {
-		if p.status == StatusCompleted || p.status == StatusFailed {
+		if p.status == StatusCompleted || p.status == StatusFailed || p.status == StatusSkipped {
 			completed++
 		}
 	}
@@ -16,7 +16,6 @@ package progress
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"runtime"
 	"strings"
@@ -49,6 +48,11 @@ func (s *ProgressTestSuite) TestURLStyleRender() {
 	s.Equal("\x1b]8;;https://go.dev\x1b\\", result)
 }
 
+func (s *ProgressTestSuite) TestStripStyle() {
+	s.Equal("logId: https://go.dev, 响应错误: error", StripStyle("logId: "+URLStyleRender("https://go.dev")+", "+GreenStyle.Render("响应错误: error")))
+	s.Equal("没有样式", StripStyle("没有样式"))
+}
+
 func toOSView(expected string) string {
 	if runtime.GOOS != constant.Windows {
 		expected = strings.Replace(expected, `                                                                       
@@ -95,8 +99,6 @@ func (s *ProgressTestSuite) Test_program_Add_Update() {
 	// 创建模型
 	m := newModel(nil)
 	// 创建 BubbleTea 程序
-	ctx := context.Background()
-	context.WithTimeout(ctx, 200*time.Second)
 	var buf SafeBuffer
 	var in bytes.Buffer
 	p := &program{
@@ -394,6 +396,24 @@ tips => 鼠标滚轮/↑/↓: 上下滚动视图 • q/esc/ctrl+c: 退出
 	s.Equal(expected, view)
 }
 
+func (s *ProgressTestSuite) Test_model_handleAddMsg_pending() {
+	m := newModel(nil)
+	// updateMsg 先于 addMsg 到达时先暂存
+	_, cmd := m.Update(updateMsg{key: "fileKey", progress: 1.0, status: StatusSkipped, msg: "未变更"})
+	s.Nil(cmd)
+	s.Len(m.pending, 1)
+	s.Empty(m.progresses)
+	// 添加文件时再应用
+	_, cmd = m.handleAddMsg(addMsg{key: "fileKey", fileName: "fileNameXyz"})
+	s.Nil(cmd)
+	s.Empty(m.pending)
+	s.Require().Len(m.progresses, 1)
+	s.Equal(StatusSkipped, m.progresses[0].status)
+	s.Equal("未变更", m.progresses[0].msg)
+	s.InDelta(1.0, m.progresses[0].progress.Percent(), 0.0001)
+	s.Equal("总数量: 1, 已下载: 0, 未下载: 0, 已失败: 0, 已跳过: 1", m.renderStats())
+}
+
 func (s *ProgressTestSuite) Test_model_view() {
 	m := newModel(nil)
 	cmd := m.Init()
@@ -449,8 +469,13 @@ func (s *ProgressTestSuite) Test_model_renderStats() {
 	view = m.renderStats()
 	s.Equal("总数量: 1, 已下载: 0, 未下载: 0, 已失败: 1", view)
 
-	m.stats = func(total, downloaded, failed int) string {
-		return fmt.Sprintf("【Stats】总数量: %d, 已下载: %d, 未下载: %d, 已失败: %d", total, downloaded, total-downloaded-failed, failed)
+	p.status = StatusSkipped
+	view = m.renderStats()
+	s.Equal("总数量: 1, 已下载: 0, 未下载: 0, 已失败: 0, 已跳过: 1", view)
+
+	p.status = StatusFailed
+	m.stats = func(total, downloaded, failed, skipped int) string {
+		return fmt.Sprintf("【Stats】总数量: %d, 已下载: %d, 未下载: %d, 已失败: %d", total, downloaded, total-downloaded-failed-skipped, failed)
 	}
 	view = m.renderStats()
 	s.Equal("【Stats】总数量: 1, 已下载: 0, 未下载: 0, 已失败: 1", view)
@@ -15,6 +15,7 @@
 package progress
 
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
@@ -33,10 +34,10 @@ type Writer struct {
 	Walked   float64  // 文件写盘前进度条已走过的占比，如 0.2
 }
 
-func (pw *Writer) WriteFile(reader io.Reader) error {
+func (pw *Writer) WriteFile(ctx context.Context, reader io.Reader) (err error) {
 	// 创建目录
 	dirPath := filepath.Dir(pw.FilePath)
-	err := app.Fs.MkdirAll(dirPath, 0o755)
+	err = app.Fs.MkdirAll(dirPath, 0o755)
 	if err != nil {
 		return oops.Wrap(err)
 	}
@@ -45,8 +46,14 @@ func (pw *Writer) WriteFile(reader io.Reader) error {
 	if err != nil {
 		return oops.Wrap(err)
 	}
+	defer func() {
+		// 写入失败或被中断时删除不完整的文件
+		if err != nil {
+			_ = app.Fs.Remove(pw.FilePath)
+		}
+	}()
 	// 将数据写入文件，同时更新进度
-	teeReader := io.TeeReader(reader, pw)
+	teeReader := io.TeeReader(&contextReader{ctx: ctx, reader: reader}, pw)
 	_, err = io.Copy(file, teeReader)
 	if er := file.Close(); er != nil && err == nil {
 		err = er
@@ -67,3 +74,39 @@ func (pw *Writer) Write(p []byte) (int, error) {
 func (pw *Writer) Progress() float64 {
 	return pw.Walked + float64(pw.Wrote)/float64(pw.Total)*(1.0-pw.Walked)
 }
+
+// contextReader ctx取消后读取时返回错误，用于中断写入文件。
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// LogOutput 返回人可读日志的输出位置，json进度输出到标准输出时日志改为输出到标准错误，保证标准输出每一行都是JSON事件。
+func LogOutput(mode, filePath string, out io.Writer) io.Writer {
+	if filePath == "" && ResolveMode(mode) == ModeJSON {
+		return os.Stderr
+	}
+	return out
+}
+
+// OpenOutput 打开plain和json进度的输出文件，filePath为空时输出到标准输出，返回的closeOut用于关闭文件。
+func OpenOutput(filePath string) (out io.Writer, closeOut func(), err error) {
+	if filePath == "" {
+		return os.Stdout, func() {}, nil
+	}
+	if err = app.Fs.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return nil, nil, oops.Wrapf(err, "创建进度输出文件目录失败")
+	}
+	file, err := app.Fs.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, nil, oops.Wrapf(err, "打开进度输出文件失败")
+	}
+	return file, func() { _ = file.Close() }, nil
+}
@@ -15,6 +15,8 @@
 package progress
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -83,6 +85,7 @@ func (s *WriterTestSuite) TestWriter() {
 	tests := []struct {
 		name      string
 		content   string
+		cancel    bool // 写入前取消ctx
 		setupMock func(name string)
 		wantErr   string
 	}{
@@ -153,6 +156,15 @@ func (s *WriterTestSuite) TestWriter() {
 			},
 			wantErr: "关闭文件失败",
 		},
+		{
+			name:    "写入时被中断",
+			content: "hello world",
+			cancel:  true,
+			setupMock: func(name string) {
+				s.writer.Total = int64(len("hello world"))
+			},
+			wantErr: "context canceled",
+		},
 	}
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
@@ -161,10 +173,21 @@ func (s *WriterTestSuite) TestWriter() {
 			}()
 			tt.setupMock(tt.name)
 			reader := strings.NewReader(tt.content)
-			err := s.writer.WriteFile(reader)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			err := s.writer.WriteFile(ctx, reader)
 			if err != nil || tt.wantErr != "" {
 				s.Require().Error(err, tt.name)
 				s.Require().EqualError(err, tt.wantErr, tt.name)
+				if _, ok := app.Fs.Fs.(*mockFs); ok || tt.cancel {
+					// 不完整的文件已被删除
+					yes, err := app.Fs.Exists(s.writer.FilePath)
+					s.Require().NoError(err, tt.name)
+					s.False(yes, tt.name)
+				}
 			} else {
 				s.Require().NoError(err, tt.name)
 				actual, err := app.Fs.ReadFile(s.writer.FilePath)
@@ -184,3 +207,34 @@ func (s *WriterTestSuite) TestWriter() {
 		})
 	}
 }
+
+func (s *WriterTestSuite) TestOpenOutput() {
+	out, closeOut, err := OpenOutput("")
+	s.Require().NoError(err)
+	s.Same(os.Stdout, out)
+	closeOut()
+
+	out, closeOut, err = OpenOutput("/tmp/progress/progress.jsonl")
+	s.Require().NoError(err)
+	app.Fprintln(out, `{"type":"add"}`)
+	closeOut()
+	data, err := s.memFs.ReadFile("/tmp/progress/progress.jsonl")
+	s.Require().NoError(err)
+	s.Equal("{\"type\":\"add\"}\n", string(data))
+
+	app.Fs = &afero.Afero{Fs: afero.NewReadOnlyFs(s.memFs)}
+	defer func() {
+		app.Fs = s.memFs
+	}()
+	_, _, err = OpenOutput("/tmp/other/progress.jsonl")
+	s.Require().Error(err)
+	s.Contains(err.Error(), "创建进度输出文件目录失败")
+}
+
+func (s *WriterTestSuite) TestLogOutput() {
+	var out bytes.Buffer
+	// json进度输出到标准输出时，日志改为输出到标准错误
+	s.Same(os.Stderr, LogOutput(ModeJSON, "", &out))
+	s.Same(&out, LogOutput(ModeJSON, "/tmp/progress/progress.jsonl", &out))
+	s.Same(&out, LogOutput(ModePlain, "", &out))
+}
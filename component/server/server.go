@@ -0,0 +1,292 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 导出任务的状态。
+const (
+	JobRunning         = "running"          // 运行中
+	JobCompleted       = "completed"        // 全部文档导出成功
+	JobPartiallyFailed = "partially_failed" // 部分文档导出失败
+	JobFailed          = "failed"           // 导出失败
+	JobCanceled        = "canceled"         // 已取消
+)
+
+type (
+	// ExportFunc 执行一次导出，进度记录到 programConstructor 创建的 IProgram 中，ctx取消时中断导出。
+	ExportFunc func(ctx context.Context, programConstructor func(progress.Stats) progress.IProgram) error
+
+	// PrepareFunc 校验导出请求并准备导出，请求不合法时返回错误。
+	// 请求中未指定的参数由配置补充，并回填到请求中，以便任务信息展示实际使用的值。
+	PrepareFunc func(req *JobRequest) (ExportFunc, error)
+
+	// JobRequest 提交导出任务的请求。
+	JobRequest struct {
+		URLs       []string          `json:"urls"`                 // 文档地址
+		Dir        string            `json:"dir"`                  // 文档存放目录(本地)
+		Extensions map[string]string `json:"extensions,omitempty"` // 文档扩展名映射，如 {"docx": "md"}
+	}
+
+	// Job 导出任务的信息。
+	Job struct {
+		ID         string            `json:"id"`                   // 任务ID
+		Status     string            `json:"status"`               // 状态: running/completed/partially_failed/failed/canceled
+		URLs       []string          `json:"urls"`                 // 文档地址
+		Dir        string            `json:"dir"`                  // 文档存放目录(本地)
+		Extensions map[string]string `json:"extensions,omitempty"` // 文档扩展名映射
+		CreatedAt  int64             `json:"createdAt"`            // 提交时间（Unix时间戳，毫秒）
+		FinishedAt int64             `json:"finishedAt,omitempty"` // 结束时间（Unix时间戳，毫秒）
+		Error      string            `json:"error,omitempty"`      // 失败原因
+		Summary    *progress.Summary `json:"summary,omitempty"`    // 各状态的文档数量，开始下载后才有
+	}
+
+	// job 导出任务的运行状态，字段都由 Server.lock 保护。
+	job struct {
+		info    Job
+		cancel  context.CancelFunc
+		program *progress.MemoryProgram // 开始下载后才有
+	}
+
+	// Server 通过HTTP接口提交、查询和取消导出任务，任务只保存在内存中。
+	Server struct {
+		prepare PrepareFunc
+		token   string // 访问令牌，不为空时请求需要携带 Authorization: Bearer <token>
+		now     func() time.Time
+		ctx     context.Context
+		cancel  context.CancelFunc // 关闭服务时取消全部任务
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		seq     int
+		jobs    map[string]*job
+		order   []*job // 按提交顺序排列的任务
+	}
+)
+
+func New(prepare PrepareFunc, token string) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		prepare: prepare,
+		token:   token,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    map[string]*job{},
+	}
+}
+
+// Handler 返回HTTP接口的处理器。
+//
+//	POST /jobs                  提交导出任务
+//	GET  /jobs                  列出全部任务
+//	GET  /jobs/{id}             查询任务
+//	GET  /jobs/{id}/documents   查询任务中各文档的状态，可以通过?status=failed过滤
+//	POST /jobs/{id}/cancel      取消任务
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.submit)
+	mux.HandleFunc("GET /jobs", s.list)
+	mux.HandleFunc("GET /jobs/{id}", s.get)
+	mux.HandleFunc("GET /jobs/{id}/documents", s.documents)
+	mux.HandleFunc("POST /jobs/{id}/cancel", s.cancelJob)
+	return s.authenticate(mux)
+}
+
+// Close 取消全部运行中的任务，并等待它们结束。
+func (s *Server) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+			writeError(w, http.StatusUnauthorized, "访问令牌不正确")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "请求体不是合法的JSON: "+err.Error())
+		return
+	}
+	export, err := s.prepare(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, progress.StripStyle(err.Error()))
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// 同一个目录同时只能有一个任务在导出，避免互相覆盖文件
+	for _, j := range s.order {
+		if j.info.Status == JobRunning && j.info.Dir == req.Dir {
+			writeError(w, http.StatusConflict, "任务"+j.info.ID+"正在导出到目录: "+req.Dir)
+			return
+		}
+	}
+	s.seq++
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &job{
+		info: Job{
+			ID:         strconv.Itoa(s.seq),
+			Status:     JobRunning,
+			URLs:       req.URLs,
+			Dir:        req.Dir,
+			Extensions: req.Extensions,
+			CreatedAt:  s.now().UnixMilli(),
+		},
+		cancel: cancel,
+	}
+	s.jobs[j.info.ID] = j
+	s.order = append(s.order, j)
+	s.wg.Add(1)
+	go s.run(ctx, j, export)
+	writeJSON(w, http.StatusCreated, s.view(j))
+}
+
+// run 执行导出，结束后根据结果更新任务状态。
+func (s *Server) run(ctx context.Context, j *job, export ExportFunc) {
+	defer s.wg.Done()
+	defer j.cancel()
+	err := export(ctx, func(progress.Stats) progress.IProgram {
+		program := progress.NewMemoryProgram()
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		j.program = program
+		return program
+	})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j.info.FinishedAt = s.now().UnixMilli()
+	var oopsError oops.OopsError
+	switch {
+	case ctx.Err() != nil:
+		j.info.Status = JobCanceled
+	case err == nil:
+		j.info.Status = JobCompleted
+	case errors.As(err, &oopsError) && oopsError.Code() == "PartialFailure":
+		j.info.Status = JobPartiallyFailed
+	default:
+		j.info.Status = JobFailed
+	}
+	if err != nil {
+		j.info.Error = progress.StripStyle(err.Error())
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, _ *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	jobs := make([]*Job, 0, len(s.order))
+	for _, j := range s.order {
+		jobs = append(jobs, s.view(j))
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.view(j))
+}
+
+func (s *Server) documents(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	j, ok := s.find(w, r)
+	var program *progress.MemoryProgram
+	if ok {
+		program = j.program
+	}
+	s.lock.Unlock()
+	if !ok {
+		return
+	}
+	files := []*progress.FileProgress{}
+	if program != nil {
+		files = program.Files()
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		files = lo.Filter(files, func(f *progress.FileProgress, _ int) bool {
+			return f.Status == status
+		})
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+func (s *Server) cancelJob(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	if j.info.Status != JobRunning {
+		writeError(w, http.StatusConflict, "任务"+j.info.ID+"已经结束")
+		return
+	}
+	// 中断进行中的请求和下载，任务结束后状态变为canceled
+	j.cancel()
+	writeJSON(w, http.StatusAccepted, s.view(j))
+}
+
+// find 按路径中的任务ID查找任务，找不到时返回404，调用方需要持有锁。
+func (s *Server) find(w http.ResponseWriter, r *http.Request) (*job, bool) {
+	id := r.PathValue("id")
+	j, ok := s.jobs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "任务不存在: "+id)
+	}
+	return j, ok
+}
+
+// view 任务信息的快照，调用方需要持有锁。
+func (s *Server) view(j *job) *Job {
+	info := j.info
+	if j.program != nil {
+		info.Summary = j.program.Summary()
+	}
+	return &info
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 acyumi <417064257@qq.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/oops"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/acyumi/xdoc/component/progress"
+)
+
+// 注册测试套件。
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	server  *Server
+	handler http.Handler
+	started chan struct{} // 阻塞的导出开始下载时通知
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	s.started = make(chan struct{}, 1)
+	s.server = New(s.prepare, "")
+	s.server.now = func() time.Time {
+		return time.UnixMilli(1735801445000)
+	}
+	s.handler = s.server.Handler()
+}
+
+func (s *ServerTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+// prepare 按存放目录模拟不同的导出结果。
+func (s *ServerTestSuite) prepare(req *JobRequest) (ExportFunc, error) {
+	if len(req.URLs) == 0 {
+		return nil, oops.Code("InvalidArgument").Errorf("urls是必需参数")
+	}
+	if req.Dir == "" {
+		req.Dir = "/tmp/default"
+	}
+	return func(ctx context.Context, programConstructor func(progress.Stats) progress.IProgram) error {
+		program := programConstructor(nil)
+		program.Add("/tmp/a.docx", "a.docx")
+		program.Add("/tmp/b.pdf", "b.pdf")
+		switch req.Dir {
+		case "/tmp/partial":
+			program.Update("/tmp/a.docx", 1.0, progress.StatusDownloading)
+			program.Update("/tmp/b.pdf", 0.2, progress.StatusFailed, "logId: %s", progress.URLStyleRender("https://open.feishu.cn/search?q=abc"))
+			return oops.Code("PartialFailure").Errorf("部分文档导出失败, 已下载: 1, 已失败: 1, 已跳过: 0")
+		case "/tmp/failed":
+			return oops.Errorf("logId: %s, error response: 获取访问凭证失败", progress.URLStyleRender("https://open.feishu.cn/search?q=xyz"))
+		case "/tmp/block":
+			program.Update("/tmp/a.docx", 0.5, progress.StatusDownloading)
+			s.started <- struct{}{}
+			<-ctx.Done()
+			program.Update("/tmp/a.docx", 0.5, progress.StatusInterrupted)
+			return oops.Wrap(ctx.Err())
+		default:
+			program.Update("/tmp/a.docx", 1.0, progress.StatusDownloading)
+			program.Update("/tmp/b.pdf", 1.0, progress.StatusSkipped, "未变更")
+			return nil
+		}
+	}, nil
+}
+
+func (s *ServerTestSuite) do(method, path, body string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func (s *ServerTestSuite) decode(body string, v any) {
+	s.Require().NoError(json.Unmarshal([]byte(body), v), body)
+}
+
+// wait 等待任务结束。
+func (s *ServerTestSuite) wait(id string) *Job {
+	var got Job
+	s.Eventually(func() bool {
+		_, body := s.do(http.MethodGet, "/jobs/"+id, "")
+		s.decode(body, &got)
+		return got.Status != JobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	return &got
+}
+
+func (s *ServerTestSuite) TestSubmit() {
+	code, body := s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/tmp/docs", "extensions": {"docx": "md"}}`)
+	s.Equal(http.StatusCreated, code, body)
+	var created Job
+	s.decode(body, &created)
+	s.Equal("1", created.ID)
+	s.Equal(JobRunning, created.Status)
+	s.Equal([]string{"https://xxx.feishu.cn/wiki/xxx"}, created.URLs)
+	s.Equal(map[string]string{"docx": "md"}, created.Extensions)
+	s.Equal(int64(1735801445000), created.CreatedAt)
+
+	got := s.wait("1")
+	s.Equal(&Job{
+		ID:         "1",
+		Status:     JobCompleted,
+		URLs:       []string{"https://xxx.feishu.cn/wiki/xxx"},
+		Dir:        "/tmp/docs",
+		Extensions: map[string]string{"docx": "md"},
+		CreatedAt:  1735801445000,
+		FinishedAt: 1735801445000,
+		Summary:    &progress.Summary{Total: 2, Completed: 1, Skipped: 1},
+	}, got)
+
+	// 未指定的参数由配置补充
+	code, body = s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"]}`)
+	s.Equal(http.StatusCreated, code, body)
+	s.decode(body, &created)
+	s.Equal("2", created.ID)
+	s.Equal("/tmp/default", created.Dir)
+}
+
+func (s *ServerTestSuite) TestSubmit_invalid() {
+	code, body := s.do(http.MethodPost, "/jobs", `{"urls": `)
+	s.Equal(http.StatusBadRequest, code)
+	s.Equal(`{"error":"请求体不是合法的JSON: unexpected EOF"}`+"\n", body)
+
+	code, body = s.do(http.MethodPost, "/jobs", `{"dir": "/tmp/docs"}`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Equal(`{"error":"urls是必需参数"}`+"\n", body)
+
+	// 同一个目录同时只能有一个任务在导出
+	code, _ = s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/tmp/block"}`)
+	s.Equal(http.StatusCreated, code)
+	<-s.started
+	code, body = s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/yyy"], "dir": "/tmp/block"}`)
+	s.Equal(http.StatusConflict, code)
+	s.Equal(`{"error":"任务1正在导出到目录: /tmp/block"}`+"\n", body)
+
+	code, body = s.do(http.MethodGet, "/jobs", "")
+	s.Equal(http.StatusOK, code)
+	var jobs []*Job
+	s.decode(body, &jobs)
+	s.Len(jobs, 1, "冲突的任务不会创建")
+}
+
+func (s *ServerTestSuite) TestJobResults() {
+	for _, dir := range []string{"/tmp/partial", "/tmp/failed"} {
+		code, body := s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "`+dir+`"}`)
+		s.Equal(http.StatusCreated, code, body)
+	}
+
+	got := s.wait("1")
+	s.Equal(JobPartiallyFailed, got.Status)
+	s.Equal("部分文档导出失败, 已下载: 1, 已失败: 1, 已跳过: 0", got.Error)
+	s.Equal(&progress.Summary{Total: 2, Completed: 1, Failed: 1}, got.Summary)
+
+	got = s.wait("2")
+	s.Equal(JobFailed, got.Status)
+	s.Equal("logId: https://open.feishu.cn/search?q=xyz, error response: 获取访问凭证失败", got.Error, "去掉终端样式")
+	s.Equal(&progress.Summary{Total: 2, Remaining: 2}, got.Summary)
+
+	code, body := s.do(http.MethodGet, "/jobs", "")
+	s.Equal(http.StatusOK, code)
+	var jobs []*Job
+	s.decode(body, &jobs)
+	s.Len(jobs, 2)
+	s.Equal("1", jobs[0].ID)
+	s.Equal("2", jobs[1].ID)
+}
+
+func (s *ServerTestSuite) TestDocuments() {
+	code, body := s.do(http.MethodGet, "/jobs/1/documents", "")
+	s.Equal(http.StatusNotFound, code)
+	s.Equal(`{"error":"任务不存在: 1"}`+"\n", body)
+
+	code, _ = s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/tmp/partial"}`)
+	s.Equal(http.StatusCreated, code)
+	s.wait("1")
+
+	code, body = s.do(http.MethodGet, "/jobs/1/documents", "")
+	s.Equal(http.StatusOK, code)
+	var files []*progress.FileProgress
+	s.decode(body, &files)
+	s.Require().Len(files, 2)
+	s.Equal("a.docx", files[0].FileName)
+	s.Equal("completed", files[0].Status)
+	s.Equal("b.pdf", files[1].FileName)
+	s.Equal("failed", files[1].Status)
+	s.Equal("logId: https://open.feishu.cn/search?q=abc", files[1].Message)
+
+	code, body = s.do(http.MethodGet, "/jobs/1/documents?status=failed", "")
+	s.Equal(http.StatusOK, code)
+	s.decode(body, &files)
+	s.Require().Len(files, 1)
+	s.Equal("/tmp/b.pdf", files[0].Key)
+}
+
+func (s *ServerTestSuite) TestCancel() {
+	code, body := s.do(http.MethodPost, "/jobs/1/cancel", "")
+	s.Equal(http.StatusNotFound, code, body)
+
+	code, _ = s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/tmp/block"}`)
+	s.Equal(http.StatusCreated, code)
+	<-s.started
+	code, body = s.do(http.MethodPost, "/jobs/1/cancel", "")
+	s.Equal(http.StatusAccepted, code, body)
+
+	got := s.wait("1")
+	s.Equal(JobCanceled, got.Status)
+	s.Equal("context canceled", got.Error)
+	s.Equal(&progress.Summary{Total: 2, Interrupted: 1, Remaining: 2}, got.Summary)
+
+	code, body = s.do(http.MethodPost, "/jobs/1/cancel", "")
+	s.Equal(http.StatusConflict, code)
+	s.Equal(`{"error":"任务1已经结束"}`+"\n", body)
+}
+
+func (s *ServerTestSuite) TestClose() {
+	code, _ := s.do(http.MethodPost, "/jobs", `{"urls": ["https://xxx.feishu.cn/wiki/xxx"], "dir": "/tmp/block"}`)
+	s.Equal(http.StatusCreated, code)
+	<-s.started
+	// 关闭服务时取消运行中的任务，并等待它们结束
+	s.server.Close()
+	_, body := s.do(http.MethodGet, "/jobs/1", "")
+	var got Job
+	s.decode(body, &got)
+	s.Equal(JobCanceled, got.Status)
+}
+
+func (s *ServerTestSuite) TestAuthenticate() {
+	s.server.token = "secret"
+	code, body := s.do(http.MethodGet, "/jobs", "")
+	s.Equal(http.StatusUnauthorized, code)
+	s.Equal(`{"error":"访问令牌不正确"}`+"\n", body)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("[]\n", rec.Body.String())
+	s.Equal("application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+}
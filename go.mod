@@ -7,10 +7,13 @@ require (
 	github.com/charmbracelet/bubbles v0.20.0
 	github.com/charmbracelet/bubbletea v1.3.4 // 终端交互
 	github.com/charmbracelet/lipgloss v1.0.0
+	github.com/charmbracelet/x/ansi v0.8.0
+	github.com/charmbracelet/x/term v0.2.1
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
 	github.com/h2non/gock v1.2.0 // http请求模拟
 	github.com/larksuite/oapi-sdk-go/v3 v3.4.11 // 飞书
 	github.com/pterm/pterm v0.12.80
+	github.com/robfig/cron/v3 v3.0.1 // 定时任务
 	github.com/samber/lo v1.49.1 // 工具
 	github.com/samber/oops v1.17.0 // 错误处理
 	github.com/savioxavier/termlink v1.4.2
@@ -21,6 +24,7 @@ require (
 	github.com/spf13/viper v1.19.0 // 配置
 	github.com/stretchr/testify v1.10.0 // 测试
 	github.com/xlab/treeprint v1.2.0 // 树状结构打印
+	golang.org/x/text v0.23.0 // Unicode规范化
 )
 
 require (
@@ -30,8 +34,6 @@ require (
 	github.com/asaskevich/govalidator v0.0.0-20230301143203-a9d515a09cc2 // indirect
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/charmbracelet/harmonica v0.2.0 // indirect
-	github.com/charmbracelet/x/ansi v0.8.0 // indirect
-	github.com/charmbracelet/x/term v0.2.1 // indirect
 	github.com/containerd/console v1.0.3 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/erikgeiser/coninput v0.0.0-20211004153227-1c3628e74d0f // indirect
@@ -68,7 +70,6 @@ require (
 	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/term v0.26.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
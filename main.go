@@ -25,12 +25,10 @@ import (
 
 // SDK 使用文档：https://open.feishu.cn/document/uAjLw4CM/ukTMukTMukTM/server-side-sdk/golang-sdk-guide/preparations
 func main() {
-	// TODO 导出和下载的协程数量支持配置
 	// TODO 执行日志输出到文件
 	// TODO docx 和 pdf 下载后自动去除水印
 	// TODO 下载UI程序支持快速滚动到顶部和底部、按ctrl+↑向上滚动10%、按ctrl+↓向下滚动10%
 	// TODO 补充更多使用说明，如主程序参数、下载UI状态下的快捷键说明
-	// TODO 支持跳过已下载文件（将下载进度保存到缓存文件中，每次执行都做一下检查）
 	// TODO 支持交互式输入进行操作
 	// 执行命令
 	if args, err := cmd.Execute(&cmd.XdocCommand{}); err != nil {
@@ -42,6 +40,6 @@ func main() {
 			app.Fprintf(os.Stderr, "%v\n", err)
 		}
 		app.Fprintln(os.Stderr, "----------------------------------------------")
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }